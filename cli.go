@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"atg_go/internal/a_technical/config"
+	"atg_go/pkg/redact"
+	"atg_go/pkg/storage"
+	tgsessions "atg_go/pkg/telegram/accounts_sessions_disconnect"
+	stats "atg_go/pkg/telegram/invite_activities_statistics"
+)
+
+const usage = `Использование: atg_go <команда> [аргументы]
+
+Команды:
+  serve                  запустить HTTP-сервер и фоновые процессы (по умолчанию)
+  migrate up             применить неприменённые миграции
+  migrate status         показать состояние миграций
+  accounts check         проверить доступ ко всем авторизованным аккаунтам
+  orders reassign        распределить свободные аккаунты по заказам
+  stats collect          пересчитать статистику активностей за сутки
+  counters check [-fix]  сверить счётчики заказов, прокси и подписок с реальными строками
+  jobs list [-kind K] [-status S] [-limit N]
+                         показать фоновые задания
+`
+
+// cliCommand — команда обслуживания, работающая с уже открытым хранилищем.
+type cliCommand func(db *storage.DB, args []string) error
+
+// cliCommands — команды вида "<группа> <действие>".
+var cliCommands = map[string]cliCommand{
+	"migrate up":      migrateUp,
+	"migrate status":  migrateStatus,
+	"accounts check":  accountsCheck,
+	"orders reassign": ordersReassign,
+	"stats collect":   statsCollect,
+	"counters check":  countersCheck,
+	"jobs list":       jobsList,
+}
+
+// runCommand выполняет команду из аргументов и возвращает код завершения процесса.
+func runCommand(cfg config.Config, args []string) int {
+	if len(args) == 0 || args[0] == "serve" {
+		if err := serve(cfg); err != nil {
+			log.Printf("[ERROR] %v", err)
+			return 1
+		}
+		return 0
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(os.Stdout, usage)
+		return 0
+	}
+	if len(args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	cmd, ok := cliCommands[args[0]+" "+args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "неизвестная команда: %s %s\n\n%s", args[0], args[1], usage)
+		return 2
+	}
+
+	dbConn, err := openDB(cfg)
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		return 1
+	}
+	defer dbConn.Close()
+
+	db := storage.NewDB(dbConn)
+	db.QueryTimeout = cfg.DBQueryTimeout
+	if err := cmd(db, args[2:]); err != nil {
+		log.Printf("[ERROR] %s %s: %v", args[0], args[1], err)
+		return 1
+	}
+	return 0
+}
+
+// migrateUp применяет неприменённые миграции и печатает их версии.
+func migrateUp(db *storage.DB, _ []string) error {
+	applied, err := db.MigrateUp(context.Background())
+	for _, v := range applied {
+		fmt.Printf("applied %s\n", v)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("миграции не требуются")
+	}
+	return nil
+}
+
+// migrateStatus печатает встроенные миграции с отметкой о применении.
+func migrateStatus(db *storage.DB, _ []string) error {
+	states, err := db.MigrationStatus(context.Background())
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED AT")
+	for _, s := range states {
+		if s.AppliedAt == nil {
+			fmt.Fprintf(w, "%s\tpending\t-\n", s.Version)
+			continue
+		}
+		fmt.Fprintf(w, "%s\tapplied\t%s\n", s.Version, s.AppliedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+// accountsCheck проверяет доступ к аккаунтам и печатает маскированные телефоны потерянных:
+// вывод команды часто попадает в логи cron и CI.
+// Проверка долгая, поэтому Ctrl+C прерывает её, не помечая аккаунты потерянными.
+func accountsCheck(db *storage.DB, _ []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	lost, err := tgsessions.CheckAccountsState(ctx, db)
+	if err != nil {
+		return err
+	}
+	if len(lost) == 0 {
+		fmt.Println("доступ есть ко всем аккаунтам")
+		return nil
+	}
+	fmt.Printf("потерян доступ к %d аккаунтам:\n", len(lost))
+	for _, phone := range lost {
+		fmt.Println(redact.Phone(phone))
+	}
+	return nil
+}
+
+// ordersReassign запускает распределение свободных аккаунтов по заказам.
+func ordersReassign(db *storage.DB, _ []string) error {
+	if err := db.AssignFreeAccountsToOrders(context.Background()); err != nil {
+		return err
+	}
+	fmt.Println("свободные аккаунты распределены")
+	return nil
+}
+
+// statsCollect пересчитывает статистику активностей за текущие сутки.
+func statsCollect(db *storage.DB, _ []string) error {
+	stat, err := stats.Calculate(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("статистика за %s: комментариев %d, реакций %d, аккаунтов %d (во флуд-бане %d)\n",
+		stat.Date.Format("2006-01-02"), stat.CommentAll, stat.ReactionAll, stat.AccountAll, stat.AccountFloodBan)
+	return nil
+}
+
+// countersCheck печатает расхождения счётчиков; с -fix исправляет исправимые.
+func countersCheck(db *storage.DB, args []string) error {
+	fs := flag.NewFlagSet("counters check", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fix := fs.Bool("fix", false, "исправить расхождения")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	list, err := db.CheckCounters(context.Background(), *fix)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Println("расхождений нет")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK	ID	STORED	ACTUAL	STATUS")
+	for _, m := range list {
+		status := "mismatch"
+		switch {
+		case m.Fixed:
+			status = "fixed"
+		case !m.Fixable:
+			status = "report only"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", m.Check, m.EntityID, m.Stored, m.Actual, status)
+	}
+	return w.Flush()
+}
+
+// jobsList печатает фоновые задания с фильтрами -kind, -status и -limit.
+func jobsList(db *storage.DB, args []string) error {
+	fs := flag.NewFlagSet("jobs list", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	var f storage.JobFilter
+	fs.StringVar(&f.Kind, "kind", "", "тип задания")
+	fs.StringVar(&f.Status, "status", "", "статус задания")
+	fs.IntVar(&f.Limit, "limit", 50, "количество заданий")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	list, err := db.ListJobs(context.Background(), f)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tKIND\tSTATUS\tPROGRESS\tCREATED AT")
+	for _, j := range list {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d/%d (ошибок %d)\t%s\n",
+			j.ID, j.Kind, j.Status, j.Processed, j.Total, j.Failed, j.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
@@ -1,9 +1,12 @@
 package order
 
 import (
+	"database/sql"
+	"errors"
 	"log"
 	"strconv"
 
+	"atg_go/internal/a_technical/dto"
 	"atg_go/internal/a_technical/httputil"
 	"atg_go/models"
 	"atg_go/pkg/storage"
@@ -31,19 +34,19 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	created, err := h.DB.CreateOrder(o)
+	created, err := h.DB.CreateOrder(c.Request.Context(), o)
 	if err != nil {
 		log.Printf("[ERROR] не удалось создать заказ: %v", err)
 		httputil.RespondError(c, 500, "db error")
 		return
 	}
 
-	c.JSON(200, created)
+	c.JSON(200, dto.NewOrder(*created))
 }
 
 // GetCategories возвращает список доступных категорий из таблицы categories
 func (h *Handler) GetCategories(c *gin.Context) {
-	names, err := h.DB.GetCategoryNames()
+	names, err := h.DB.GetCategoryNames(c.Request.Context())
 	if err != nil {
 		httputil.RespondError(c, 500, "db error")
 		return
@@ -61,22 +64,79 @@ func (h *Handler) UpdateAccountsNumber(c *gin.Context) {
 		httputil.RespondError(c, 400, "invalid data")
 		return
 	}
-	updated, err := h.DB.UpdateOrderAccountsNumber(id, input.AccountsNumberTheory)
+	updated, err := h.DB.UpdateOrderAccountsNumber(c.Request.Context(), id, input.AccountsNumberTheory)
+	if errors.Is(err, storage.ErrOrderArchived) {
+		httputil.RespondError(c, 409, err.Error())
+		return
+	}
 	if err != nil {
 		log.Printf("[ERROR] не удалось обновить заказ: %v", err)
 		httputil.RespondError(c, 500, "db error")
 		return
 	}
-	c.JSON(200, updated)
+	c.JSON(200, dto.NewOrder(*updated))
 }
 
-// DeleteOrder удаляет заказ и освобождает связанные аккаунты
+// DeleteOrder переводит заказ в архив: аккаунты освобождаются, история сохраняется.
+// Физическое удаление больше не выполняется, чтобы каскад не стирал посты и отчёты.
 func (h *Handler) DeleteOrder(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	if err := h.DB.DeleteOrder(id); err != nil {
-		log.Printf("[ERROR] не удалось удалить заказ: %v", err)
+	h.setStatus(c, id, models.OrderStatusArchived)
+}
+
+// SetStatus меняет статус заказа: active, paused или archived
+func (h *Handler) SetStatus(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		Status string `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || !models.ValidOrderStatus(input.Status) {
+		httputil.RespondError(c, 400, "status должен быть active, paused или archived")
+		return
+	}
+	h.setStatus(c, id, input.Status)
+}
+
+func (h *Handler) setStatus(c *gin.Context, id int, status string) {
+	o, err := h.DB.SetOrderStatus(c.Request.Context(), id, status)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		httputil.RespondError(c, 404, "order not found")
+		return
+	case errors.Is(err, storage.ErrOrderURLTaken):
+		httputil.RespondError(c, 409, err.Error())
+		return
+	case err != nil:
+		log.Printf("[ERROR] не удалось сменить статус заказа %d: %v", id, err)
+		httputil.RespondError(c, 500, "db error")
+		return
+	}
+	c.JSON(200, dto.NewOrder(*o))
+}
+
+// ListOrders возвращает заказы с фильтром ?status= и ?limit=
+func (h *Handler) ListOrders(c *gin.Context) {
+	var f storage.OrderFilter
+	if v := c.Query("status"); v != "" {
+		if !models.ValidOrderStatus(v) {
+			httputil.RespondError(c, 400, "некорректный status")
+			return
+		}
+		f.Status = v
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httputil.RespondError(c, 400, "некорректный limit")
+			return
+		}
+		f.Limit = n
+	}
+	list, err := h.DB.ListOrders(c.Request.Context(), f)
+	if err != nil {
+		log.Printf("[ERROR] не удалось получить заказы: %v", err)
 		httputil.RespondError(c, 500, "db error")
 		return
 	}
-	c.JSON(200, gin.H{"status": "deleted"})
+	c.JSON(200, gin.H{"orders": dto.NewOrders(list)})
 }
@@ -11,6 +11,8 @@ func SetupRoutes(r *gin.RouterGroup, db *storage.DB) {
 	h := NewHandler(db)
 	r.POST("/CreateOrder", h.CreateOrder)
 	r.POST("/UpdateAccounts/:id", h.UpdateAccountsNumber)
-	r.DELETE("/DeleteOrder/:id", h.DeleteOrder)
+	r.DELETE("/DeleteOrder/:id", h.DeleteOrder) // Архивирует заказ, данные не удаляются
+	r.POST("/SetStatus/:id", h.SetStatus)
+	r.GET("/ListOrders", h.ListOrders)
 	r.GET("/OrderCategories", h.GetCategories) // Возвращаем список категорий для выпадающего списка
 }
@@ -2,85 +2,99 @@ package activity
 
 import (
 	"atg_go/internal/a_technical/common"
-	"atg_go/internal/a_technical/httputil"
+	"atg_go/internal/a_technical/jobs"
 	"atg_go/models"
+	"atg_go/pkg/clock"
 	"atg_go/pkg/storage"
+	"atg_go/pkg/telegram/a_technical/floodwait"
+	"context"
 	"errors"
+	"fmt"
 	"log"
-	"math/rand"
-	"net/http"
 	"time"
-
-	"github.com/gin-gonic/gin"
 )
 
+// rnd задаёт случайные паузы между аккаунтами.
+var rnd = clock.NewRandomRNG()
+
 // ProcessAccounts выполняет общие шаги массовых операций над аккаунтами.
 // Выносим задержки и выбор каналов сюда, чтобы не дублировать код в хэндлерах.
 // Коллбэк отвечает только за конкретное действие (комментарий, реакция) и
-// сообщает, было ли оно успешным.
+// сообщает, было ли оно успешным. Ход обработки отражается в progress задания.
 func ProcessAccounts(
-	c *gin.Context,
+	ctx context.Context,
 	accounts []models.Account,
 	commentDB *storage.CommentDB,
+	progress *jobs.Progress,
 	send func(models.Account, string) (bool, error),
 ) (successCount, errorCount int, err error) {
-	// Инициализируем генератор случайных чисел один раз, чтобы паузы и
-	// выбор каналов не повторяли один и тот же шаблон.
-	rand.Seed(time.Now().UnixNano())
-
 	// Максимальное количество попыток для одного аккаунта.
 	const maxAttempts = 10
 
+	progress.SetTotal(len(accounts))
+
 	for i, account := range accounts {
 		if i > 0 {
 			// Пауза между аккаунтами делает активность менее подозрительной.
-			log.Printf("[HANDLER] Аккаунт %s обработан. Ожидание перед следующим...", accounts[i-1].Phone)
-			if err := common.WaitWithCancellation(c.Request.Context(), [2]int{6, 15}); err != nil {
-				// При отмене запроса прекращаем работу, чтобы не тратить ресурсы зря.
-				log.Printf("[HANDLER WARN] Запрос отменён во время ожидания: %v", err)
-				c.JSON(http.StatusRequestTimeout, gin.H{
-					"status":     "Cancelled during delay",
-					"processed":  i,
-					"successful": successCount,
-					"failed":     errorCount,
-				})
+			log.Printf("[HANDLER] Аккаунт %d обработан. Ожидание перед следующим...", accounts[i-1].ID)
+			if err := common.WaitWithCancellation(ctx, clock.System, rnd, [2]int{6, 15}); err != nil {
+				// При отмене задания прекращаем работу, чтобы не тратить ресурсы зря.
+				log.Printf("[HANDLER WARN] Задание отменено во время ожидания: %v", err)
 				return successCount, errorCount, err
 			}
 		}
+		progress.SetCurrent(fmt.Sprintf("аккаунт %d", account.ID))
+		// Аккаунт под флуд-вейтом пропускаем: запросы всё равно будут отклонены
+		if until, ok := floodwait.Until(account.ID); ok {
+			log.Printf("[HANDLER INFO] Аккаунт %d под флуд-вейтом до %s, пропуск", account.ID, until.Format(time.RFC3339))
+			progress.Skip()
+			continue
+		}
 
 		// Несколько попыток выполнить действие: пока не удастся или не исчерпаем лимит.
 		for attempt := 1; attempt <= maxAttempts; attempt++ {
 			// Выбираем канал для текущей попытки. Отсутствие каналов считаем фатальной ошибкой.
-			channelURL, err := storage.PickRandomChannel(commentDB, *account.OrderID)
+			channelURL, err := storage.PickRandomChannel(ctx, commentDB, *account.OrderID)
 			if err != nil {
 				if errors.Is(err, storage.ErrNoChannel) {
 					log.Printf("[HANDLER ERROR] Нет доступных каналов: %v", err)
-					httputil.RespondError(c, http.StatusNotFound, "No channels available")
 					return successCount, errorCount, err
 				}
 				// Прочие ошибки фиксируем и пробуем ещё раз.
-				log.Printf("[HANDLER WARN] Ошибка выбора канала для %s (попытка %d): %v", account.Phone, attempt, err)
+				log.Printf("[HANDLER WARN] Ошибка выбора канала для аккаунта %d (попытка %d): %v", account.ID, attempt, err)
 				if attempt == maxAttempts {
 					errorCount++
+					progress.Fail()
 				}
 				continue
 			}
-			log.Printf("[HANDLER INFO] Выбран канал для %s: %s (попытка %d)", account.Phone, channelURL, attempt)
+			log.Printf("[HANDLER INFO] Выбран канал для аккаунта %d: %s (попытка %d)", account.ID, channelURL, attempt)
 
 			// Коллбэк отправляет действие и возвращает, было ли оно успешным.
 			ok, err := send(account, channelURL)
 			if err != nil {
-				log.Printf("[HANDLER WARN] Ошибка обработки для %s (попытка %d): %v", account.Phone, attempt, err)
+				log.Printf("[HANDLER WARN] Ошибка обработки для аккаунта %d (попытка %d): %v", account.ID, attempt, err)
+				// Повторять попытки до окончания флуд-вейта бессмысленно
+				if errors.Is(err, floodwait.ErrActive) {
+					errorCount++
+					progress.Fail()
+					break
+				}
 				if attempt == maxAttempts {
 					errorCount++
+					progress.Fail()
 				}
 				continue
 			}
 			if ok {
 				successCount++
+				progress.Success()
 			} else if attempt == maxAttempts {
 				// Все попытки исчерпаны, результат не получен.
 				errorCount++
+				progress.Fail()
+			} else {
+				progress.Skip()
 			}
 			break
 		}
@@ -2,15 +2,17 @@ package common
 
 import (
 	"context"
-	"math/rand"
 	"time"
+
+	"atg_go/pkg/clock"
 )
 
 // WaitWithCancellation выполняет ожидание в случайном диапазоне и
 // регулярно проверяет контекст на отмену, чтобы не блокировать долгие задержки.
 // Используем шаг в пять секунд, чтобы можно было вовремя завершить работу по требованию.
-func WaitWithCancellation(ctx context.Context, delayRange [2]int) error {
-	delay := rand.Intn(delayRange[1]-delayRange[0]+1) + delayRange[0]
+// Часы и генератор передаются явно, чтобы ожидание можно было проверить на фейковых часах.
+func WaitWithCancellation(ctx context.Context, clk clock.Clock, rng clock.RNG, delayRange [2]int) error {
+	delay := rng.Intn(delayRange[1]-delayRange[0]+1) + delayRange[0]
 	for remaining := delay; remaining > 0; {
 		step := 5
 		if remaining < step {
@@ -20,7 +22,7 @@ func WaitWithCancellation(ctx context.Context, delayRange [2]int) error {
 		case <-ctx.Done():
 			// Возвращаем ошибку контекста, чтобы вызвать обработку прерывания выше по стеку.
 			return ctx.Err()
-		case <-time.After(time.Duration(step) * time.Second):
+		case <-clk.After(time.Duration(step) * time.Second):
 		}
 		remaining -= step
 	}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"atg_go/pkg/clock"
+)
+
+// TestWaitWithCancellation проверяет ожидание шагами по пять секунд на фейковых часах.
+func TestWaitWithCancellation(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 9, 8, 0, 0, 0, 0, time.UTC))
+	done := make(chan error, 1)
+	go func() {
+		// Диапазон из одного значения исключает случайность: ждём ровно 12 секунд
+		done <- WaitWithCancellation(context.Background(), fake, clock.NewRNG(1), [2]int{12, 12})
+	}()
+
+	// Шаги 5, 5 и 2 секунды
+	for _, step := range []time.Duration{5, 5, 2} {
+		waitForTimer(t, fake)
+		fake.Advance(step * time.Second)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("неожиданная ошибка: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("ожидание не завершилось")
+	}
+}
+
+// TestWaitWithCancellationCancelled проверяет досрочный выход при отмене контекста.
+func TestWaitWithCancellationCancelled(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 9, 8, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- WaitWithCancellation(ctx, fake, clock.NewRNG(1), [2]int{60, 60})
+	}()
+
+	waitForTimer(t, fake)
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("ожидалась context.Canceled, получено %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("отмена не прервала ожидание")
+	}
+}
+
+// waitForTimer ждёт, пока проверяемый код встанет на таймер фейковых часов.
+func waitForTimer(t *testing.T, fake *clock.Fake) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for fake.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("код не начал ожидание")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
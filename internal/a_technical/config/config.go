@@ -0,0 +1,91 @@
+// Package config собирает настройки приложения из переменных окружения.
+// HTTP-сервер и команды CLI используют одну конфигурацию, чтобы не расходиться в подключении к БД.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"atg_go/pkg/storage"
+)
+
+// Config — настройки процесса.
+type Config struct {
+	DatabaseURL            string        // DATABASE_URL
+	Port                   string        // PORT, по умолчанию 8080
+	ScheduledActionWorkers int           // SCHEDULED_ACTIONS_WORKERS, по умолчанию 10
+	DryRun                 bool          // ATG_DRY_RUN
+	ArchiveDir             string        // ATG_ARCHIVE_DIR, каталог архивов очистки; по умолчанию archive
+	IdempotencyTTL         time.Duration // ATG_IDEMPOTENCY_TTL, срок хранения ключей идемпотентности; по умолчанию 24h
+	LogRedaction           string        // LOG_REDACTION: off, mask или strict; по умолчанию mask
+	DBQueryTimeout         time.Duration // ATG_DB_QUERY_TIMEOUT, срок одного вызова хранилища; по умолчанию 30s, 0 — без ограничения
+	// Пул соединений: ATG_DB_MAX_OPEN_CONNS (25), ATG_DB_MAX_IDLE_CONNS (10),
+	// ATG_DB_CONN_MAX_IDLE_TIME (5m), ATG_DB_CONN_MAX_LIFETIME (30m)
+	DBPool storage.PoolConfig
+	// ATG_LEADER_INTERVAL — период попыток стать ведущей репликой и проверки блокировки; по умолчанию 5s
+	LeaderInterval time.Duration
+	// ATG_DISABLED_MODULES — модули общей сессии Telegram через запятую, выключенные при старте
+	DisabledModules []string
+	// ATG_SECRET_KEY — ключ AES-256 (32 байта в base64 или hex) для паролей 2FA аккаунтов
+	SecretKey string
+}
+
+// Load читает конфигурацию из окружения, подставляя значения по умолчанию.
+func Load() Config {
+	cfg := Config{
+		DatabaseURL:            storage.DSN(),
+		Port:                   "8080",
+		ScheduledActionWorkers: 10,
+		ArchiveDir:             "archive",
+		IdempotencyTTL:         24 * time.Hour,
+		DBQueryTimeout:         storage.DefaultQueryTimeout,
+		LeaderInterval:         5 * time.Second,
+		DBPool: storage.PoolConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnMaxLifetime: 30 * time.Minute,
+		},
+	}
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Port = port
+	}
+	if n, err := strconv.Atoi(os.Getenv("SCHEDULED_ACTIONS_WORKERS")); err == nil && n > 0 {
+		cfg.ScheduledActionWorkers = n
+	}
+	if dir := os.Getenv("ATG_ARCHIVE_DIR"); dir != "" {
+		cfg.ArchiveDir = dir
+	}
+	if d, err := time.ParseDuration(os.Getenv("ATG_IDEMPOTENCY_TTL")); err == nil && d > 0 {
+		cfg.IdempotencyTTL = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("ATG_DB_QUERY_TIMEOUT")); err == nil && d >= 0 {
+		cfg.DBQueryTimeout = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("ATG_DB_MAX_OPEN_CONNS")); err == nil && n >= 0 {
+		cfg.DBPool.MaxOpenConns = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("ATG_DB_MAX_IDLE_CONNS")); err == nil && n > 0 {
+		cfg.DBPool.MaxIdleConns = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("ATG_DB_CONN_MAX_IDLE_TIME")); err == nil && d >= 0 {
+		cfg.DBPool.ConnMaxIdleTime = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("ATG_DB_CONN_MAX_LIFETIME")); err == nil && d >= 0 {
+		cfg.DBPool.ConnMaxLifetime = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("ATG_LEADER_INTERVAL")); err == nil && d > 0 {
+		cfg.LeaderInterval = d
+	}
+	for _, name := range strings.Split(os.Getenv("ATG_DISABLED_MODULES"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.DisabledModules = append(cfg.DisabledModules, name)
+		}
+	}
+	cfg.SecretKey = os.Getenv("ATG_SECRET_KEY")
+	cfg.LogRedaction = os.Getenv("LOG_REDACTION")
+	cfg.DryRun, _ = strconv.ParseBool(os.Getenv("ATG_DRY_RUN"))
+	return cfg
+}
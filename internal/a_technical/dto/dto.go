@@ -0,0 +1,173 @@
+// Package dto описывает представление сущностей в ответах API.
+// Поля перечисляются явно: секреты аккаунтов и прокси (api_hash, phone_code_hash,
+// логин и пароль прокси) в ответы не попадают, телефоны маскируются.
+// Модели из пакета models напрямую в ответах не используются.
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"atg_go/models"
+	"atg_go/pkg/redact"
+)
+
+// MaskPhone скрывает середину номера, оставляя код страны и две последние цифры:
+// +79991234567 → +79*******67. Короткие номера скрываются целиком.
+func MaskPhone(phone string) string {
+	return redact.Phone(phone)
+}
+
+// MaskPhones маскирует список номеров.
+func MaskPhones(phones []string) []string {
+	out := make([]string, len(phones))
+	for i, p := range phones {
+		out[i] = MaskPhone(p)
+	}
+	return out
+}
+
+// Account — аккаунт в ответах API.
+type Account struct {
+	ID                       int      `json:"id"`
+	Phone                    string   `json:"phone"` // Маскированный номер
+	ApiID                    int      `json:"api_id"`
+	IsAuthorized             bool     `json:"is_authorized"`
+	AccountMonitoring        bool     `json:"account_monitoring"`
+	AccountGeneratorCategory bool     `json:"account_generator_category"`
+	Gender                   []string `json:"gender"`
+	ProxyID                  *int     `json:"proxy_id"`
+	OrderID                  *int     `json:"order_id"`
+	Proxy                    *Proxy   `json:"proxy"`
+}
+
+// NewAccount формирует представление аккаунта.
+func NewAccount(a models.Account) Account {
+	return Account{
+		ID:                       a.ID,
+		Phone:                    MaskPhone(a.Phone),
+		ApiID:                    a.ApiID,
+		IsAuthorized:             a.IsAuthorized,
+		AccountMonitoring:        a.AccountMonitoring,
+		AccountGeneratorCategory: a.AccountGeneratorCategory,
+		Gender:                   a.Gender,
+		ProxyID:                  a.ProxyID,
+		OrderID:                  a.OrderID,
+		Proxy:                    NewProxy(a.Proxy),
+	}
+}
+
+// NewAccounts формирует представление списка аккаунтов.
+func NewAccounts(list []models.Account) []Account {
+	out := make([]Account, 0, len(list))
+	for _, a := range list {
+		out = append(out, NewAccount(a))
+	}
+	return out
+}
+
+// Proxy — прокси в ответах API. Учётные данные заменены признаком HasAuth.
+type Proxy struct {
+	ID            int    `json:"id"`
+	IP            string `json:"ip"`
+	Port          int    `json:"port"`
+	IPv6          string `json:"ipv6"`
+	HasAuth       bool   `json:"has_auth"` // Задан ли логин для подключения
+	AccountsCount int    `json:"accounts_count"`
+	IsActive      *bool  `json:"is_active"`
+}
+
+// NewProxy формирует представление прокси; nil остаётся nil.
+func NewProxy(p *models.Proxy) *Proxy {
+	if p == nil {
+		return nil
+	}
+	out := &Proxy{
+		ID:            p.ID,
+		IP:            p.IP,
+		Port:          p.Port,
+		IPv6:          p.IPv6,
+		HasAuth:       p.Login != "",
+		AccountsCount: p.AccountsCount,
+	}
+	if p.IsActive.Valid {
+		active := p.IsActive.Bool
+		out.IsActive = &active
+	}
+	return out
+}
+
+// Order — заказ в ответах API.
+type Order struct {
+	ID                   int        `json:"id"`
+	Name                 string     `json:"name"`
+	Category             []string   `json:"category"`
+	URLDescription       string     `json:"url_description"`
+	URLDefault           string     `json:"url_default"`
+	ChannelTGID          *string    `json:"channel_tgid"`
+	AccountsNumberTheory int        `json:"accounts_number_theory"`
+	AccountsNumberFact   int        `json:"accounts_number_fact"`
+	SubsActiveCount      *int       `json:"subs_active_count"`
+	PostReactions        []string   `json:"post_reactions"`
+	Gender               []string   `json:"gender"`
+	Status               string     `json:"status"`
+	ArchivedAt           *time.Time `json:"archived_at"`
+	DateTime             time.Time  `json:"date_time"`
+}
+
+// NewOrder формирует представление заказа.
+func NewOrder(o models.Order) Order {
+	return Order{
+		ID:                   o.ID,
+		Name:                 o.Name,
+		Category:             o.Category,
+		URLDescription:       o.URLDescription,
+		URLDefault:           o.URLDefault,
+		ChannelTGID:          o.ChannelTGID,
+		AccountsNumberTheory: o.AccountsNumberTheory,
+		AccountsNumberFact:   o.AccountsNumberFact,
+		SubsActiveCount:      o.SubsActiveCount,
+		PostReactions:        o.PostReactions,
+		Gender:               o.Gender,
+		Status:               o.Status,
+		ArchivedAt:           o.ArchivedAt,
+		DateTime:             o.DateTime,
+	}
+}
+
+// NewOrders формирует представление списка заказов.
+func NewOrders(list []models.Order) []Order {
+	out := make([]Order, 0, len(list))
+	for _, o := range list {
+		out = append(out, NewOrder(o))
+	}
+	return out
+}
+
+// ChannelDuplicate — настройка дублирования канала в ответах API.
+type ChannelDuplicate struct {
+	ID               int             `json:"id"`
+	OrderID          int             `json:"order_id"`
+	URLChannelDonor  string          `json:"url_channel_donor"`
+	ChannelDonorTGID *string         `json:"channel_donor_tgid"`
+	PostTextRemove   *string         `json:"post_text_remove"`
+	PostTextAdd      *string         `json:"post_text_add"`
+	PostSkip         json.RawMessage `json:"post_skip"`
+	LastPostID       *int            `json:"last_post_id"`
+	PostCountDay     []string        `json:"post_count_day"`
+}
+
+// NewChannelDuplicate формирует представление настройки дублирования.
+func NewChannelDuplicate(cd models.ChannelDuplicate) ChannelDuplicate {
+	return ChannelDuplicate{
+		ID:               cd.ID,
+		OrderID:          cd.OrderID,
+		URLChannelDonor:  cd.URLChannelDonor,
+		ChannelDonorTGID: cd.ChannelDonorTGID,
+		PostTextRemove:   cd.PostTextRemove,
+		PostTextAdd:      cd.PostTextAdd,
+		PostSkip:         cd.PostSkip,
+		LastPostID:       cd.LastPostID,
+		PostCountDay:     cd.PostCountDay,
+	}
+}
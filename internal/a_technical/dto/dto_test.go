@@ -0,0 +1,132 @@
+package dto
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"atg_go/models"
+)
+
+// allowed — поля, которые разрешено отдавать в API. Новое поле DTO должно быть
+// добавлено сюда осознанно, иначе тест упадёт.
+var allowed = map[reflect.Type][]string{
+	reflect.TypeOf(Account{}): {"id", "phone", "api_id", "is_authorized", "account_monitoring",
+		"account_generator_category", "gender", "proxy_id", "order_id", "proxy"},
+	reflect.TypeOf(Proxy{}): {"id", "ip", "port", "ipv6", "has_auth", "accounts_count", "is_active"},
+	reflect.TypeOf(Order{}): {"id", "name", "category", "url_description", "url_default", "channel_tgid",
+		"accounts_number_theory", "accounts_number_fact", "subs_active_count", "post_reactions", "gender",
+		"status", "archived_at", "date_time"},
+	reflect.TypeOf(ChannelDuplicate{}): {"id", "order_id", "url_channel_donor", "channel_donor_tgid",
+		"post_text_remove", "post_text_add", "post_skip", "last_post_id", "post_count_day"},
+}
+
+// hidden — поля моделей, которые в API не отдаются.
+var hidden = map[reflect.Type][]string{
+	reflect.TypeOf(models.Account{}): {"api_hash", "phone_code_hash"},
+	reflect.TypeOf(models.Proxy{}):   {"login", "password"},
+}
+
+// modelDTO сопоставляет модели с их представлением в API.
+var modelDTO = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(models.Account{}):          reflect.TypeOf(Account{}),
+	reflect.TypeOf(models.Proxy{}):            reflect.TypeOf(Proxy{}),
+	reflect.TypeOf(models.Order{}):            reflect.TypeOf(Order{}),
+	reflect.TypeOf(models.ChannelDuplicate{}): reflect.TypeOf(ChannelDuplicate{}),
+}
+
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// TestDTOFieldsAllowList проверяет, что DTO сериализуют ровно разрешённые поля.
+func TestDTOFieldsAllowList(t *testing.T) {
+	for typ, want := range allowed {
+		want = append([]string(nil), want...)
+		sort.Strings(want)
+		if got := jsonFields(typ); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: поля %v, разрешены %v", typ.Name(), got, want)
+		}
+	}
+}
+
+// TestModelFieldsClassified проверяет, что каждое поле модели либо отдаётся через DTO,
+// либо явно помечено скрытым: новое поле модели требует решения, показывать ли его.
+func TestModelFieldsClassified(t *testing.T) {
+	for model, view := range modelDTO {
+		for _, f := range jsonFields(model) {
+			inDTO := contains(allowed[view], f)
+			isHidden := contains(hidden[model], f)
+			switch {
+			case inDTO && isHidden:
+				t.Errorf("%s.%s скрыто, но отдаётся в API", model.Name(), f)
+			case !inDTO && !isHidden:
+				t.Errorf("%s.%s: добавьте поле в DTO или в список скрытых", model.Name(), f)
+			}
+		}
+	}
+}
+
+// TestNoSecretsInJSON проверяет, что значения секретов не попадают в ответ.
+func TestNoSecretsInJSON(t *testing.T) {
+	acc := models.Account{
+		ID:            1,
+		Phone:         "+79991234567",
+		ApiHash:       "secret-api-hash",
+		PhoneCodeHash: "secret-code-hash",
+		Proxy: &models.Proxy{
+			ID:       2,
+			Login:    "secret-login",
+			Password: "secret-password",
+			IsActive: sql.NullBool{Bool: true, Valid: true},
+		},
+	}
+	raw, err := json.Marshal(NewAccounts([]models.Account{acc}))
+	if err != nil {
+		t.Fatalf("сериализация: %v", err)
+	}
+	out := string(raw)
+	for _, secret := range []string{"secret", "+79991234567", "1234"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("в ответ попало %q: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, `"phone":"+79*******67"`) || !strings.Contains(out, `"has_auth":true`) {
+		t.Errorf("неожиданное представление: %s", out)
+	}
+}
+
+// TestMaskPhone проверяет маскирование номеров разной длины.
+func TestMaskPhone(t *testing.T) {
+	cases := map[string]string{
+		"+79991234567": "+79*******67",
+		"79991234567":  "79*******67",
+		"+123":         "+***",
+		"":             "",
+	}
+	for in, want := range cases {
+		if got := MaskPhone(in); got != want {
+			t.Errorf("MaskPhone(%q) = %q, ожидалось %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package flags управляет флагами подсистем через API.
+package flags
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"atg_go/internal/a_technical/httputil"
+	"atg_go/pkg/featureflags"
+	"atg_go/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultActor записывается в журнал, если запрос не передал X-Actor.
+const defaultActor = "api"
+
+// Handler обрабатывает запросы к флагам подсистем.
+type Handler struct {
+	DB    *storage.DB
+	Cache *featureflags.Cache
+}
+
+// NewHandler создаёт обработчик флагов.
+func NewHandler(db *storage.DB, cache *featureflags.Cache) *Handler {
+	return &Handler{DB: db, Cache: cache}
+}
+
+// ListFlags обрабатывает GET /feature_flags.
+func (h *Handler) ListFlags(c *gin.Context) {
+	list, err := h.DB.GetFeatureFlags(c.Request.Context())
+	if err != nil {
+		log.Printf("[ERROR] получение флагов: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"flags": list})
+}
+
+// UpdateFlag обрабатывает PUT /feature_flags/:name.
+// Изменение пишется в audit_log; автор берётся из заголовка X-Actor.
+func (h *Handler) UpdateFlag(c *gin.Context) {
+	var input struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Enabled == nil {
+		httputil.RespondError(c, http.StatusBadRequest, "ожидается enabled")
+		return
+	}
+	actor := c.GetHeader("X-Actor")
+	if actor == "" {
+		actor = defaultActor
+	}
+
+	flag, err := h.DB.SetFeatureFlag(c.Request.Context(), c.Param("name"), *input.Enabled, actor)
+	if err == sql.ErrNoRows {
+		httputil.RespondError(c, http.StatusNotFound, "флаг не найден")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] изменение флага %s: %v", c.Param("name"), err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	// Остальные реплики получат изменение через уведомление, эта — сразу
+	h.Cache.Set(flag.Name, flag.Enabled)
+	log.Printf("[FEATURE FLAGS] %s: enabled=%t (%s)", flag.Name, flag.Enabled, actor)
+	c.JSON(http.StatusOK, flag)
+}
+
+// ListAudit обрабатывает GET /feature_flags/audit?limit=N — история изменений флагов.
+func (h *Handler) ListAudit(c *gin.Context) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httputil.RespondError(c, http.StatusBadRequest, "некорректный limit")
+			return
+		}
+		limit = n
+	}
+	entries, err := h.DB.GetAuditLog(c.Request.Context(), "feature_flag", limit)
+	if err != nil {
+		log.Printf("[ERROR] журнал изменений флагов: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
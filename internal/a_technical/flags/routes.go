@@ -0,0 +1,16 @@
+package flags
+
+import (
+	"atg_go/pkg/featureflags"
+	"atg_go/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes регистрирует маршруты флагов подсистем.
+func SetupRoutes(r *gin.RouterGroup, db *storage.DB, cache *featureflags.Cache) {
+	handler := NewHandler(db, cache)
+	r.GET("", handler.ListFlags)
+	r.GET("/audit", handler.ListAudit)
+	r.PUT("/:name", handler.UpdateFlag)
+}
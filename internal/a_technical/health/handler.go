@@ -0,0 +1,183 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	telegram "atg_go/internal/a_technical/telegram"
+	"atg_go/migrations"
+	"atg_go/pkg/storage"
+	tgmonitor "atg_go/pkg/telegram/a_technical/monitoring"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Статусы компонентов и итогового отчёта.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+	// StatusSkipped — компонент не работает на этой реплике по назначению (она не ведущая)
+	StatusSkipped = "skipped"
+)
+
+// pingTimeout ограничивает проверку БД, чтобы зонд не зависал вместе с базой.
+const pingTimeout = 2 * time.Second
+
+// backlogWarnThreshold — число просроченных действий, после которого считаем, что воркеры не справляются.
+const backlogWarnThreshold = 1000
+
+// Component описывает состояние одной подсистемы.
+// Critical означает, что без компонента сервис не готов принимать запросы.
+type Component struct {
+	Status   string         `json:"status"`
+	Critical bool           `json:"critical"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+}
+
+// Report — структурированный ответ проверки готовности.
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+// Handler выполняет проверки живости и готовности сервиса.
+type Handler struct {
+	DB       *storage.DB
+	Notifier *storage.Notifier
+	// IsLeader сообщает, ведущая ли это реплика: сессия Telegram и мониторинг
+	// запускаются только у неё
+	IsLeader func() bool
+	started  time.Time
+}
+
+// NewHandler создаёт обработчик проверок состояния.
+func NewHandler(db *storage.DB, notifier *storage.Notifier, isLeader func() bool) *Handler {
+	return &Handler{DB: db, Notifier: notifier, IsLeader: isLeader, started: time.Now()}
+}
+
+// Live отвечает, что процесс запущен и обрабатывает запросы.
+// Внешние зависимости здесь не проверяются: их недоступность не лечится перезапуском.
+func (h *Handler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         StatusOK,
+		"uptime_seconds": int(time.Since(h.started).Seconds()),
+	})
+}
+
+// Ready проверяет все подсистемы и возвращает отчёт по каждой.
+// 503 отдаём только при отказе критичных компонентов (БД и схема),
+// проблемы Telegram-модулей понижают статус до degraded.
+func (h *Handler) Ready(c *gin.Context) {
+	report := h.check(c.Request.Context())
+	code := http.StatusOK
+	if report.Status == StatusFail {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
+
+// check собирает отчёт по всем компонентам.
+func (h *Handler) check(ctx context.Context) Report {
+	components := map[string]Component{}
+
+	db := h.checkDatabase(ctx)
+	components["database"] = db
+	if db.Status == StatusOK {
+		components["migrations"] = h.checkMigrations(ctx)
+	} else {
+		// Без соединения версию схемы проверить невозможно
+		components["migrations"] = Component{Status: StatusFail, Critical: true, Error: "база данных недоступна"}
+	}
+	if h.IsLeader() {
+		components["telegram"] = flagComponent(telegram.Connected(), "нет соединения с Telegram")
+		components["monitoring"] = flagComponent(tgmonitor.Running(), "модуль мониторинга не запущен")
+	} else {
+		// Ведомая реплика модули не запускает, их отсутствие — не деградация
+		components["telegram"] = followerComponent()
+		components["monitoring"] = followerComponent()
+	}
+	components["pq_listener"] = flagComponent(h.Notifier.Connected(), "pq-слушатель не подключён")
+	components["job_backlog"] = h.checkBacklog(ctx)
+
+	return Report{Status: overall(components), Components: components}
+}
+
+// checkDatabase пингует БД с ограничением по времени.
+func (h *Handler) checkDatabase(ctx context.Context) Component {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := h.DB.Conn.PingContext(ctx); err != nil {
+		return Component{Status: StatusFail, Critical: true, Error: err.Error()}
+	}
+	stats := h.DB.Conn.Stats()
+	return Component{Status: StatusOK, Critical: true, Details: map[string]any{
+		"latency_ms":       time.Since(start).Milliseconds(),
+		"open_connections": stats.OpenConnections,
+		"in_use":           stats.InUse,
+	}}
+}
+
+// checkMigrations сверяет применённую версию схемы с последней встроенной миграцией.
+func (h *Handler) checkMigrations(ctx context.Context) Component {
+	expected := migrations.Latest()
+	applied, err := h.DB.GetSchemaVersion(ctx)
+	if err != nil {
+		return Component{Status: StatusFail, Critical: true, Error: err.Error(), Details: map[string]any{"expected": expected}}
+	}
+	details := map[string]any{"applied": applied, "expected": expected}
+	if applied < expected {
+		return Component{Status: StatusFail, Critical: true, Error: "схема БД отстаёт от приложения", Details: details}
+	}
+	return Component{Status: StatusOK, Critical: true, Details: details}
+}
+
+// checkBacklog показывает размер очереди отложенных действий.
+// Просроченные действия означают, что воркеры не успевают или остановлены.
+func (h *Handler) checkBacklog(ctx context.Context) Component {
+	backlog, err := h.DB.GetScheduledActionsBacklog(ctx)
+	if err != nil {
+		return Component{Status: StatusDegraded, Error: err.Error()}
+	}
+	details := map[string]any{"pending": backlog.Pending, "overdue": backlog.Overdue, "running": backlog.Running}
+	// Фоновые задания API показываем рядом, ошибка их подсчёта на статус не влияет
+	if active, err := h.DB.CountActiveJobs(ctx); err == nil {
+		details["active_jobs"] = active
+	}
+	if backlog.Overdue > backlogWarnThreshold {
+		return Component{Status: StatusDegraded, Error: "очередь действий не успевает обрабатываться", Details: details}
+	}
+	return Component{Status: StatusOK, Details: details}
+}
+
+// flagComponent превращает булево состояние некритичного модуля в компонент отчёта.
+func flagComponent(up bool, downMsg string) Component {
+	if up {
+		return Component{Status: StatusOK}
+	}
+	return Component{Status: StatusFail, Error: downMsg}
+}
+
+// followerComponent описывает модуль, который работает только у ведущей реплики.
+func followerComponent() Component {
+	return Component{Status: StatusSkipped, Details: map[string]any{"role": "follower"}}
+}
+
+// overall вычисляет итоговый статус: отказ критичного компонента — fail,
+// любой другой проблемный компонент — degraded.
+func overall(components map[string]Component) string {
+	status := StatusOK
+	for _, comp := range components {
+		if comp.Status == StatusOK || comp.Status == StatusSkipped {
+			continue
+		}
+		if comp.Critical {
+			return StatusFail
+		}
+		status = StatusDegraded
+	}
+	return status
+}
@@ -0,0 +1,15 @@
+package health
+
+import (
+	"atg_go/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes регистрирует проверки живости и готовности.
+// isLeader сообщает роль реплики для компонентов, работающих только у ведущей.
+func SetupRoutes(r *gin.RouterGroup, db *storage.DB, notifier *storage.Notifier, isLeader func() bool) {
+	handler := NewHandler(db, notifier, isLeader)
+	r.GET("/live", handler.Live)
+	r.GET("/ready", handler.Ready)
+}
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"atg_go/internal/a_technical/httputil"
+	"atg_go/models"
+	"atg_go/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler отдаёт состояние фоновых заданий.
+type Handler struct {
+	DB      *storage.DB
+	Manager *Manager
+}
+
+// NewHandler создаёт обработчик заданий.
+func NewHandler(db *storage.DB, m *Manager) *Handler {
+	return &Handler{DB: db, Manager: m}
+}
+
+// ListJobs обрабатывает GET /jobs.
+// Необязательные параметры: kind, status, limit (по умолчанию 50).
+func (h *Handler) ListJobs(c *gin.Context) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httputil.RespondError(c, http.StatusBadRequest, "limit должен быть положительным числом")
+			return
+		}
+		limit = n
+	}
+	jobs, err := h.DB.ListJobs(c.Request.Context(), storage.JobFilter{Kind: c.Query("kind"), Status: c.Query("status"), Limit: limit})
+	if err != nil {
+		log.Printf("[ERROR] получение заданий: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	if jobs == nil {
+		jobs = []models.Job{}
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
+
+// GetJob обрабатывает GET /jobs/:id и возвращает прогресс и итог задания.
+func (h *Handler) GetJob(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	job, err := h.DB.GetJob(c.Request.Context(), id)
+	if err == sql.ErrNoRows {
+		httputil.RespondError(c, http.StatusNotFound, "задание не найдено")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] получение задания %d: %v", id, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob обрабатывает POST /jobs/:id/cancel.
+// Отмена асинхронна: итоговый статус cancelled появится после остановки задания;
+// задание другой реплики останавливается при её следующем обновлении заданий.
+func (h *Handler) CancelJob(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	requested, err := h.Manager.RequestCancel(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[ERROR] отмена задания %d: %v", id, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !requested {
+		httputil.RespondError(c, http.StatusConflict, "задание не выполняется")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "отмена запрошена", "job_id": id})
+}
+
+// parseID читает идентификатор задания из пути.
+func parseID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httputil.RespondError(c, http.StatusBadRequest, "некорректный id")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,198 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"atg_go/models"
+	"atg_go/pkg/storage"
+)
+
+// Типы заданий, запускаемых через API.
+const (
+	KindCommentSend  = "invite_comment_send"
+	KindReactionSend = "invite_reaction_send"
+	KindUnsubscribe  = "unsubscribe"
+)
+
+// heartbeatInterval — как часто менеджер обновляет updated_at своих заданий и проверяет
+// запросы отмены с других реплик; staleAfter — после какого простоя задание считается брошенным.
+const (
+	heartbeatInterval = 30 * time.Second
+	staleAfter        = 3 * time.Minute
+)
+
+// InstanceID возвращает идентификатор экземпляра сервиса (хост:pid) для поля jobs.owner.
+// После перезапуска контейнера он обычно совпадает с прежним, и прерванные задания
+// закрываются сразу, не дожидаясь staleAfter.
+func InstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// RunFunc выполняет задание, сообщая прогресс через p.
+// Возвращаемый результат сохраняется в jobs.result в виде JSON.
+type RunFunc func(ctx context.Context, p *Progress) (any, error)
+
+// Manager запускает задания в фоне и хранит функции их отмены.
+// Состояние заданий живёт в таблице jobs, в памяти остаются только отмены.
+// Задания помечаются владельцем Owner: другие реплики не считают их прерванными,
+// пока владелец обновляет updated_at.
+type Manager struct {
+	DB    *storage.DB
+	Owner string
+
+	mu      sync.Mutex
+	cancels map[int64]context.CancelFunc
+}
+
+// NewManager создаёт менеджер фоновых заданий.
+func NewManager(db *storage.DB, owner string) *Manager {
+	return &Manager{DB: db, Owner: owner, cancels: make(map[int64]context.CancelFunc)}
+}
+
+// Run закрывает брошенные задания и до отмены ctx поддерживает свои: обновляет их updated_at,
+// выполняет запросы отмены, пришедшие через другие реплики, и закрывает задания остановившихся реплик.
+func (m *Manager) Run(ctx context.Context) {
+	m.failInterrupted(ctx)
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ids, err := m.DB.HeartbeatJobs(ctx, m.Owner)
+		if err != nil {
+			log.Printf("[JOBS] обновление заданий: %v", err)
+		}
+		for _, id := range ids {
+			if m.Cancel(id) {
+				log.Printf("[JOBS] задание %d отменено по запросу с другой реплики", id)
+			}
+		}
+		m.failInterrupted(ctx)
+	}
+}
+
+// failInterrupted закрывает задания, которые уже никто не выполняет.
+func (m *Manager) failInterrupted(ctx context.Context) {
+	n, err := m.DB.FailInterruptedJobs(ctx, m.Owner, staleAfter)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[JOBS] закрытие прерванных заданий: %v", err)
+		}
+		return
+	}
+	if n > 0 {
+		log.Printf("[JOBS] закрыто прерванных заданий: %d", n)
+	}
+}
+
+// Start регистрирует задание и запускает fn в отдельной горутине.
+// Возвращает ID задания сразу, не дожидаясь выполнения. Из parent берутся только
+// значения (например, режим dry-run): отмена HTTP-запроса задание не останавливает.
+func (m *Manager) Start(parent context.Context, kind string, params any, fn RunFunc) (int64, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return 0, fmt.Errorf("параметры задания: %w", err)
+	}
+	id, err := m.DB.CreateJob(parent, kind, raw, m.Owner)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	m.mu.Lock()
+	m.cancels[id] = cancel
+	m.mu.Unlock()
+
+	go m.run(ctx, id, kind, fn)
+	return id, nil
+}
+
+// Cancel останавливает выполняющееся задание. Возвращает false, если задание
+// не выполняется в этом процессе (см. RequestCancel).
+func (m *Manager) Cancel(id int64) bool {
+	m.mu.Lock()
+	cancel, ok := m.cancels[id]
+	m.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// RequestCancel отменяет задание, где бы оно ни выполнялось: своё — сразу,
+// задание другой реплики — через отметку в БД, которую та заметит при обновлении заданий.
+// Возвращает false, если задание уже завершено.
+func (m *Manager) RequestCancel(ctx context.Context, id int64) (bool, error) {
+	if m.Cancel(id) {
+		return true, nil
+	}
+	return m.DB.RequestJobCancel(ctx, id)
+}
+
+// run выполняет задание и сохраняет итог.
+func (m *Manager) run(ctx context.Context, id int64, kind string, fn RunFunc) {
+	defer func() {
+		m.mu.Lock()
+		if cancel, ok := m.cancels[id]; ok {
+			cancel()
+			delete(m.cancels, id)
+		}
+		m.mu.Unlock()
+	}()
+
+	// Отмена задания не должна мешать записи его статуса
+	dbCtx := context.WithoutCancel(ctx)
+	if err := m.DB.StartJob(dbCtx, id); err != nil {
+		log.Printf("[JOBS] задание %d: не удалось отметить запуск: %v", id, err)
+	}
+	log.Printf("[JOBS] задание %d (%s) запущено", id, kind)
+
+	p := &Progress{db: m.DB, id: id}
+	result, err := runSafely(ctx, p, fn)
+
+	status, errMsg := models.JobSucceeded, ""
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.Canceled):
+		status, errMsg = models.JobCancelled, "задание отменено"
+	case err != nil:
+		status, errMsg = models.JobFailed, err.Error()
+	}
+
+	var raw []byte
+	if result != nil {
+		if raw, err = json.Marshal(result); err != nil {
+			log.Printf("[JOBS] задание %d: не удалось сохранить результат: %v", id, err)
+			raw = nil
+		}
+	}
+	// Последний прогресс мог не записаться из-за ошибки БД — сохраняем его вместе с итогом
+	p.flush()
+	if err := m.DB.FinishJob(dbCtx, id, status, raw, errMsg); err != nil {
+		log.Printf("[JOBS] задание %d: не удалось сохранить итог: %v", id, err)
+	}
+	log.Printf("[JOBS] задание %d (%s) завершено со статусом %s", id, kind, status)
+}
+
+// runSafely не даёт панике задания оставить его в статусе running.
+func runSafely(ctx context.Context, p *Progress, fn RunFunc) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("паника задания: %v", r)
+		}
+	}()
+	return fn(ctx, p)
+}
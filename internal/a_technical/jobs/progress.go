@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"atg_go/pkg/storage"
+)
+
+// Progress накапливает счётчики задания и сохраняет их в БД при каждом изменении.
+// Операции идут с паузами в секунды, поэтому запись на каждый шаг не нагружает базу.
+type Progress struct {
+	db *storage.DB
+	id int64
+
+	mu    sync.Mutex
+	state storage.JobProgress
+}
+
+// ID возвращает идентификатор задания.
+func (p *Progress) ID() int64 {
+	return p.id
+}
+
+// SetTotal задаёт общее число обрабатываемых элементов.
+func (p *Progress) SetTotal(n int) {
+	p.update(func(s *storage.JobProgress) { s.Total = n })
+}
+
+// SetCurrent отмечает элемент, который обрабатывается сейчас.
+func (p *Progress) SetCurrent(item string) {
+	p.update(func(s *storage.JobProgress) { s.CurrentItem = item })
+}
+
+// Success засчитывает успешно обработанный элемент.
+func (p *Progress) Success() {
+	p.update(func(s *storage.JobProgress) {
+		s.Processed++
+		s.Successful++
+	})
+}
+
+// Fail засчитывает элемент, обработка которого завершилась ошибкой.
+func (p *Progress) Fail() {
+	p.update(func(s *storage.JobProgress) {
+		s.Processed++
+		s.Failed++
+	})
+}
+
+// Skip засчитывает элемент, для которого действие не потребовалось.
+func (p *Progress) Skip() {
+	p.update(func(s *storage.JobProgress) { s.Processed++ })
+}
+
+// Snapshot возвращает текущие значения счётчиков.
+func (p *Progress) Snapshot() storage.JobProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// update меняет счётчики и сохраняет их.
+func (p *Progress) update(fn func(*storage.JobProgress)) {
+	p.mu.Lock()
+	fn(&p.state)
+	p.mu.Unlock()
+	p.flush()
+}
+
+// flush записывает текущий прогресс в БД. Ошибки записи только логируются,
+// чтобы сбой БД не прерывал уже начатую операцию.
+func (p *Progress) flush() {
+	if p.db == nil {
+		return
+	}
+	if err := p.db.UpdateJobProgress(context.Background(), p.id, p.Snapshot()); err != nil {
+		log.Printf("[JOBS] задание %d: не удалось сохранить прогресс: %v", p.id, err)
+	}
+}
@@ -0,0 +1,15 @@
+package jobs
+
+import (
+	"atg_go/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes регистрирует маршруты просмотра и отмены фоновых заданий.
+func SetupRoutes(r *gin.RouterGroup, db *storage.DB, m *Manager) {
+	handler := NewHandler(db, m)
+	r.GET("", handler.ListJobs)
+	r.GET("/:id", handler.GetJob)
+	r.POST("/:id/cancel", handler.CancelJob)
+}
@@ -0,0 +1,144 @@
+// Package leader выбирает ведущую реплику через advisory-блокировку PostgreSQL.
+// Фоновые модули, которые нельзя запускать дважды (сессия мониторинга, дублирование
+// каналов, планировщик служебных задач), работают только у владельца блокировки.
+// Блокировка сессионная: если процесс упал или потерял соединение, PostgreSQL
+// освобождает её сам, и другая реплика подхватывает модули на следующей попытке.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"atg_go/pkg/clock"
+)
+
+// DefaultLockKey — ключ advisory-блокировки ведущей реплики.
+const DefaultLockKey int64 = 0x6174675f6c656164 // "atg_lead"
+
+// queryTimeout ограничивает запросы захвата, проверки и освобождения блокировки.
+const queryTimeout = 5 * time.Second
+
+// Elector участвует в выборах ведущей реплики.
+type Elector struct {
+	DB       *sql.DB
+	Key      int64
+	Interval time.Duration // Период попыток захвата и проверки удержания блокировки
+
+	clock  clock.Clock
+	leader atomic.Bool
+}
+
+// NewElector создаёт участника выборов.
+func NewElector(db *sql.DB, key int64, interval time.Duration, clk clock.Clock) *Elector {
+	return &Elector{DB: db, Key: key, Interval: interval, clock: clk}
+}
+
+// IsLeader сообщает, удерживает ли процесс блокировку сейчас.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run участвует в выборах до отмены ctx. Захватив блокировку, вызывает lead с контекстом,
+// который отменяется при потере блокировки или остановке процесса. lead должен вернуться
+// после остановки своих модулей: только после этого блокировка освобождается, поэтому
+// следующая ведущая реплика не пересекается с предыдущей.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	for {
+		if conn := e.tryAcquire(ctx); conn != nil {
+			e.hold(ctx, conn, lead)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.clock.After(e.Interval):
+		}
+	}
+}
+
+// tryAcquire пытается захватить блокировку на выделенном соединении.
+// Возвращает соединение-владельца или nil, если блокировка занята.
+func (e *Elector) tryAcquire(ctx context.Context) *sql.Conn {
+	conn, err := e.DB.Conn(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[LEADER] соединение для блокировки: %v", err)
+		}
+		return nil
+	}
+	qctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	var ok bool
+	if err := conn.QueryRowContext(qctx, `SELECT pg_try_advisory_lock($1)`, e.Key).Scan(&ok); err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[LEADER] захват блокировки: %v", err)
+		}
+		conn.Close()
+		return nil
+	}
+	if !ok {
+		conn.Close()
+		return nil
+	}
+	return conn
+}
+
+// hold выполняет lead, пока блокировка удерживается, затем освобождает её.
+func (e *Elector) hold(ctx context.Context, conn *sql.Conn, lead func(ctx context.Context)) {
+	e.leader.Store(true)
+	log.Printf("[LEADER] реплика стала ведущей")
+
+	leadCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+
+	for stop := false; !stop; {
+		select {
+		case <-ctx.Done():
+			stop = true
+		case <-done:
+			log.Printf("[LEADER] фоновые модули завершились, блокировка освобождается")
+			stop = true
+		case <-e.clock.After(e.Interval):
+			if err := e.ping(ctx, conn); err != nil && ctx.Err() == nil {
+				// Соединение потеряно — PostgreSQL уже отпустил блокировку, модули надо остановить
+				log.Printf("[LEADER] блокировка потеряна: %v", err)
+				stop = true
+			}
+		}
+	}
+
+	cancel()
+	<-done
+	e.release(conn)
+	e.leader.Store(false)
+	log.Printf("[LEADER] реплика больше не ведущая")
+}
+
+// ping проверяет, что соединение-владелец живо.
+func (e *Elector) ping(ctx context.Context, conn *sql.Conn) error {
+	qctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	return conn.PingContext(qctx)
+}
+
+// release освобождает блокировку и возвращает соединение в пул. Если освободить
+// не удалось, соединение закрывается: вместе с сессией PostgreSQL снимет и блокировку,
+// а в пул не вернётся соединение, удерживающее её.
+func (e *Elector) release(conn *sql.Conn) {
+	// Контекст процесса к этому моменту может быть отменён, а блокировку нужно отдать сразу
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+	var ok bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_advisory_unlock($1)`, e.Key).Scan(&ok); err != nil || !ok {
+		log.Printf("[LEADER] освобождение блокировки: %v, соединение закрывается", err)
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	conn.Close()
+}
@@ -0,0 +1,181 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"atg_go/pkg/clock"
+)
+
+// lockTestDriver имитирует сессионную advisory-блокировку: ею владеет одно соединение.
+type lockTestDriver struct{}
+
+// id нужен, чтобы указатели на разные соединения не совпадали.
+type lockTestConn struct{ id int64 }
+
+type lockTestRows struct {
+	value bool
+	done  bool
+}
+
+var (
+	lockMu    sync.Mutex
+	lockOwner *lockTestConn
+)
+
+var lockConnSeq atomic.Int64
+
+func (lockTestDriver) Open(name string) (driver.Conn, error) {
+	return &lockTestConn{id: lockConnSeq.Add(1)}, nil
+}
+
+func (c *lockTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *lockTestConn) Begin() (driver.Tx, error) { return nil, errors.New("not implemented") }
+
+// Close имитирует завершение сессии: PostgreSQL снимает её блокировки.
+func (c *lockTestConn) Close() error {
+	lockMu.Lock()
+	defer lockMu.Unlock()
+	if lockOwner == c {
+		lockOwner = nil
+	}
+	return nil
+}
+
+func (c *lockTestConn) Ping(ctx context.Context) error { return nil }
+
+func (c *lockTestConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	lockMu.Lock()
+	defer lockMu.Unlock()
+	switch {
+	case strings.Contains(query, "pg_try_advisory_lock"):
+		if lockOwner == nil {
+			lockOwner = c
+		}
+		return &lockTestRows{value: lockOwner == c}, nil
+	case strings.Contains(query, "pg_advisory_unlock"):
+		ok := lockOwner == c
+		if ok {
+			lockOwner = nil
+		}
+		return &lockTestRows{value: ok}, nil
+	}
+	return nil, errors.New("unexpected query")
+}
+
+func (r *lockTestRows) Columns() []string { return []string{"ok"} }
+func (r *lockTestRows) Close() error      { return nil }
+func (r *lockTestRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+func init() { sql.Register("leaderDummy", lockTestDriver{}) }
+
+// waitFor ждёт выполнения условия, чтобы не завязывать тест на точные задержки.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("не дождались: %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestElectorHandover проверяет, что модули работают только у одной реплики,
+// а после её остановки блокировку подхватывает другая.
+func TestElectorHandover(t *testing.T) {
+	var active atomic.Int32 // сколько реплик сейчас выполняют модули
+	var overlap atomic.Bool
+	lead := func(ctx context.Context) {
+		if active.Add(1) > 1 {
+			overlap.Store(true)
+		}
+		<-ctx.Done()
+		active.Add(-1)
+	}
+
+	start := func() (*Elector, context.CancelFunc, chan struct{}) {
+		conn, err := sql.Open("leaderDummy", "")
+		if err != nil {
+			t.Fatalf("не удалось открыть мок БД: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		e := NewElector(conn, DefaultLockKey, 10*time.Millisecond, clock.System)
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			e.Run(ctx, lead)
+		}()
+		return e, cancel, stopped
+	}
+
+	first, stopFirst, firstStopped := start()
+	waitFor(t, "первая реплика станет ведущей", first.IsLeader)
+	second, stopSecond, secondStopped := start()
+	defer func() {
+		stopSecond()
+		<-secondStopped
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if second.IsLeader() {
+		t.Fatal("вторая реплика не должна стать ведущей, пока блокировка занята")
+	}
+
+	stopFirst()
+	<-firstStopped
+	if first.IsLeader() {
+		t.Fatal("остановленная реплика не должна считаться ведущей")
+	}
+	waitFor(t, "вторая реплика подхватит блокировку", second.IsLeader)
+	waitFor(t, "модули второй реплики запустятся", func() bool { return active.Load() == 1 })
+	if overlap.Load() {
+		t.Fatal("модули работали на двух репликах одновременно")
+	}
+}
+
+// TestElectorStepsDownWhenModulesExit проверяет, что при завершении модулей
+// блокировка отпускается и захватывается снова.
+func TestElectorStepsDownWhenModulesExit(t *testing.T) {
+	conn, err := sql.Open("leaderDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	e := NewElector(conn, DefaultLockKey, 10*time.Millisecond, clock.System)
+
+	var runs atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		e.Run(ctx, func(ctx context.Context) { runs.Add(1) })
+	}()
+	waitFor(t, "повторный захват после завершения модулей", func() bool { return runs.Load() >= 2 })
+	cancel()
+	<-stopped
+
+	lockMu.Lock()
+	defer lockMu.Unlock()
+	if lockOwner != nil {
+		t.Fatal("после остановки блокировка должна быть свободна")
+	}
+}
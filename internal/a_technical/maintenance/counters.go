@@ -0,0 +1,35 @@
+package maintenance
+
+import (
+	"log"
+	"net/http"
+
+	"atg_go/internal/a_technical/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckCounters обрабатывает GET /maintenance/counters — отчёт о расхождениях
+// денормализованных счётчиков с реальными строками без изменений в БД.
+func (h *Handler) CheckCounters(c *gin.Context) {
+	h.counters(c, false)
+}
+
+// FixCounters обрабатывает POST /maintenance/counters/fix — исправляет расхождения,
+// не требующие обращения к Telegram, и возвращает отчёт с отметкой fixed.
+func (h *Handler) FixCounters(c *gin.Context) {
+	h.counters(c, true)
+}
+
+func (h *Handler) counters(c *gin.Context, fix bool) {
+	list, err := h.DB.CheckCounters(c.Request.Context(), fix)
+	if err != nil {
+		log.Printf("[ERROR] проверка счётчиков: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	if fix && len(list) > 0 {
+		log.Printf("[MAINTENANCE] исправлены счётчики, расхождений: %d", len(list))
+	}
+	c.JSON(http.StatusOK, gin.H{"mismatches": list, "fix": fix})
+}
@@ -0,0 +1,120 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"atg_go/internal/a_technical/httputil"
+	"atg_go/models"
+	"atg_go/pkg/cron"
+	"atg_go/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler управляет расписаниями служебных задач через API.
+type Handler struct {
+	DB        *storage.DB
+	Scheduler *Scheduler
+}
+
+// NewHandler создаёт обработчик служебных задач.
+func NewHandler(db *storage.DB, s *Scheduler) *Handler {
+	return &Handler{DB: db, Scheduler: s}
+}
+
+// taskView дополняет задачу ближайшим временем запуска.
+type taskView struct {
+	models.MaintenanceTask
+	NextRun *time.Time `json:"next_run"`
+}
+
+// ListTasks обрабатывает GET /maintenance/tasks.
+func (h *Handler) ListTasks(c *gin.Context) {
+	tasks, err := h.DB.GetMaintenanceTasks(c.Request.Context())
+	if err != nil {
+		log.Printf("[ERROR] получение служебных задач: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	views := make([]taskView, 0, len(tasks))
+	for _, t := range tasks {
+		views = append(views, taskView{MaintenanceTask: t, NextRun: h.Scheduler.NextRun(t)})
+	}
+	c.JSON(http.StatusOK, gin.H{"tasks": views})
+}
+
+// UpdateTask обрабатывает PUT /maintenance/tasks/:name.
+// Принимает cron_expr и/или enabled; расписание проверяется до сохранения.
+func (h *Handler) UpdateTask(c *gin.Context) {
+	var input struct {
+		CronExpr *string `json:"cron_expr"`
+		Enabled  *bool   `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || (input.CronExpr == nil && input.Enabled == nil) {
+		httputil.RespondError(c, http.StatusBadRequest, "ожидается cron_expr и/или enabled")
+		return
+	}
+	if input.CronExpr != nil {
+		if _, err := cron.Parse(*input.CronExpr); err != nil {
+			httputil.RespondError(c, http.StatusBadRequest, "некорректное расписание: "+err.Error())
+			return
+		}
+	}
+
+	task, err := h.DB.UpdateMaintenanceTask(c.Request.Context(), c.Param("name"), input.CronExpr, input.Enabled)
+	if err == sql.ErrNoRows {
+		httputil.RespondError(c, http.StatusNotFound, "задача не найдена")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] обновление задачи %s: %v", c.Param("name"), err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	c.JSON(http.StatusOK, taskView{MaintenanceTask: *task, NextRun: h.Scheduler.NextRun(*task)})
+}
+
+// ListRuns обрабатывает GET /maintenance/tasks/:name/runs?limit=N.
+func (h *Handler) ListRuns(c *gin.Context) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httputil.RespondError(c, http.StatusBadRequest, "некорректный limit")
+			return
+		}
+		limit = n
+	}
+	runs, err := h.DB.GetMaintenanceRuns(c.Request.Context(), c.Param("name"), limit)
+	if err != nil {
+		log.Printf("[ERROR] история задачи %s: %v", c.Param("name"), err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// RunTask обрабатывает POST /maintenance/tasks/:name/run — внеплановый запуск.
+func (h *Handler) RunTask(c *gin.Context) {
+	// Запуск не привязываем к контексту запроса: задача продолжается после ответа
+	runID, err := h.Scheduler.Trigger(context.WithoutCancel(c.Request.Context()), c.Param("name"), models.MaintenanceTriggerAPI)
+	switch {
+	case errors.Is(err, ErrUnknownTask):
+		httputil.RespondError(c, http.StatusNotFound, "задача не найдена")
+		return
+	case errors.Is(err, storage.ErrTaskAlreadyRunning):
+		httputil.RespondError(c, http.StatusConflict, "задача уже выполняется")
+		return
+	case err != nil:
+		log.Printf("[ERROR] запуск задачи %s: %v", c.Param("name"), err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"run_id": runID})
+}
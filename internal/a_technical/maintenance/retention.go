@@ -0,0 +1,75 @@
+package maintenance
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"atg_go/internal/a_technical/httputil"
+	"atg_go/models"
+	"atg_go/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRetentionPolicies обрабатывает GET /maintenance/retention.
+// Итог последней очистки каждой таблицы — в last_run_at и last_removed.
+func (h *Handler) ListRetentionPolicies(c *gin.Context) {
+	policies, err := h.DB.GetRetentionPolicies(c.Request.Context())
+	if err != nil {
+		log.Printf("[ERROR] получение политик хранения: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+// UpdateRetentionPolicy обрабатывает PUT /maintenance/retention/:table.
+// Принимает keep_days, mode (delete или archive), batch_size и enabled; не переданные поля не меняются.
+func (h *Handler) UpdateRetentionPolicy(c *gin.Context) {
+	var input struct {
+		KeepDays  *int    `json:"keep_days"`
+		Mode      *string `json:"mode"`
+		BatchSize *int    `json:"batch_size"`
+		Enabled   *bool   `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil ||
+		(input.KeepDays == nil && input.Mode == nil && input.BatchSize == nil && input.Enabled == nil) {
+		httputil.RespondError(c, http.StatusBadRequest, "ожидается keep_days, mode, batch_size и/или enabled")
+		return
+	}
+	if input.KeepDays != nil && *input.KeepDays <= 0 {
+		httputil.RespondError(c, http.StatusBadRequest, "keep_days должен быть больше нуля")
+		return
+	}
+	if input.BatchSize != nil && *input.BatchSize <= 0 {
+		httputil.RespondError(c, http.StatusBadRequest, "batch_size должен быть больше нуля")
+		return
+	}
+	if input.Mode != nil && *input.Mode != models.RetentionModeDelete && *input.Mode != models.RetentionModeArchive {
+		httputil.RespondError(c, http.StatusBadRequest, "mode должен быть delete или archive")
+		return
+	}
+
+	table := c.Param("table")
+	if !storage.IsRetentionTable(table) {
+		httputil.RespondError(c, http.StatusNotFound, "политика не найдена")
+		return
+	}
+	policy, err := h.DB.UpdateRetentionPolicy(c.Request.Context(), table, storage.RetentionPolicyUpdate{
+		KeepDays:  input.KeepDays,
+		Mode:      input.Mode,
+		BatchSize: input.BatchSize,
+		Enabled:   input.Enabled,
+	})
+	if err == sql.ErrNoRows {
+		httputil.RespondError(c, http.StatusNotFound, "политика не найдена")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] обновление политики хранения %s: %v", table, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
@@ -0,0 +1,20 @@
+package maintenance
+
+import (
+	"atg_go/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes регистрирует маршруты управления служебными задачами.
+func SetupRoutes(r *gin.RouterGroup, db *storage.DB, s *Scheduler) {
+	handler := NewHandler(db, s)
+	r.GET("/tasks", handler.ListTasks)
+	r.PUT("/tasks/:name", handler.UpdateTask)
+	r.GET("/tasks/:name/runs", handler.ListRuns)
+	r.POST("/tasks/:name/run", handler.RunTask)
+	r.GET("/retention", handler.ListRetentionPolicies)
+	r.PUT("/retention/:table", handler.UpdateRetentionPolicy)
+	r.GET("/counters", handler.CheckCounters)
+	r.POST("/counters/fix", handler.FixCounters)
+}
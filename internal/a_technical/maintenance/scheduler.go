@@ -0,0 +1,206 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"atg_go/models"
+	"atg_go/pkg/clock"
+	"atg_go/pkg/cron"
+	"atg_go/pkg/storage"
+)
+
+// TaskFunc выполняет служебную задачу и возвращает краткий итог для истории запусков.
+type TaskFunc func(ctx context.Context) (string, error)
+
+// ErrUnknownTask означает, что для задачи не зарегистрирован обработчик.
+var ErrUnknownTask = errors.New("неизвестная задача")
+
+// staleRunTimeout — через сколько незавершённый запуск считается брошенным упавшим процессом.
+const staleRunTimeout = 12 * time.Hour
+
+// Scheduler запускает зарегистрированные задачи по cron-расписаниям из таблицы maintenance_tasks.
+// Расписания перечитываются каждую минуту, поэтому изменения через API применяются без перезапуска.
+type Scheduler struct {
+	DB *storage.DB
+
+	clock clock.Clock
+
+	mu    sync.RWMutex
+	tasks map[string]TaskFunc
+
+	// Запущенные задачи: Run и Wait дожидаются их завершения. Запуск через API может
+	// прийти в любой момент, поэтому вместо sync.WaitGroup — счётчик и канал простоя.
+	runsMu  sync.Mutex
+	running int
+	idle    chan struct{} // закрывается, когда running снова становится нулём
+}
+
+// NewScheduler создаёт планировщик служебных задач.
+func NewScheduler(db *storage.DB, clk clock.Clock) *Scheduler {
+	return &Scheduler{DB: db, clock: clk, tasks: make(map[string]TaskFunc)}
+}
+
+// Register связывает имя задачи из maintenance_tasks с обработчиком.
+func (s *Scheduler) Register(name string, fn TaskFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[name] = fn
+}
+
+// task возвращает обработчик задачи.
+func (s *Scheduler) task(name string) (TaskFunc, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn, ok := s.tasks[name]
+	return fn, ok
+}
+
+// Run проверяет расписания в начале каждой минуты до отмены контекста.
+// После отмены дожидается запущенных задач, чтобы их итог был записан до передачи лидерства.
+func (s *Scheduler) Run(ctx context.Context) {
+	defer func() { _ = s.Wait(context.Background()) }()
+
+	if n, err := s.DB.AbortStaleMaintenanceRuns(ctx, staleRunTimeout); err != nil {
+		log.Printf("[MAINTENANCE] закрытие брошенных запусков: %v", err)
+	} else if n > 0 {
+		log.Printf("[MAINTENANCE] закрыто брошенных запусков: %d", n)
+	}
+
+	for {
+		now := s.clock.Now()
+		wait := now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(wait):
+		}
+		s.tick(ctx, s.clock.Now())
+	}
+}
+
+// tick запускает задачи, расписание которых совпадает с минутой now.
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	tasks, err := s.DB.GetMaintenanceTasks(ctx)
+	if err != nil {
+		log.Printf("[MAINTENANCE] получение расписаний: %v", err)
+		return
+	}
+	for _, t := range tasks {
+		if !t.Enabled {
+			continue
+		}
+		sched, err := cron.Parse(t.CronExpr)
+		if err != nil {
+			log.Printf("[MAINTENANCE] задача %s: некорректное расписание %q: %v", t.Name, t.CronExpr, err)
+			continue
+		}
+		if !sched.Matches(now) {
+			continue
+		}
+		if _, err := s.Trigger(ctx, t.Name, models.MaintenanceTriggerSchedule); err != nil {
+			log.Printf("[MAINTENANCE] задача %s не запущена: %v", t.Name, err)
+		}
+	}
+}
+
+// Trigger запускает задачу в фоне и возвращает ID записи истории.
+// Если предыдущий запуск ещё не завершён, возвращается storage.ErrTaskAlreadyRunning.
+func (s *Scheduler) Trigger(ctx context.Context, name, trigger string) (int64, error) {
+	fn, ok := s.task(name)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownTask, name)
+	}
+	runID, err := s.DB.StartMaintenanceRun(ctx, name, trigger)
+	if err != nil {
+		return 0, err
+	}
+
+	// Итог записываем и после отмены ctx, иначе запуск остался бы running до staleRunTimeout
+	dbCtx := context.WithoutCancel(ctx)
+	s.runStarted()
+	go func() {
+		defer s.runFinished()
+		log.Printf("[MAINTENANCE] задача %s запущена (%s)", name, trigger)
+		result, err := runSafely(ctx, fn)
+		status, errMsg := models.MaintenanceRunSuccess, ""
+		if err != nil {
+			status, errMsg = models.MaintenanceRunFailed, err.Error()
+			log.Printf("[MAINTENANCE] задача %s завершилась ошибкой: %v", name, err)
+		} else {
+			log.Printf("[MAINTENANCE] задача %s выполнена: %s", name, result)
+		}
+		if err := s.DB.FinishMaintenanceRun(dbCtx, runID, status, result, errMsg); err != nil {
+			log.Printf("[MAINTENANCE] сохранение итога задачи %s: %v", name, err)
+		}
+	}()
+	return runID, nil
+}
+
+// Wait дожидается завершения запущенных задач или отмены ctx.
+// Нужен при остановке реплики, на которой задача запущена через API, а не по расписанию.
+func (s *Scheduler) Wait(ctx context.Context) error {
+	s.runsMu.Lock()
+	if s.running == 0 {
+		s.runsMu.Unlock()
+		return nil
+	}
+	idle := s.idle
+	s.runsMu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runStarted учитывает запущенную задачу.
+func (s *Scheduler) runStarted() {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+	if s.running == 0 {
+		s.idle = make(chan struct{})
+	}
+	s.running++
+}
+
+// runFinished снимает задачу с учёта и будит ожидающих, если задач не осталось.
+func (s *Scheduler) runFinished() {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+	s.running--
+	if s.running == 0 {
+		close(s.idle)
+	}
+}
+
+// NextRun возвращает ближайшее время запуска по расписанию или nil, если его нет.
+func (s *Scheduler) NextRun(t models.MaintenanceTask) *time.Time {
+	if !t.Enabled {
+		return nil
+	}
+	sched, err := cron.Parse(t.CronExpr)
+	if err != nil {
+		return nil
+	}
+	next := sched.Next(s.clock.Now())
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// runSafely не даёт панике задачи оставить запуск в статусе running.
+func runSafely(ctx context.Context, fn TaskFunc) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("паника задачи: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,93 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"atg_go/internal/a_technical/retention"
+	subactive "atg_go/internal/subs_active"
+	"atg_go/pkg/featureflags"
+	"atg_go/pkg/storage"
+	telegrammodule "atg_go/pkg/telegram/a_technical"
+	tgsessions "atg_go/pkg/telegram/accounts_sessions_disconnect"
+	stats "atg_go/pkg/telegram/invite_activities_statistics"
+)
+
+// Имена задач совпадают с записями в таблице maintenance_tasks.
+const (
+	TaskAccountsSessionsDisconnect = "accounts_sessions_disconnect"
+	TaskStatisticsCollect          = "invite_activities_statistics_collect"
+	TaskAccountsStateCheck         = "accounts_state_check"
+	TaskOrderLinkUpdate            = "order_link_update"
+	TaskRetentionPrune             = "retention_prune"
+	TaskIdempotencyKeysCleanup     = "idempotency_keys_cleanup"
+)
+
+// RegisterDefaultTasks подключает к планировщику стандартные служебные задачи.
+func RegisterDefaultTasks(s *Scheduler, db *storage.DB, pruner *retention.Pruner) {
+	s.Register(TaskAccountsSessionsDisconnect, func(ctx context.Context) (string, error) {
+		if !featureflags.Enabled(featureflags.AccountsSessionsDisconnect) {
+			return "пропущено: выключено флагом " + featureflags.AccountsSessionsDisconnect, nil
+		}
+		res, err := tgsessions.DisconnectSuspiciousSessions(ctx, db, 0, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("подозрительные сессии отключены на %d аккаунтах", len(res)), nil
+	})
+
+	s.Register(TaskStatisticsCollect, func(ctx context.Context) (string, error) {
+		stat, err := stats.Calculate(ctx, db)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("статистика за %s сохранена", stat.Date.Format("2006-01-02")), nil
+	})
+
+	s.Register(TaskAccountsStateCheck, func(ctx context.Context) (string, error) {
+		lost, err := tgsessions.CheckAccountsState(ctx, db)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("потерян доступ к %d аккаунтам", len(lost)), nil
+	})
+
+	s.Register(TaskOrderLinkUpdate, func(ctx context.Context) (string, error) {
+		// Тот же порядок шагов, что и в POST /module/order/link_updat
+		if err := telegrammodule.Modf_OrderLinkUpdate(ctx, db); err != nil {
+			return "", fmt.Errorf("обновление ссылок: %w", err)
+		}
+		if err := subactive.SyncWithSubsActiveCount(ctx, db); err != nil {
+			return "", fmt.Errorf("синхронизация подписок: %w", err)
+		}
+		if err := subactive.ActivateSubscriptions(ctx, db); err != nil {
+			return "", fmt.Errorf("активные подписки: %w", err)
+		}
+		return "ссылки обновлены, подписки синхронизированы", nil
+	})
+
+	s.Register(TaskRetentionPrune, func(ctx context.Context) (string, error) {
+		results, err := pruner.Run(ctx)
+		parts := make([]string, 0, len(results))
+		for _, r := range results {
+			parts = append(parts, fmt.Sprintf("%s: %d", r.Table, r.Removed))
+		}
+		summary := "удалено строк: " + strings.Join(parts, ", ")
+		if len(parts) == 0 {
+			summary = "нет включённых политик хранения"
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w (%s)", err, summary)
+		}
+		return summary, nil
+	})
+
+	s.Register(TaskIdempotencyKeysCleanup, func(ctx context.Context) (string, error) {
+		n, err := db.DeleteExpiredIdempotencyKeys(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("удалено истёкших ключей идемпотентности: %d", n), nil
+	})
+}
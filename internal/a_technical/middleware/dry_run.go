@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"strconv"
+
+	"atg_go/pkg/telegram/a_technical/dryrun"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DryRunHeader включает режим dry-run для отдельного запроса.
+const DryRunHeader = "X-Dry-Run"
+
+// DryRun переносит признак dry-run из заголовка X-Dry-Run или параметра dry_run
+// в контекст запроса. Фоновые задания, запущенные запросом, наследуют этот признак.
+func DryRun() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.GetHeader(DryRunHeader)
+		if v == "" {
+			v = c.Query("dry_run")
+		}
+		enabled, _ := strconv.ParseBool(v)
+		if enabled {
+			c.Request = c.Request.WithContext(dryrun.WithContext(c.Request.Context(), true))
+		}
+		// Ответ показывает, в каком режиме обработан запрос
+		if dryrun.Enabled(c.Request.Context()) {
+			c.Header(DryRunHeader, "true")
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"atg_go/internal/a_technical/httputil"
+	"atg_go/models"
+	"atg_go/pkg/telegram/a_technical/dryrun"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader — заголовок, по которому повтор запроса распознаётся как тот же запрос.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader отмечает ответ, взятый из сохранённого результата.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLen ограничивает длину ключа от клиента.
+const maxIdempotencyKeyLen = 255
+
+// dryRunKeyPrefix отделяет ключи dry-run запросов от ключей настоящих.
+const dryRunKeyPrefix = "dry-run:"
+
+// IdempotencyStore хранит ключи и ответы; реализуется *storage.DB.
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, key, method, path, requestHash string, ttl time.Duration) (*models.IdempotencyKey, bool, error)
+	SaveIdempotentResponse(ctx context.Context, key, method, path string, status int, contentType string, body []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, key, method, path string) error
+}
+
+// Idempotency обрабатывает заголовок Idempotency-Key у POST-запросов.
+// Первый запрос с ключом выполняется и его ответ сохраняется на ttl; повтор с тем же
+// телом получает сохранённый ответ, с другим телом — 409. Ответы 5xx не сохраняются,
+// чтобы клиент мог повторить запрос после сбоя. Запросы без заголовка не затрагиваются.
+func Idempotency(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			httputil.RespondError(c, http.StatusBadRequest, "слишком длинный Idempotency-Key")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			httputil.RespondError(c, http.StatusBadRequest, "не удалось прочитать тело запроса")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		method, path := c.Request.Method, c.Request.URL.Path
+		// Dry-run и настоящий запрос с одним ключом — разные операции: повтор без dry-run
+		// должен выполниться, а не получить смоделированный ответ
+		if dryrun.Enabled(c.Request.Context()) {
+			key = dryRunKeyPrefix + key
+		}
+		hash := requestHash(c.Request.URL.RawQuery, body)
+		rec, reserved, err := store.ReserveIdempotencyKey(c.Request.Context(), key, method, path, hash, ttl)
+		if err != nil {
+			log.Printf("[ERROR] ключ идемпотентности %q: %v", key, err)
+			httputil.RespondError(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		if !reserved {
+			replay(c, rec, hash)
+			return
+		}
+
+		// Ответ уже отправлен: итог сохраняется, даже если клиент отключился
+		ctx := context.WithoutCancel(c.Request.Context())
+		release := func() {
+			if err := store.ReleaseIdempotencyKey(ctx, key, method, path); err != nil {
+				log.Printf("[ERROR] освобождение ключа идемпотентности %q: %v", key, err)
+			}
+		}
+		// Панику перехватывает gin.Recovery выше по цепочке; без освобождения ключ
+		// отвечал бы 409 на каждый повтор до истечения ttl
+		defer func() {
+			if r := recover(); r != nil {
+				release()
+				panic(r)
+			}
+		}()
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		status := w.Status()
+		if status >= http.StatusInternalServerError {
+			release()
+			return
+		}
+		if err := store.SaveIdempotentResponse(ctx, key, method, path, status, w.Header().Get("Content-Type"), w.body.Bytes()); err != nil {
+			log.Printf("[ERROR] сохранение ответа для ключа идемпотентности %q: %v", key, err)
+		}
+	}
+}
+
+// replay отвечает на повтор запроса с уже использованным ключом.
+func replay(c *gin.Context, rec *models.IdempotencyKey, hash string) {
+	switch {
+	case rec.RequestHash != hash:
+		httputil.RespondError(c, http.StatusConflict, "Idempotency-Key уже использован с другим запросом")
+	case rec.StatusCode == nil:
+		httputil.RespondError(c, http.StatusConflict, "запрос с этим Idempotency-Key ещё выполняется")
+	default:
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(*rec.StatusCode, rec.ContentType, rec.Body)
+		c.Abort()
+	}
+}
+
+// requestHash считает отпечаток запроса по параметрам и телу.
+func requestHash(query string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(query))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter копирует тело ответа для сохранения.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"atg_go/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryIdempotencyStore хранит ключи в памяти вместо таблицы idempotency_keys.
+type memoryIdempotencyStore struct {
+	keys map[string]*models.IdempotencyKey
+}
+
+func (s *memoryIdempotencyStore) ReserveIdempotencyKey(_ context.Context, key, method, path, hash string, ttl time.Duration) (*models.IdempotencyKey, bool, error) {
+	id := method + " " + path + " " + key
+	if rec, ok := s.keys[id]; ok {
+		return rec, false, nil
+	}
+	s.keys[id] = &models.IdempotencyKey{Key: key, Method: method, Path: path, RequestHash: hash}
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) SaveIdempotentResponse(_ context.Context, key, method, path string, status int, contentType string, body []byte) error {
+	rec := s.keys[method+" "+path+" "+key]
+	rec.StatusCode, rec.ContentType, rec.Body = &status, contentType, append([]byte(nil), body...)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) ReleaseIdempotencyKey(_ context.Context, key, method, path string) error {
+	delete(s.keys, method+" "+path+" "+key)
+	return nil
+}
+
+func idempotencyRouter(store IdempotencyStore, calls *int, status *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Idempotency(store, time.Hour))
+	r.POST("/order/CreateOrder", func(c *gin.Context) {
+		*calls++
+		c.JSON(*status, gin.H{"id": *calls})
+	})
+	return r
+}
+
+func post(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/order/CreateOrder", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestIdempotencyReplay проверяет повтор ответа и конфликт при другом теле.
+func TestIdempotencyReplay(t *testing.T) {
+	store := &memoryIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	calls, status := 0, http.StatusOK
+	r := idempotencyRouter(store, &calls, &status)
+
+	first := post(r, "k1", `{"name":"a"}`)
+	second := post(r, "k1", `{"name":"a"}`)
+	if calls != 1 {
+		t.Fatalf("обработчик должен выполниться один раз, выполнен %d", calls)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Fatalf("повтор должен вернуть сохранённый ответ: %d %s, ожидался %d %s",
+			second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatal("повторный ответ должен быть отмечен заголовком")
+	}
+
+	if w := post(r, "k1", `{"name":"b"}`); w.Code != http.StatusConflict {
+		t.Fatalf("ключ с другим телом должен давать 409, получено %d", w.Code)
+	}
+	if post(r, "", `{"name":"a"}`); calls != 2 {
+		t.Fatalf("запрос без ключа должен выполняться всегда, выполнено %d", calls)
+	}
+}
+
+// TestIdempotencyServerErrorReleasesKey проверяет, что ответ 5xx не сохраняется.
+func TestIdempotencyServerErrorReleasesKey(t *testing.T) {
+	store := &memoryIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	calls, status := 0, http.StatusInternalServerError
+	r := idempotencyRouter(store, &calls, &status)
+
+	post(r, "k2", `{}`)
+	status = http.StatusOK
+	if w := post(r, "k2", `{}`); w.Code != http.StatusOK || calls != 2 {
+		t.Fatalf("после ошибки сервера запрос должен выполниться повторно: код %d, вызовов %d", w.Code, calls)
+	}
+}
+
+// TestIdempotencyInProgress проверяет ответ на повтор, пока первый запрос не завершён.
+func TestIdempotencyInProgress(t *testing.T) {
+	store := &memoryIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	hash := requestHash("", []byte(`{}`))
+	store.keys["POST /order/CreateOrder k3"] = &models.IdempotencyKey{RequestHash: hash}
+	calls, status := 0, http.StatusOK
+	r := idempotencyRouter(store, &calls, &status)
+
+	if w := post(r, "k3", `{}`); w.Code != http.StatusConflict || calls != 0 {
+		t.Fatalf("ожидался 409 без выполнения, получено %d, вызовов %d", w.Code, calls)
+	}
+}
+
+// TestIdempotencyPanicReleasesKey проверяет, что паника обработчика не оставляет ключ занятым.
+func TestIdempotencyPanicReleasesKey(t *testing.T) {
+	store := &memoryIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.Recovery(), Idempotency(store, time.Hour))
+	r.POST("/order/CreateOrder", func(c *gin.Context) { panic("boom") })
+
+	if w := post(r, "k4", `{}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("ожидался 500 после паники, получено %d", w.Code)
+	}
+	if len(store.keys) != 0 {
+		t.Fatalf("ключ должен освобождаться после паники: %v", store.keys)
+	}
+}
+
+// TestIdempotencyDryRunSeparateKey проверяет, что настоящий запрос с ключом dry-run запроса
+// выполняется, а не получает смоделированный ответ.
+func TestIdempotencyDryRunSeparateKey(t *testing.T) {
+	store := &memoryIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	calls, status := 0, http.StatusOK
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(DryRun(), Idempotency(store, time.Hour))
+	r.POST("/order/CreateOrder", func(c *gin.Context) {
+		calls++
+		c.JSON(status, gin.H{"id": calls})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/order/CreateOrder", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "k5")
+	req.Header.Set(DryRunHeader, "true")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if w := post(r, "k5", `{}`); w.Code != http.StatusOK || w.Header().Get(IdempotentReplayedHeader) != "" || calls != 2 {
+		t.Fatalf("настоящий запрос должен выполниться: код %d, вызовов %d", w.Code, calls)
+	}
+}
@@ -33,7 +33,7 @@ func (h *Handler) UpdateChannelDuplicateTimes(c *gin.Context) {
 		}
 	}
 
-	if err := h.DB.UpdateChannelDuplicateTimes(id, pq.StringArray(times)); err != nil {
+	if err := h.DB.UpdateChannelDuplicateTimes(c.Request.Context(), id, pq.StringArray(times)); err != nil {
 		log.Printf("[ERROR] обновление post_count_day для channel_duplicate %d: %v", id, err)
 		httputil.RespondError(c, http.StatusInternalServerError, "db error")
 		return
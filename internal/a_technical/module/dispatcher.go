@@ -31,7 +31,8 @@ func (h *Handler) DispatcherActivity(c *gin.Context) {
 	}
 
 	// Запускаем задачу в отдельной горутине с возможностью отмены.
-	ctx, cancel := context.WithCancel(context.Background())
+	// От запроса наследуется только режим dry-run, но не его отмена.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.Request.Context()))
 
 	h.mu.Lock()
 	id := h.next
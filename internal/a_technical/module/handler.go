@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 
+	"atg_go/internal/a_technical/jobs"
+	"atg_go/internal/a_technical/telegram"
 	"atg_go/pkg/storage"
 )
 
@@ -11,7 +13,11 @@ import (
 // Здесь хранится общее состояние и доступ к БД, чтобы остальные обработчики
 // могли запускать фоновые задачи и пользоваться одной точкой входа.
 type Handler struct {
-	DB *storage.DB
+	DB      *storage.DB
+	Jobs    *jobs.Manager
+	Modules *telegram.Registry
+	// IsLeader сообщает, ведущая ли это реплика: модули сессии работают только у неё
+	IsLeader func() bool
 
 	mu    sync.Mutex
 	tasks map[int]context.CancelFunc
@@ -19,6 +25,6 @@ type Handler struct {
 }
 
 // NewHandler создаёт новый экземпляр обработчика.
-func NewHandler(db *storage.DB) *Handler {
-	return &Handler{DB: db, tasks: make(map[int]context.CancelFunc)}
+func NewHandler(db *storage.DB, jm *jobs.Manager, modules *telegram.Registry, isLeader func() bool) *Handler {
+	return &Handler{DB: db, Jobs: jm, Modules: modules, IsLeader: isLeader, tasks: make(map[int]context.CancelFunc)}
 }
@@ -1,6 +1,7 @@
 package module
 
 import (
+	"context"
 	"log"
 	"net/http"
 
@@ -16,17 +17,19 @@ import (
 
 // OrderLinkUpdate обрабатывает запрос на обновление ссылок в описании аккаунтов.
 func (h *Handler) OrderLinkUpdate(c *gin.Context) {
-	if err := telegrammodule.Modf_OrderLinkUpdate(h.DB); err != nil {
+	// Отмена HTTP-запроса не должна обрывать обновление на середине, режим dry-run сохраняется
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := telegrammodule.Modf_OrderLinkUpdate(ctx, h.DB); err != nil {
 		log.Printf("[HANDLER ERROR] обновление ссылок: %v", err)
 		httputil.RespondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
-	if err := subactive.SyncWithSubsActiveCount(h.DB); err != nil {
+	if err := subactive.SyncWithSubsActiveCount(ctx, h.DB); err != nil {
 		log.Printf("[HANDLER ERROR] синхронизация подписок: %v", err)
 		httputil.RespondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
-	if err := subactive.ActivateSubscriptions(h.DB); err != nil {
+	if err := subactive.ActivateSubscriptions(ctx, h.DB); err != nil {
 		log.Printf("[HANDLER ERROR] активные подписки: %v", err)
 		httputil.RespondError(c, http.StatusInternalServerError, err.Error())
 		return
@@ -1,6 +1,8 @@
 package module
 
 import (
+	"atg_go/internal/a_technical/jobs"
+	"atg_go/internal/a_technical/telegram"
 	accauth "atg_go/internal/accounts_auth"
 	accsess "atg_go/internal/accounts_sessions_disconnect"
 	"atg_go/pkg/storage"
@@ -9,13 +11,18 @@ import (
 )
 
 // SetupRoutes регистрирует маршруты модуля.
-func SetupRoutes(r *gin.RouterGroup, db *storage.DB) {
-	handler := NewHandler(db)
+func SetupRoutes(r *gin.RouterGroup, db *storage.DB, jm *jobs.Manager, modules *telegram.Registry, isLeader func() bool) {
+	handler := NewHandler(db, jm, modules, isLeader)
 	r.POST("/dispatcher_activity", handler.DispatcherActivity)
 	r.POST("/dispatcher_activity/cancel_all", handler.CancelAllDispatcherActivity)
 	r.POST("/unsubscribe", handler.Unsubscribe)
 	r.POST("/order/link_updat", handler.OrderLinkUpdate)
 	r.POST("/channel_duplicate/:id/post_count_day", handler.UpdateChannelDuplicateTimes)
+	r.GET("/scheduled_actions", handler.ListScheduledActions)
+	r.POST("/scheduled_actions/order/:id/cancel", handler.CancelOrderScheduledActions)
+	r.GET("/simulated_actions", handler.ListSimulatedActions)
+	r.GET("/telegram_modules", handler.ListTelegramModules)
+	r.PUT("/telegram_modules/:name", handler.UpdateTelegramModule)
 	accauth.SetupCheckRoutes(r.Group("/account_auth_check"), db)
 	accsess.SetupRoutes(r.Group("/accounts_sessions_disconnect"), db)
 }
@@ -0,0 +1,71 @@
+package module
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"atg_go/internal/a_technical/httputil"
+	"atg_go/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListScheduledActions обрабатывает GET /module/scheduled_actions.
+// Поддерживает фильтры status, kind, order_id и limit в строке запроса.
+func (h *Handler) ListScheduledActions(c *gin.Context) {
+	filter := storage.ScheduledActionFilter{
+		Status: c.Query("status"),
+		Kind:   c.Query("kind"),
+	}
+	if v := c.Query("order_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			httputil.RespondError(c, http.StatusBadRequest, "некорректный order_id")
+			return
+		}
+		filter.OrderID = &id
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			httputil.RespondError(c, http.StatusBadRequest, "некорректный limit")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	actions, err := h.DB.ListScheduledActions(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("[ERROR] получение запланированных действий: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	backlog, err := h.DB.GetScheduledActionsBacklog(c.Request.Context())
+	if err != nil {
+		log.Printf("[ERROR] подсчёт очереди действий: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"backlog": backlog, "actions": actions})
+}
+
+// CancelOrderScheduledActions обрабатывает POST /module/scheduled_actions/order/:id/cancel.
+// Отменяет все невыполненные действия заказа.
+func (h *Handler) CancelOrderScheduledActions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, "некорректный id")
+		return
+	}
+
+	cancelled, err := h.DB.CancelScheduledActionsForOrder(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[ERROR] отмена действий заказа %d: %v", id, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cancelled": cancelled})
+}
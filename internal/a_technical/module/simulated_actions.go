@@ -0,0 +1,47 @@
+package module
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"atg_go/internal/a_technical/httputil"
+	"atg_go/models"
+	"atg_go/pkg/telegram/a_technical/dryrun"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSimulatedActions обрабатывает GET /module/simulated_actions.
+// Возвращает запросы к Telegram, перехваченные в режиме dry-run.
+// Поддерживает фильтры account_id и limit в строке запроса.
+func (h *Handler) ListSimulatedActions(c *gin.Context) {
+	var accountID, limit int
+	if v := c.Query("account_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			httputil.RespondError(c, http.StatusBadRequest, "некорректный account_id")
+			return
+		}
+		accountID = id
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httputil.RespondError(c, http.StatusBadRequest, "некорректный limit")
+			return
+		}
+		limit = n
+	}
+
+	actions, err := h.DB.ListSimulatedActions(c.Request.Context(), accountID, limit)
+	if err != nil {
+		log.Printf("[ERROR] получение симулированных действий: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	if actions == nil {
+		actions = []models.SimulatedAction{}
+	}
+	c.JSON(http.StatusOK, gin.H{"dry_run_global": dryrun.Global(), "actions": actions})
+}
@@ -0,0 +1,44 @@
+package module
+
+import (
+	"errors"
+	"net/http"
+
+	"atg_go/internal/a_technical/httputil"
+	"atg_go/internal/a_technical/telegram"
+
+	"github.com/gin-gonic/gin"
+)
+
+// telegram_modules.go управляет модулями общей сессии мониторинга.
+// Модули работают только у ведущей реплики, поэтому переключать их можно только там:
+// изменение действует до перезапуска процесса, постоянный набор выключенных модулей
+// задаётся через ATG_DISABLED_MODULES.
+
+// ListTelegramModules обрабатывает GET /module/telegram_modules.
+func (h *Handler) ListTelegramModules(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"modules": h.Modules.Statuses()})
+}
+
+// UpdateTelegramModule обрабатывает PUT /module/telegram_modules/:name.
+// Принимает enabled; при активной сессии модуль запускается или останавливается сразу.
+// Ведомая реплика отвечает 409: модули у неё не запущены, и переключение ни на что бы не повлияло.
+func (h *Handler) UpdateTelegramModule(c *gin.Context) {
+	if !h.IsLeader() {
+		httputil.RespondError(c, http.StatusConflict, "реплика не ведущая, модули переключаются на ведущей")
+		return
+	}
+	var input struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Enabled == nil {
+		httputil.RespondError(c, http.StatusBadRequest, "ожидается enabled")
+		return
+	}
+	status, err := h.Modules.SetEnabled(c.Param("name"), *input.Enabled)
+	if errors.Is(err, telegram.ErrUnknownModule) {
+		httputil.RespondError(c, http.StatusNotFound, "модуль не найден")
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
@@ -1,10 +1,12 @@
 package module
 
 import (
+	"context"
 	"log"
 	"net/http"
 
 	"atg_go/internal/a_technical/httputil"
+	"atg_go/internal/a_technical/jobs"
 	telegrammodule "atg_go/pkg/telegram/a_technical"
 
 	"github.com/gin-gonic/gin"
@@ -22,14 +24,16 @@ import (
 //	  "number_channels_or_groups": N     // количество каналов/групп (>= 0)
 //	}
 //
-// Ответ (200, JSON):
-// { "status": "запущено" }
+// Ответ (202, JSON):
+// { "status": "запущено", "job_id": ID }
+//
+// Ход отписки по аккаунтам и итог доступны через GET /jobs/:id.
 //
 // Возможные ошибки:
 // - 400: неверный формат запроса
 // - 400: delay должен содержать два значения
 // - 400: number_channels_or_groups должен быть неотрицательным числом
-// - 500: внутренняя ошибка отписки
+// - 500: не удалось создать задание
 func (h *Handler) Unsubscribe(c *gin.Context) {
 	var req struct {
 		Delay                  []int `json:"delay" binding:"required"`
@@ -54,10 +58,19 @@ func (h *Handler) Unsubscribe(c *gin.Context) {
 	delayRange := [2]int{req.Delay[0], req.Delay[1]}
 	log.Printf("[UNSUBSCRIBE] запрос: delay=%v, count=%d", delayRange, req.NumberChannelsOrGroups)
 
-	go func() {
-		if err := telegrammodule.ModF_UnsubscribeAll(h.DB, delayRange, req.NumberChannelsOrGroups); err != nil {
+	jobID, err := h.Jobs.Start(c.Request.Context(), jobs.KindUnsubscribe, req, func(ctx context.Context, p *jobs.Progress) (any, error) {
+		channelsLeft, err := telegrammodule.ModF_UnsubscribeAll(ctx, h.DB, delayRange, req.NumberChannelsOrGroups, p)
+		if err != nil {
 			log.Printf("[UNSUBSCRIBE] ошибка: %v", err)
+			return nil, err
 		}
-	}()
-	c.JSON(http.StatusOK, gin.H{"status": "запущено"})
+		snap := p.Snapshot()
+		return gin.H{"accounts": snap.Total, "successful": snap.Successful, "failed": snap.Failed, "channels_left": channelsLeft}, nil
+	})
+	if err != nil {
+		log.Printf("[UNSUBSCRIBE] не удалось создать задание: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "не удалось создать задание")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "запущено", "job_id": jobID})
 }
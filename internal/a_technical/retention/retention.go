@@ -0,0 +1,173 @@
+// Package retention очищает растущие таблицы по срокам хранения из retention_policies.
+// Строки удаляются пачками; в режиме archive они предварительно выгружаются
+// в сжатый файл JSON Lines, по одному файлу на таблицу за запуск.
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"atg_go/models"
+	"atg_go/pkg/clock"
+	"atg_go/pkg/storage"
+)
+
+// batchPause — пауза между пачками, чтобы очистка не вытесняла рабочую нагрузку.
+const batchPause = 100 * time.Millisecond
+
+// Result — итог очистки одной таблицы.
+type Result struct {
+	Table   string `json:"table"`
+	Removed int64  `json:"removed"`
+	Archive string `json:"archive,omitempty"` // Путь к файлу архива, если строки выгружались
+}
+
+// Pruner применяет включённые политики хранения.
+type Pruner struct {
+	DB  *storage.DB
+	Dir string // Каталог для архивов
+
+	clock clock.Clock
+}
+
+// NewPruner создаёт очистку с архивами в каталоге dir.
+func NewPruner(db *storage.DB, dir string, clk clock.Clock) *Pruner {
+	return &Pruner{DB: db, Dir: dir, clock: clk}
+}
+
+// Run очищает все таблицы с включёнными политиками. Ошибка одной таблицы
+// не останавливает остальные; возвращается первая из них вместе с итогами.
+func (p *Pruner) Run(ctx context.Context) ([]Result, error) {
+	policies, err := p.DB.GetRetentionPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		results  []Result
+		firstErr error
+	)
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		res, err := p.prune(ctx, policy)
+		if res.Removed > 0 || err == nil {
+			results = append(results, res)
+			if dbErr := p.DB.FinishRetentionRun(ctx, policy.TableName, res.Removed); dbErr != nil {
+				log.Printf("[RETENTION] %s: не удалось сохранить итог: %v", policy.TableName, dbErr)
+			}
+		}
+		if err != nil {
+			log.Printf("[RETENTION] %s: %v", policy.TableName, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", policy.TableName, err)
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return results, firstErr
+}
+
+// prune удаляет устаревшие строки таблицы пачками, пока они не закончатся.
+func (p *Pruner) prune(ctx context.Context, policy models.RetentionPolicy) (Result, error) {
+	res := Result{Table: policy.TableName}
+	now := p.clock.Now()
+	cutoff := now.AddDate(0, 0, -policy.KeepDays)
+
+	var arch *archive
+	if policy.Mode == models.RetentionModeArchive {
+		name := fmt.Sprintf("%s-%s.jsonl.gz", policy.TableName, now.Format("20060102-150405"))
+		arch = &archive{path: filepath.Join(p.Dir, name)}
+		defer func() {
+			if err := arch.close(); err != nil {
+				log.Printf("[RETENTION] %s: закрытие архива: %v", policy.TableName, err)
+			}
+		}()
+	}
+
+	for {
+		var (
+			n   int64
+			err error
+		)
+		if arch != nil {
+			n, err = p.DB.ArchiveExpired(ctx, policy.TableName, cutoff, policy.BatchSize, arch.write)
+			if arch.opened() {
+				res.Archive = arch.path
+			}
+		} else {
+			n, err = p.DB.DeleteExpired(ctx, policy.TableName, cutoff, policy.BatchSize)
+		}
+		res.Removed += n
+		if err != nil {
+			return res, err
+		}
+		if n < int64(policy.BatchSize) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return res, ctx.Err()
+		case <-p.clock.After(batchPause):
+		}
+	}
+	if res.Removed > 0 {
+		log.Printf("[RETENTION] %s: удалено %d строк старше %s", policy.TableName, res.Removed, cutoff.Format(time.RFC3339))
+	}
+	return res, nil
+}
+
+// archive — файл JSON Lines в gzip, открываемый при первой записи,
+// чтобы запуск без устаревших строк не оставлял пустых файлов.
+type archive struct {
+	path string
+	f    *os.File
+	gz   *gzip.Writer
+}
+
+func (a *archive) opened() bool { return a.f != nil }
+
+// write дописывает пачку строк и сбрасывает её на диск: после возврата
+// строки можно удалять из таблицы.
+func (a *archive) write(rows [][]byte) error {
+	if a.f == nil {
+		if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		a.f, a.gz = f, gzip.NewWriter(f)
+	}
+	for _, row := range rows {
+		if _, err := a.gz.Write(row); err != nil {
+			return err
+		}
+		if _, err := a.gz.Write([]byte{'\n'}); err != nil {
+			return err
+		}
+	}
+	if err := a.gz.Flush(); err != nil {
+		return err
+	}
+	return a.f.Sync()
+}
+
+func (a *archive) close() error {
+	if a.f == nil {
+		return nil
+	}
+	if err := a.gz.Close(); err != nil {
+		a.f.Close()
+		return err
+	}
+	return a.f.Close()
+}
@@ -0,0 +1,273 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"atg_go/pkg/storage"
+	tgdup "atg_go/pkg/telegram/a_base/channel_duplicate"
+	tgmonitor "atg_go/pkg/telegram/a_technical/monitoring"
+
+	"github.com/gotd/td/tg"
+)
+
+// ErrUnknownModule возвращается при обращении к незарегистрированному модулю.
+var ErrUnknownModule = errors.New("неизвестный модуль")
+
+// Module — модуль, работающий поверх общей сессии мониторинга.
+// Start регистрирует обработчики в собственном диспетчере модуля и не должен
+// блокироваться: долгую инициализацию модуль выполняет в горутине. Всё, что
+// запущено в Start, завершается по отмене ctx или вызову Stop.
+type Module interface {
+	Name() string
+	Start(ctx context.Context, api *tg.Client, dispatcher *tg.UpdateDispatcher) error
+	Stop()
+}
+
+// ModuleStatus — состояние модуля для API и проверки готовности.
+type ModuleStatus struct {
+	Name      string     `json:"name"`
+	Enabled   bool       `json:"enabled"`
+	Running   bool       `json:"running"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// accountKey — ключ контекста с ID аккаунта общей сессии.
+type accountKey struct{}
+
+// AccountID возвращает ID аккаунта, под которым открыта общая сессия.
+// Модули получают его из ctx, переданного в Start.
+func AccountID(ctx context.Context) int {
+	id, _ := ctx.Value(accountKey{}).(int)
+	return id
+}
+
+// moduleEntry хранит модуль и его текущее состояние в реестре.
+type moduleEntry struct {
+	module     Module
+	enabled    bool
+	dispatcher *tg.UpdateDispatcher // nil, пока модуль не запущен
+	cancel     context.CancelFunc
+	startedAt  time.Time
+	lastErr    string
+}
+
+// Registry управляет модулями общей сессии: запускает включённые модули при
+// подключении клиента, позволяет включать и выключать их по отдельности и
+// раздаёт каждому обновления Telegram. У каждого модуля свой диспетчер, поэтому
+// обработчики одного типа обновлений больше не перезаписывают друг друга.
+type Registry struct {
+	ops     sync.Mutex // упорядочивает запуск и остановку модулей
+	mu      sync.Mutex // защищает состояние модулей и сессии
+	entries []*moduleEntry
+	byName  map[string]*moduleEntry
+
+	// Текущая сессия; sessionCtx == nil, пока клиент не подключён
+	sessionCtx context.Context
+	api        *tg.Client
+}
+
+// NewRegistry создаёт реестр; все модули изначально включены.
+func NewRegistry(modules ...Module) *Registry {
+	r := &Registry{byName: make(map[string]*moduleEntry)}
+	for _, m := range modules {
+		e := &moduleEntry{module: m, enabled: true}
+		r.entries = append(r.entries, e)
+		r.byName[m.Name()] = e
+	}
+	return r
+}
+
+// DefaultModules возвращает модули мониторинга заказов и дублирования каналов.
+func DefaultModules(db *storage.DB, notifier *storage.Notifier) []Module {
+	return []Module{
+		&connectModule{name: "monitoring", db: db, notifier: notifier, connect: tgmonitor.Connect},
+		&connectModule{name: "channel_duplicate", db: db, notifier: notifier, connect: tgdup.Connect},
+	}
+}
+
+// SetEnabled включает или выключает модуль. При активной сессии модуль
+// запускается или останавливается сразу, иначе — при следующем подключении.
+func (r *Registry) SetEnabled(name string, enabled bool) (ModuleStatus, error) {
+	r.ops.Lock()
+	defer r.ops.Unlock()
+	e, ok := r.byName[name]
+	if !ok {
+		return ModuleStatus{}, ErrUnknownModule
+	}
+	r.mu.Lock()
+	e.enabled = enabled
+	running, attached := e.dispatcher != nil, r.sessionCtx != nil
+	r.mu.Unlock()
+	switch {
+	case enabled && !running && attached:
+		r.start(e)
+	case !enabled && running:
+		r.stop(e)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return e.status(), nil
+}
+
+// Statuses возвращает состояние модулей в порядке регистрации.
+func (r *Registry) Statuses() []ModuleStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]ModuleStatus, 0, len(r.entries))
+	for _, e := range r.entries {
+		out = append(out, e.status())
+	}
+	return out
+}
+
+// Handle реализует telegram.UpdateHandler: передаёт обновления диспетчерам
+// всех запущенных модулей. Ошибка одного модуля не мешает остальным.
+func (r *Registry) Handle(ctx context.Context, u tg.UpdatesClass) error {
+	type target struct {
+		name       string
+		dispatcher *tg.UpdateDispatcher
+	}
+	r.mu.Lock()
+	targets := make([]target, 0, len(r.entries))
+	for _, e := range r.entries {
+		if e.dispatcher != nil {
+			targets = append(targets, target{name: e.module.Name(), dispatcher: e.dispatcher})
+		}
+	}
+	r.mu.Unlock()
+
+	for _, t := range targets {
+		if err := t.dispatcher.Handle(ctx, u); err != nil {
+			log.Printf("[TELEGRAM] модуль %s: обработка обновлений: %v", t.name, err)
+		}
+	}
+	return nil
+}
+
+// attach запускает включённые модули в рамках сессии аккаунта accountID.
+func (r *Registry) attach(ctx context.Context, api *tg.Client, accountID int) {
+	r.ops.Lock()
+	defer r.ops.Unlock()
+	r.mu.Lock()
+	r.sessionCtx = context.WithValue(ctx, accountKey{}, accountID)
+	r.api = api
+	r.mu.Unlock()
+	for _, e := range r.entries {
+		r.mu.Lock()
+		enabled := e.enabled
+		r.mu.Unlock()
+		if enabled {
+			r.start(e)
+		}
+	}
+}
+
+// detach останавливает все модули при завершении сессии.
+func (r *Registry) detach() {
+	r.ops.Lock()
+	defer r.ops.Unlock()
+	for _, e := range r.entries {
+		r.mu.Lock()
+		running := e.dispatcher != nil
+		r.mu.Unlock()
+		if running {
+			r.stop(e)
+		}
+	}
+	r.mu.Lock()
+	r.sessionCtx = nil
+	r.api = nil
+	r.mu.Unlock()
+}
+
+// start запускает модуль с собственным контекстом и диспетчером.
+// Обработчики модуля начинают получать обновления после успешного Start.
+// Вызывается под r.ops; r.mu на время Start не держим, чтобы не задерживать Handle.
+func (r *Registry) start(e *moduleEntry) {
+	r.mu.Lock()
+	ctx, cancel := context.WithCancel(r.sessionCtx)
+	api := r.api
+	r.mu.Unlock()
+
+	dispatcher := tg.NewUpdateDispatcher()
+	err := e.module.Start(ctx, api, &dispatcher)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		cancel()
+		e.lastErr = err.Error()
+		log.Printf("[TELEGRAM] запуск модуля %s: %v", e.module.Name(), err)
+		return
+	}
+	e.dispatcher = &dispatcher
+	e.cancel = cancel
+	e.startedAt = time.Now()
+	e.lastErr = ""
+	log.Printf("[TELEGRAM] модуль %s запущен", e.module.Name())
+}
+
+// stop отключает модуль от обновлений и останавливает его. Вызывается под r.ops.
+func (r *Registry) stop(e *moduleEntry) {
+	r.mu.Lock()
+	cancel := e.cancel
+	e.dispatcher = nil
+	e.cancel = nil
+	r.mu.Unlock()
+
+	e.module.Stop()
+	cancel()
+	log.Printf("[TELEGRAM] модуль %s остановлен", e.module.Name())
+}
+
+// status формирует снимок состояния модуля. Вызывается под r.mu.
+func (e *moduleEntry) status() ModuleStatus {
+	s := ModuleStatus{Name: e.module.Name(), Enabled: e.enabled, Running: e.dispatcher != nil, LastError: e.lastErr}
+	if s.Running {
+		t := e.startedAt
+		s.StartedAt = &t
+	}
+	return s
+}
+
+// connectModule приспосабливает функции Connect пакетов pkg/telegram к интерфейсу Module.
+// Connect сам завершает подписки по отмене ctx, поэтому Stop лишь отменяет его.
+type connectModule struct {
+	name     string
+	db       *storage.DB
+	notifier *storage.Notifier
+	connect  func(ctx context.Context, api *tg.Client, dispatcher *tg.UpdateDispatcher, db *storage.DB, notifier *storage.Notifier, accountID int)
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+func (m *connectModule) Name() string { return m.name }
+
+func (m *connectModule) Start(ctx context.Context, api *tg.Client, dispatcher *tg.UpdateDispatcher) error {
+	accountID := AccountID(ctx)
+	if accountID == 0 {
+		return fmt.Errorf("не задан аккаунт сессии")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	m.mu.Lock()
+	m.cancel = cancel
+	m.mu.Unlock()
+	m.connect(ctx, api, dispatcher, m.db, m.notifier, accountID)
+	return nil
+}
+
+func (m *connectModule) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+}
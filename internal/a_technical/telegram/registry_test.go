@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gotd/td/tg"
+)
+
+// recordModule считает полученные посты и запуски.
+type recordModule struct {
+	name     string
+	startErr error
+	starts   int
+	stops    int
+	posts    int
+	ctx      context.Context
+}
+
+func (m *recordModule) Name() string { return m.name }
+
+func (m *recordModule) Start(ctx context.Context, api *tg.Client, dispatcher *tg.UpdateDispatcher) error {
+	if m.startErr != nil {
+		return m.startErr
+	}
+	m.starts++
+	m.ctx = ctx
+	dispatcher.OnNewChannelMessage(func(ctx context.Context, e tg.Entities, upd *tg.UpdateNewChannelMessage) error {
+		m.posts++
+		return nil
+	})
+	return nil
+}
+
+func (m *recordModule) Stop() { m.stops++ }
+
+func newPost() tg.UpdatesClass {
+	return &tg.Updates{Updates: []tg.UpdateClass{&tg.UpdateNewChannelMessage{Message: &tg.Message{ID: 1}}}}
+}
+
+func TestRegistryFanOut(t *testing.T) {
+	a := &recordModule{name: "a"}
+	b := &recordModule{name: "b"}
+	r := NewRegistry(a, b)
+
+	r.attach(context.Background(), nil, 7)
+	if AccountID(a.ctx) != 7 {
+		t.Fatalf("account id = %d, want 7", AccountID(a.ctx))
+	}
+	if err := r.Handle(context.Background(), newPost()); err != nil {
+		t.Fatal(err)
+	}
+	// Оба модуля подписаны на новые посты и получают обновление
+	if a.posts != 1 || b.posts != 1 {
+		t.Fatalf("posts = %d/%d, want 1/1", a.posts, b.posts)
+	}
+}
+
+func TestRegistryEnableDisable(t *testing.T) {
+	a := &recordModule{name: "a"}
+	b := &recordModule{name: "b"}
+	r := NewRegistry(a, b)
+
+	// До подключения клиента модуль только помечается выключенным
+	if _, err := r.SetEnabled("b", false); err != nil {
+		t.Fatal(err)
+	}
+	r.attach(context.Background(), nil, 1)
+	if b.starts != 0 {
+		t.Fatalf("disabled module started")
+	}
+
+	st, err := r.SetEnabled("a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Running || a.stops != 1 || a.ctx.Err() == nil {
+		t.Fatalf("module a not stopped: %+v stops=%d", st, a.stops)
+	}
+	_ = r.Handle(context.Background(), newPost())
+	if a.posts != 0 || b.posts != 0 {
+		t.Fatalf("stopped modules received updates: %d/%d", a.posts, b.posts)
+	}
+
+	if _, err := r.SetEnabled("b", true); err != nil {
+		t.Fatal(err)
+	}
+	_ = r.Handle(context.Background(), newPost())
+	if b.starts != 1 || b.posts != 1 || a.posts != 0 {
+		t.Fatalf("starts=%d posts=%d/%d", b.starts, a.posts, b.posts)
+	}
+
+	if _, err := r.SetEnabled("missing", true); !errors.Is(err, ErrUnknownModule) {
+		t.Fatalf("err = %v, want ErrUnknownModule", err)
+	}
+
+	statuses := r.Statuses()
+	if len(statuses) != 2 || statuses[0].Enabled || !statuses[1].Running || statuses[1].StartedAt == nil {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+
+	r.detach()
+	if b.stops != 1 || b.ctx.Err() == nil {
+		t.Fatalf("detach did not stop module b")
+	}
+}
+
+func TestRegistryStartError(t *testing.T) {
+	a := &recordModule{name: "a", startErr: errors.New("boom")}
+	r := NewRegistry(a)
+	r.attach(context.Background(), nil, 1)
+
+	st := r.Statuses()[0]
+	if st.Running || st.LastError != "boom" {
+		t.Fatalf("status = %+v", st)
+	}
+	// Ошибка запуска не мешает остановке сессии
+	r.detach()
+}
@@ -4,32 +4,55 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"math/rand"
-	"time"
+	"sync/atomic"
 
 	"atg_go/pkg/storage"
-	tgdup "atg_go/pkg/telegram/a_base/channel_duplicate"
 	base "atg_go/pkg/telegram/a_technical"
 	accountmutex "atg_go/pkg/telegram/a_technical/account_mutex"
+	"atg_go/pkg/telegram/a_technical/floodwait"
 	tgmonitor "atg_go/pkg/telegram/a_technical/monitoring"
+	schedact "atg_go/pkg/telegram/a_technical/scheduled_actions"
 
 	"github.com/gotd/td/tg"
 )
 
-// Run запускает все telegram-модули, используя одну сессию.
-// Сессия создаётся один раз, после чего к ней подключаются модули.
-func Run(db *storage.DB) {
-	go func() {
-		if err := run(db); err != nil {
-			log.Printf("[TELEGRAM] остановлено: %v", err)
-		}
-	}()
+// connected отражает, установлено ли сейчас соединение клиента с Telegram.
+// Флаг читает проверка готовности, поэтому он атомарный.
+var connected atomic.Bool
+
+// Connected сообщает, работает ли общая сессия Telegram.
+func Connected() bool {
+	return connected.Load()
+}
+
+// Run запускает пул запланированных действий до отмены ctx.
+// Пул работает на каждой реплике: действия забираются через SKIP LOCKED
+// и не выполняются дважды. workers задаёт число воркеров очереди.
+func Run(ctx context.Context, db *storage.DB, workers int) {
+	// Ограничения, полученные до перезапуска, продолжают действовать
+	if err := floodwait.Load(db); err != nil {
+		log.Printf("[TELEGRAM] загрузка флуд-вейтов: %v", err)
+	}
+
+	// Пул исполняет запланированные действия независимо от сессии мониторинга:
+	// каждое действие открывает сессию своего аккаунта
+	pool := schedact.NewPool(db, workers)
+	tgmonitor.RegisterExecutors(pool, db)
+	go pool.Run(ctx)
+}
+
+// RunMonitoring запускает модули общей сессии мониторинга и блокируется до отмены ctx.
+// Сессия аккаунта мониторинга одна на всё приложение, поэтому функцию вызывает
+// только ведущая реплика. Набор модулей и их включение задаёт реестр modules.
+func RunMonitoring(ctx context.Context, db *storage.DB, modules *Registry) {
+	if err := run(ctx, db, modules); err != nil && ctx.Err() == nil {
+		log.Printf("[TELEGRAM] остановлено: %v", err)
+	}
 }
 
 // run инициализирует клиента и подключает модули.
-func run(db *storage.DB) error {
-	rand.Seed(time.Now().UnixNano())
-	accounts, err := db.GetMonitoringAccounts()
+func run(ctx context.Context, db *storage.DB, modules *Registry) error {
+	accounts, err := db.GetMonitoringAccounts(ctx)
 	if err != nil {
 		return err
 	}
@@ -43,19 +66,17 @@ func run(db *storage.DB) error {
 	}
 	defer accountmutex.UnlockAccount(acc.ID)
 
-	dispatcher := tg.NewUpdateDispatcher()
-
-	client, err := base.Modf_AccountInitialization(acc.ApiID, acc.ApiHash, acc.Phone, acc.Proxy, nil, db.Conn, acc.ID, dispatcher)
+	// Реестр сам раздаёт обновления диспетчерам запущенных модулей
+	client, err := base.Modf_AccountInitialization(acc.ApiID, acc.ApiHash, acc.Phone, acc.Proxy, nil, db.Conn, acc.ID, modules)
 	if err != nil {
 		return err
 	}
 
-	ctx := context.Background()
 	return client.Run(ctx, func(ctx context.Context) error {
-		api := tg.NewClient(client)
-		// Передаём указатель на диспетчер, так как модули ожидают *tg.UpdateDispatcher.
-		tgmonitor.Connect(ctx, api, &dispatcher, db, acc.ID)
-		tgdup.Connect(ctx, api, &dispatcher, db, acc.ID)
+		connected.Store(true)
+		defer connected.Store(false)
+		modules.attach(ctx, tg.NewClient(client), acc.ID)
+		defer modules.detach()
 		<-ctx.Done()
 		return nil
 	})
@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 
+	"atg_go/internal/a_technical/dto"
 	"atg_go/internal/a_technical/httputil"
 	tgauth "atg_go/pkg/telegram/accounts_auth"
 
@@ -11,25 +12,30 @@ import (
 )
 
 // Check проходит по всем аккаунтам и фиксирует потерю авторизации.
-// Возвращает список телефонов, для которых сессия отсутствует.
+// Возвращает список маскированных телефонов, для которых сессия отсутствует.
 func (h *AccountHandler) Check(c *gin.Context) {
-	accounts, err := h.DB.GetAuthorizedAccounts()
+	accounts, err := h.DB.GetAuthorizedAccounts(c.Request.Context())
 	if err != nil {
 		log.Printf("[ACCOUNT AUTH CHECK] ошибка получения аккаунтов: %v", err)
 		httputil.RespondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	ctx := c.Request.Context()
 	var unauth []string
 	for _, acc := range accounts {
-		if !tgauth.Check(h.DB, acc) {
+		if !tgauth.Check(ctx, h.DB, acc) {
 			unauth = append(unauth, acc.Phone)
 		}
 	}
+	if ctx.Err() != nil {
+		// Клиент отключился: результат неполный и никому не нужен
+		return
+	}
 
 	if len(unauth) == 0 {
 		c.JSON(http.StatusOK, gin.H{"status": "все аккаунты авторизованы"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"неавторизованы": unauth})
+	c.JSON(http.StatusOK, gin.H{"неавторизованы": dto.MaskPhones(unauth)})
 }
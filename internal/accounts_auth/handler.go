@@ -32,7 +32,7 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 
 	var proxy *models.Proxy
 	if account.ProxyID != nil {
-		p, err := h.DB.GetProxyByID(*account.ProxyID)
+		p, err := h.DB.GetProxyByID(c.Request.Context(), *account.ProxyID)
 		if err != nil {
 			httputil.RespondError(c, 400, "Proxy not found")
 			return
@@ -52,7 +52,7 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 	}
 
 	// Сохраняем аккаунт и получаем его ID
-	created, err := h.DB.CreateAccount(account)
+	created, err := h.DB.CreateAccount(c.Request.Context(), account)
 	if err != nil {
 		log.Printf("[ERROR] Не удалось создать аккаунт в БД: %v", err)
 		httputil.RespondError(c, 500, "DB error")
@@ -60,7 +60,7 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 	}
 
 	// Отправляем код подтверждения и сохраняем хеш в БД
-	if _, err := tgauth.RequestCode(account.ApiID, account.ApiHash, account.Phone, proxy, h.DB, created.ID); err != nil {
+	if _, err := tgauth.RequestCode(c.Request.Context(), account.ApiID, account.ApiHash, account.Phone, proxy, h.DB, created.ID); err != nil {
 		log.Printf("[ERROR] Не удалось получить код: %v", err)
 		httputil.RespondError(c, 500, "Failed to request code")
 		return
@@ -73,7 +73,8 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 
 func (h *AccountHandler) VerifyAccount(c *gin.Context) {
 	var input struct {
-		Code string `json:"code"`
+		Code     string `json:"code"`
+		Password string `json:"password"` // Пароль 2FA; если не передан, используется сохранённый
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -82,7 +83,7 @@ func (h *AccountHandler) VerifyAccount(c *gin.Context) {
 	}
 
 	// Получаем последнюю запись аккаунта и фиксируем первичную ошибку
-	account, err := h.DB.GetLastAccount()
+	account, err := h.DB.GetLastAccount(c.Request.Context())
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			// Логируем отсутствие данных, чтобы понимать, что таблица пуста
@@ -107,6 +108,7 @@ func (h *AccountHandler) VerifyAccount(c *gin.Context) {
 	}
 
 	if err := tgauth.CompleteAuthorization(
+		c.Request.Context(),
 		h.DB,
 		account.ID,
 		account.ApiID,
@@ -114,14 +116,30 @@ func (h *AccountHandler) VerifyAccount(c *gin.Context) {
 		account.Phone,
 		input.Code,
 		account.PhoneCodeHash,
+		input.Password,
 		account.Proxy,
 	); err != nil {
-		httputil.RespondError(c, 400, "Auth failed: "+err.Error())
+		switch {
+		case errors.Is(err, tgauth.ErrPasswordRequired):
+			// Код принят, остаётся пароль: его можно передать в password или задать через /auth/accounts/:id/2fa
+			c.AbortWithStatusJSON(409, gin.H{"error": "Требуется пароль 2FA", "state": "password_required", "account_id": account.ID})
+		case errors.Is(err, tgauth.ErrPasswordInvalid):
+			c.AbortWithStatusJSON(400, gin.H{"error": "Неверный пароль 2FA", "state": "password_invalid", "account_id": account.ID})
+		default:
+			httputil.RespondError(c, 400, "Auth failed: "+err.Error())
+		}
 		return
 	}
 
+	// Пароль из запроса подошёл — сохраняем его для следующих входов
+	if input.Password != "" {
+		if err := h.saveTwoFAPassword(c, account.ID, input.Password); err != nil {
+			log.Printf("[WARN] Аккаунт %d: пароль 2FA не сохранён: %v", account.ID, err)
+		}
+	}
+
 	// Помечаем аккаунт как авторизованный
-	if err := h.DB.MarkAccountAsAuthorized(account.ID); err != nil {
+	if err := h.DB.MarkAccountAsAuthorized(c.Request.Context(), account.ID); err != nil {
 		httputil.RespondError(c, 500, "Failed to mark account as authorized")
 		return
 	}
@@ -10,4 +10,8 @@ func SetupRoutes(r *gin.RouterGroup, db *storage.DB) {
 	handler := NewHandler(db)
 	r.POST("/CreateAccount", handler.CreateAccount)
 	r.POST("/CreateAccount/verify", handler.VerifyAccount)
+	r.GET("/accounts/:id/timeline", handler.Timeline)
+	r.PUT("/accounts/:id/status", handler.SetStatus)
+	r.PUT("/accounts/:id/2fa", handler.SetTwoFAPassword)
+	r.DELETE("/accounts/:id/2fa", handler.ClearTwoFAPassword)
 }
@@ -0,0 +1,109 @@
+package accounts_auth
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"atg_go/internal/a_technical/httputil"
+	"atg_go/models"
+	"atg_go/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// status_handler.go показывает историю статусов аккаунта и позволяет оператору
+// отключать и возвращать аккаунты в работу.
+
+// Timeline обрабатывает GET /auth/accounts/:id/timeline?limit=N.
+// Возвращает текущий статус и переходы, новые первыми.
+func (h *AccountHandler) Timeline(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		httputil.RespondError(c, http.StatusBadRequest, "некорректный id")
+		return
+	}
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httputil.RespondError(c, http.StatusBadRequest, "некорректный limit")
+			return
+		}
+		limit = n
+	}
+
+	status, changedAt, err := h.DB.GetAccountStatus(c.Request.Context(), id)
+	if err == sql.ErrNoRows {
+		httputil.RespondError(c, http.StatusNotFound, "аккаунт не найден")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] статус аккаунта %d: %v", id, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	events, err := h.DB.GetAccountEvents(c.Request.Context(), id, limit)
+	if err != nil {
+		log.Printf("[ERROR] история аккаунта %d: %v", id, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"account_id":        id,
+		"status":            status,
+		"status_changed_at": changedAt,
+		"events":            events,
+	})
+}
+
+// SetStatus обрабатывает PUT /auth/accounts/:id/status.
+// Оператор может только отключить аккаунт или вернуть его в работу;
+// unauthorized и limited выставляют проверки и ответы Telegram.
+func (h *AccountHandler) SetStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		httputil.RespondError(c, http.StatusBadRequest, "некорректный id")
+		return
+	}
+	var input struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, "некорректное тело запроса")
+		return
+	}
+	if input.Status != models.AccountStatusActive && input.Status != models.AccountStatusDisabled {
+		httputil.RespondError(c, http.StatusBadRequest, "status должен быть active или disabled")
+		return
+	}
+	if input.Reason == "" {
+		input.Reason = "изменено оператором"
+	}
+
+	current, _, err := h.DB.GetAccountStatus(c.Request.Context(), id)
+	if err == sql.ErrNoRows {
+		httputil.RespondError(c, http.StatusNotFound, "аккаунт не найден")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] статус аккаунта %d: %v", id, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	// Без рабочей сессии аккаунт возвращается в работу только повторной авторизацией
+	if input.Status == models.AccountStatusActive && current != models.AccountStatusDisabled {
+		httputil.RespondError(c, http.StatusConflict, "вернуть в работу можно только отключённый аккаунт")
+		return
+	}
+
+	changed, err := h.DB.SetAccountStatus(c.Request.Context(), id, input.Status, input.Reason, storage.AccountSourceAPI)
+	if err != nil {
+		log.Printf("[ERROR] изменение статуса аккаунта %d: %v", id, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"account_id": id, "status": input.Status, "changed": changed})
+}
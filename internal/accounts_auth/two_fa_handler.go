@@ -0,0 +1,82 @@
+package accounts_auth
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"atg_go/internal/a_technical/httputil"
+	"atg_go/pkg/secret"
+
+	"github.com/gin-gonic/gin"
+)
+
+// two_fa_handler.go задаёт и меняет пароль 2FA аккаунта. Пароль хранится
+// зашифрованным и никогда не возвращается в ответах API.
+
+// SetTwoFAPassword обрабатывает PUT /auth/accounts/:id/2fa — установка или смена пароля.
+func (h *AccountHandler) SetTwoFAPassword(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		httputil.RespondError(c, http.StatusBadRequest, "некорректный id")
+		return
+	}
+	var input struct {
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Password == "" {
+		httputil.RespondError(c, http.StatusBadRequest, "ожидается password")
+		return
+	}
+	if err := h.saveTwoFAPassword(c, id, input.Password); err != nil {
+		h.respondTwoFAError(c, id, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"account_id": id, "two_fa_password": "задан"})
+}
+
+// ClearTwoFAPassword обрабатывает DELETE /auth/accounts/:id/2fa.
+func (h *AccountHandler) ClearTwoFAPassword(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		httputil.RespondError(c, http.StatusBadRequest, "некорректный id")
+		return
+	}
+	if err := h.DB.SetAccountTwoFAPassword(c.Request.Context(), id, "", actor(c)); err != nil {
+		h.respondTwoFAError(c, id, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"account_id": id, "two_fa_password": "удалён"})
+}
+
+// saveTwoFAPassword шифрует пароль ключом процесса и сохраняет его у аккаунта.
+func (h *AccountHandler) saveTwoFAPassword(c *gin.Context, accountID int, password string) error {
+	enc, err := secret.Seal(password)
+	if err != nil {
+		return err
+	}
+	return h.DB.SetAccountTwoFAPassword(c.Request.Context(), accountID, enc, actor(c))
+}
+
+// respondTwoFAError переводит ошибки сохранения пароля в ответ API.
+func (h *AccountHandler) respondTwoFAError(c *gin.Context, accountID int, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		httputil.RespondError(c, http.StatusNotFound, "аккаунт не найден")
+	case errors.Is(err, secret.ErrNoKey):
+		httputil.RespondError(c, http.StatusServiceUnavailable, "ключ шифрования не настроен")
+	default:
+		log.Printf("[ERROR] пароль 2FA аккаунта %d: %v", accountID, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+	}
+}
+
+// actor возвращает автора изменения для журнала: заголовок X-Actor или api.
+func actor(c *gin.Context) string {
+	if a := c.GetHeader("X-Actor"); a != "" {
+		return a
+	}
+	return "api"
+}
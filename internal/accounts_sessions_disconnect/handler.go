@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 
+	"atg_go/internal/a_technical/dto"
 	"atg_go/internal/a_technical/httputil"
 	"atg_go/pkg/storage"
 	tgsessions "atg_go/pkg/telegram/accounts_sessions_disconnect"
@@ -25,7 +26,7 @@ func NewHandler(db *storage.DB) *Handler {
 // Возвращает сообщение об успехе или список аккаунтов,
 // к которым программа потеряла доступ.
 func (h *Handler) Info(c *gin.Context) {
-	lost, err := tgsessions.CheckAccountsState(h.DB)
+	lost, err := tgsessions.CheckAccountsState(c.Request.Context(), h.DB)
 	if err != nil {
 		httputil.RespondError(c, http.StatusInternalServerError, err.Error())
 		return
@@ -34,7 +35,7 @@ func (h *Handler) Info(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "все аккаунты авторизованы"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"потеряны": lost})
+	c.JSON(http.StatusOK, gin.H{"потеряны": dto.MaskPhones(lost)})
 }
 
 // Disconnect отключает подозрительные сессии на всех авторизованных аккаунтах.
@@ -64,7 +65,7 @@ func (h *Handler) Disconnect(c *gin.Context) {
 		return
 	}
 
-	res, err := tgsessions.DisconnectSuspiciousSessions(h.DB, minDelay, maxDelay)
+	res, err := tgsessions.DisconnectSuspiciousSessions(c.Request.Context(), h.DB, minDelay, maxDelay)
 	if err != nil {
 		// Логируем ошибку, чтобы понять, где произошёл сбой
 		log.Printf("[ACCOUNTS SESSIONS DISCONNECT] ошибка выполнения: %v", err)
@@ -75,5 +76,12 @@ func (h *Handler) Disconnect(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"Ответ": "Аккаунты не пытались увести"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"Ответ": res})
+	// Результат сгруппирован по телефонам — в ответе они маскируются;
+	// совпавшие после маскирования группы объединяются
+	masked := make(map[string][]string, len(res))
+	for phone, devices := range res {
+		key := dto.MaskPhone(phone)
+		masked[key] = append(masked[key], devices...)
+	}
+	c.JSON(http.StatusOK, gin.H{"Ответ": masked})
 }
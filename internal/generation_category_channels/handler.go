@@ -1,32 +1,28 @@
 package generation_category_channels
 
 import (
+	"database/sql"
 	"log"
-	"math/rand"
 	"net/http"
-	"time"
+	"strconv"
 
 	"atg_go/internal/a_technical/httputil"
 	"atg_go/models"
+	"atg_go/pkg/link"
 	"atg_go/pkg/storage"
-	accountmutex "atg_go/pkg/telegram/a_technical/account_mutex"
-	gcc "atg_go/pkg/telegram/generation_category_channels"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Handler обрабатывает запросы генерации подборки каналов.
 type Handler struct {
-	DB    *storage.DB
-	queue chan struct{} // очередь на выполнение запросов генерации
+	DB     *storage.DB
+	Runner *Runner // выполняет сохранённые запуски у ведущей реплики
 }
 
 // NewHandler создаёт новый экземпляр обработчика.
-func NewHandler(db *storage.DB) *Handler {
-	return &Handler{
-		DB:    db,
-		queue: make(chan struct{}, 1), // допускаем единовременную обработку одного запроса
-	}
+func NewHandler(db *storage.DB, runner *Runner) *Handler {
+	return &Handler{DB: db, Runner: runner}
 }
 
 type request struct {
@@ -35,162 +31,120 @@ type request struct {
 	ResultCountLinks int      `json:"result_count_links" binding:"required"`
 }
 
-// GenerateCategory обрабатывает POST-запрос и формирует новую категорию каналов.
+// GenerateCategory обрабатывает POST-запрос и ставит генерацию категории в очередь.
+// Сразу возвращает ID запуска; ход обхода и итог читаются через GET /runs/:id.
 func (h *Handler) GenerateCategory(c *gin.Context) {
 	var req request
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil || req.ResultCountLinks <= 0 {
 		// Логируем проблему с распознаванием входных данных
 		log.Printf("[GENERATION ERROR] некорректный формат запроса: %v", err)
 		httputil.RespondError(c, http.StatusBadRequest, "invalid request format")
 		return
 	}
 
-	// Ставим запрос в очередь: пока предыдущий не завершён, новый ожидает
-	h.queue <- struct{}{}
-	defer func() { <-h.queue }()
+	// Ссылки без имени канала обход не сможет разрешить ни при одной попытке
+	for _, ch := range req.InputChannels {
+		if _, err := link.Username(ch); err != nil {
+			httputil.RespondError(c, http.StatusBadRequest, "invalid channel link: "+ch)
+			return
+		}
+	}
 
-	accounts, err := h.DB.GetGeneratorCategoryAccounts()
+	taken, err := h.DB.GenerationNameTaken(c.Request.Context(), req.NameCategory)
 	if err != nil {
-		// Фиксируем ошибку получения аккаунтов генерации
-		log.Printf("[GENERATION ERROR] не удалось получить аккаунты генерации категорий: %v", err)
-		httputil.RespondError(c, http.StatusInternalServerError, "failed to get generator accounts")
+		log.Printf("[ERROR] проверка названия категории %q: %v", req.NameCategory, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
 		return
 	}
-	if len(accounts) == 0 {
-		// Сообщаем о пустом списке аккаунтов
-		log.Printf("[GENERATION ERROR] аккаунты генерации категорий не найдены")
-		httputil.RespondError(c, http.StatusNotFound, "generator accounts not found")
+	if taken {
+		httputil.RespondError(c, http.StatusConflict, "category name already exists")
 		return
 	}
-	log.Printf("[GENERATION DEBUG] получено %d аккаунтов генерации: %v", len(accounts), accountIDs(accounts))
 
-	// Отбираем только свободные аккаунты и сразу блокируем их,
-	// чтобы избежать параллельного использования.
-	var (
-		free []models.Account
-		busy []int
-	)
-	for _, acc := range accounts {
-		if err := accountmutex.LockAccount(acc.ID); err != nil {
-			log.Printf("[GENERATION WARN] аккаунт %d пропущен: %v", acc.ID, err)
-			busy = append(busy, acc.ID)
-			continue
-		}
-		free = append(free, acc)
-	}
-	log.Printf("[GENERATION DEBUG] свободные аккаунты: %v, занятые: %v", accountIDs(free), busy)
-	if len(free) == 0 {
-		log.Printf("[GENERATION ERROR] нет свободных аккаунтов для генерации, заняты: %v", busy)
-		httputil.RespondError(c, http.StatusServiceUnavailable, "no free accounts")
+	id, err := h.DB.CreateGenerationRun(c.Request.Context(), req.NameCategory, req.InputChannels, req.ResultCountLinks)
+	if err != nil {
+		log.Printf("[ERROR] создание запуска генерации: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
 		return
 	}
+	h.Runner.Notify()
 
-	// results хранит уникальные найденные ссылки в порядке появления
-	results := make([]string, 0, req.ResultCountLinks)
-	// seen используется для отслеживания уже добавленных ссылок
-	seen := make(map[string]struct{})
-	// processed помогает не запрашивать рекомендации повторно для одного и того же канала
-	processed := make(map[string]struct{})
-
-	// очередь каналов на обработку
-	queue := append([]string(nil), req.InputChannels...)
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	accIdx := 0 // индекс аккаунта для последовательного использования
-
-	// Освобождаем занятые аккаунты по завершении работы
-	defer func() {
-		for _, a := range free {
-			accountmutex.UnlockAccount(a.ID)
-		}
-	}()
-
-	// Сначала проверяем входные каналы и добавляем подходящие в результаты
-	for _, link := range req.InputChannels {
-		if len(results) >= req.ResultCountLinks {
-			break
-		}
-		acc := free[accIdx%len(free)]
-		accIdx++
-		h.appendChannelIfAccessible(acc, link, seen, &results)
-	}
-
-	for len(queue) > 0 && len(results) < req.ResultCountLinks {
-		url := queue[0]
-		queue = queue[1:]
-		if _, ok := processed[url]; ok {
-			continue
-		}
-		processed[url] = struct{}{}
-
-		acc := free[accIdx%len(free)]
-		accIdx++
-		recs, err := gcc.GetChannelRecommendations(h.DB, acc, url)
-		if err != nil {
-			log.Printf("[GENERATION WARN] не удалось получить рекомендации для %s аккаунтом %d: %v", url, acc.ID, err)
-			continue
-		}
-
-		for _, link := range recs {
-			accCheck := free[accIdx%len(free)]
-			accIdx++
-			if !h.appendChannelIfAccessible(accCheck, link, seen, &results) {
-				continue
-			}
-			if len(results)%10 == 0 {
-				log.Printf("[GENERATION INFO] записано %d похожих каналов, последний: %s", len(results), link)
-			}
-			if len(results) >= req.ResultCountLinks {
-				break
-			}
-			queue = append(queue, link)
-			time.Sleep(time.Duration(500+r.Intn(1000)) * time.Millisecond)
-		}
+	c.JSON(http.StatusAccepted, gin.H{
+		"run_id": id,
+		"status": models.JobQueued,
+	})
+}
 
-		if len(results) >= req.ResultCountLinks {
-			break
+// ListRuns обрабатывает GET /generation_category_channels/runs.
+// Необязательный параметр limit (по умолчанию 50).
+func (h *Handler) ListRuns(c *gin.Context) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httputil.RespondError(c, http.StatusBadRequest, "limit должен быть положительным числом")
+			return
 		}
+		limit = n
 	}
-
-	log.Printf("[GENERATION INFO] найдено %d ссылок для категории %s", len(results), req.NameCategory)
-
-	if _, err := h.DB.CreateCategory(req.NameCategory, results); err != nil {
-		// Логируем ошибку сохранения итоговой категории
-		log.Printf("[GENERATION ERROR] не удалось сохранить категорию: %v", err)
-		httputil.RespondError(c, http.StatusInternalServerError, "failed to save category")
+	runs, err := h.DB.ListGenerationRuns(c.Request.Context(), limit)
+	if err != nil {
+		log.Printf("[ERROR] получение запусков генерации: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-		"count":  len(results),
-	})
+	if runs == nil {
+		runs = []models.GenerationRun{}
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
 }
 
-// appendChannelIfAccessible проверяет, что у канала есть открытое обсуждение,
-// и добавляет ссылку в результаты, если её ещё не было.
-// Возвращает true, если ссылка добавлена.
-func (h *Handler) appendChannelIfAccessible(acc models.Account, link string, seen map[string]struct{}, results *[]string) bool {
-	ok, err := gcc.HasAccessibleDiscussion(h.DB, acc, link)
+// GetRun обрабатывает GET /generation_category_channels/runs/:id
+// и возвращает статус, очередь обхода и найденные ссылки.
+func (h *Handler) GetRun(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	run, err := h.DB.GetGenerationRun(c.Request.Context(), id)
+	if err == sql.ErrNoRows {
+		httputil.RespondError(c, http.StatusNotFound, "запуск не найден")
+		return
+	}
 	if err != nil {
-		log.Printf("[GENERATION WARN] не удалось проверить обсуждение для %s аккаунтом %d: %v", link, acc.ID, err)
-		return false
+		log.Printf("[ERROR] получение запуска генерации %d: %v", id, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
 	}
+	c.JSON(http.StatusOK, run)
+}
+
+// CancelRun обрабатывает POST /generation_category_channels/runs/:id/cancel.
+// Выполняющийся запуск останавливается после текущего шага обхода; категория не создаётся.
+func (h *Handler) CancelRun(c *gin.Context) {
+	id, ok := parseID(c)
 	if !ok {
-		return false
+		return
 	}
-	if _, exists := seen[link]; exists {
-		return false
+	cancelled, err := h.DB.CancelGenerationRun(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[ERROR] отмена запуска генерации %d: %v", id, err)
+		httputil.RespondError(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !cancelled {
+		httputil.RespondError(c, http.StatusConflict, "запуск не выполняется")
+		return
 	}
-	seen[link] = struct{}{}
-	*results = append(*results, link)
-	return true
+	c.JSON(http.StatusOK, gin.H{"status": models.JobCancelled, "run_id": id})
 }
 
-// accountIDs возвращает идентификаторы аккаунтов для вывода в журнал.
-func accountIDs(accs []models.Account) []int {
-	ids := make([]int, 0, len(accs))
-	for _, a := range accs {
-		ids = append(ids, a.ID)
+// parseID читает идентификатор запуска из пути.
+func parseID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httputil.RespondError(c, http.StatusBadRequest, "некорректный id")
+		return 0, false
 	}
-	return ids
+	return id, true
 }
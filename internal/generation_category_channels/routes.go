@@ -6,8 +6,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRoutes регистрирует маршруты генерации подборки каналов.
-func SetupRoutes(r *gin.RouterGroup, db *storage.DB) {
-	handler := NewHandler(db)
+// SetupRoutes регистрирует маршруты генерации подборки каналов и просмотра её запусков.
+func SetupRoutes(r *gin.RouterGroup, db *storage.DB, runner *Runner) {
+	handler := NewHandler(db, runner)
 	r.POST("", handler.GenerateCategory)
+	r.GET("/runs", handler.ListRuns)
+	r.GET("/runs/:id", handler.GetRun)
+	r.POST("/runs/:id/cancel", handler.CancelRun)
 }
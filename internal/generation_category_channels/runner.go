@@ -0,0 +1,184 @@
+package generation_category_channels
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"atg_go/models"
+	"atg_go/pkg/storage"
+	accountmutex "atg_go/pkg/telegram/a_technical/account_mutex"
+	gcc "atg_go/pkg/telegram/generation_category_channels"
+)
+
+// pollInterval — как часто Runner проверяет очередь запусков без сигнала от обработчика
+// и повторяет запуск, которому не хватило свободных аккаунтов.
+const pollInterval = 5 * time.Second
+
+// Runner выполняет запуски генерации по одному, как и прежняя очередь на один слот.
+// Работает только у ведущей реплики: запуски других реплик он находит при опросе таблицы,
+// а прерванный перезапуском запуск продолжает с последнего сохранённого шага.
+type Runner struct {
+	db   *storage.DB
+	wake chan struct{}
+}
+
+// NewRunner создаёт обработчик запусков генерации.
+func NewRunner(db *storage.DB) *Runner {
+	return &Runner{db: db, wake: make(chan struct{}, 1)}
+}
+
+// Notify сообщает о новом запуске, чтобы не ждать следующего опроса.
+func (r *Runner) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run обрабатывает запуски до отмены ctx.
+func (r *Runner) Run(ctx context.Context) {
+	log.Printf("[GENERATION] обработчик запусков генерации запущен")
+	for {
+		for r.runNext(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			log.Printf("[GENERATION] обработчик запусков генерации остановлен")
+			return
+		case <-r.wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// runNext берёт следующий запуск и выполняет его.
+// Возвращает true, если запуск завершён и можно сразу брать следующий.
+func (r *Runner) runNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	run, err := r.db.ClaimGenerationRun(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		log.Printf("[GENERATION ERROR] выбор запуска генерации: %v", err)
+		return false
+	}
+	return r.execute(ctx, run)
+}
+
+// execute продолжает обход запуска с сохранённого места и сохраняет прогресс после каждого шага.
+// При остановке сервиса запуск остаётся в статусе running и продолжается после перезапуска.
+func (r *Runner) execute(ctx context.Context, run *models.GenerationRun) bool {
+	// Записи статуса не должны прерываться вместе с остановкой ведущей реплики
+	dbCtx := context.WithoutCancel(ctx)
+
+	accounts, err := r.db.GetGeneratorCategoryAccounts(ctx)
+	if err != nil {
+		// Фиксируем ошибку получения аккаунтов генерации; запуск повторим позже
+		log.Printf("[GENERATION ERROR] не удалось получить аккаунты генерации категорий: %v", err)
+		return false
+	}
+	if len(accounts) == 0 {
+		log.Printf("[GENERATION ERROR] аккаунты генерации категорий не найдены, запуск %d завершён", run.ID)
+		if err := r.db.FailGenerationRun(dbCtx, run.ID, "generator accounts not found"); err != nil {
+			log.Printf("[GENERATION ERROR] завершение запуска %d: %v", run.ID, err)
+		}
+		return true
+	}
+	log.Printf("[GENERATION DEBUG] получено %d аккаунтов генерации: %v", len(accounts), accountIDs(accounts))
+
+	// Отбираем только свободные аккаунты и сразу блокируем их,
+	// чтобы избежать параллельного использования.
+	var (
+		free []models.Account
+		busy []int
+	)
+	for _, acc := range accounts {
+		if err := accountmutex.LockAccount(acc.ID); err != nil {
+			log.Printf("[GENERATION WARN] аккаунт %d пропущен: %v", acc.ID, err)
+			busy = append(busy, acc.ID)
+			continue
+		}
+		free = append(free, acc)
+	}
+	log.Printf("[GENERATION DEBUG] свободные аккаунты: %v, занятые: %v", accountIDs(free), busy)
+	if len(free) == 0 {
+		// Запуск остаётся в работе и будет продолжен при следующем опросе
+		log.Printf("[GENERATION WARN] нет свободных аккаунтов для запуска %d, заняты: %v", run.ID, busy)
+		return false
+	}
+	// Освобождаем занятые аккаунты по завершении работы
+	defer func() {
+		for _, a := range free {
+			accountmutex.UnlockAccount(a.ID)
+		}
+	}()
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := newSearch(run, free)
+	s.recommend = func(acc models.Account, url string) ([]string, error) {
+		return gcc.GetChannelRecommendations(r.db, acc, url)
+	}
+	s.accessible = func(acc models.Account, url string) (bool, error) {
+		return gcc.HasAccessibleDiscussion(r.db, acc, url)
+	}
+	s.pause = func() {
+		time.Sleep(time.Duration(500+rnd.Intn(1000)) * time.Millisecond)
+	}
+	log.Printf("[GENERATION INFO] запуск %d: категория %s, найдено %d из %d, в очереди %d", run.ID, run.NameCategory, len(s.results), s.target, len(s.queue))
+
+	for !s.done() {
+		if ctx.Err() != nil {
+			log.Printf("[GENERATION INFO] запуск %d приостановлен, найдено %d ссылок", run.ID, len(s.results))
+			return false
+		}
+		s.step()
+		active, err := r.db.SaveGenerationRunProgress(dbCtx, run.ID, s.progress())
+		if err != nil {
+			log.Printf("[GENERATION ERROR] сохранение прогресса запуска %d: %v", run.ID, err)
+			return false
+		}
+		if !active {
+			log.Printf("[GENERATION INFO] запуск %d отменён, найдено %d ссылок", run.ID, len(s.results))
+			return true
+		}
+	}
+
+	log.Printf("[GENERATION INFO] найдено %d ссылок для категории %s", len(s.results), run.NameCategory)
+
+	category, err := r.db.CompleteGenerationRun(dbCtx, run.ID, s.results)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Printf("[GENERATION INFO] запуск %d отменён до сохранения категории", run.ID)
+		return true
+	}
+	if errors.Is(err, storage.ErrGenerationRunRejected) {
+		// Повтор даст ту же ошибку и заблокирует очередь, поэтому завершаем запуск
+		log.Printf("[GENERATION ERROR] запуск %d завершён с ошибкой: %v", run.ID, err)
+		if err := r.db.FailGenerationRun(dbCtx, run.ID, err.Error()); err != nil {
+			log.Printf("[GENERATION ERROR] завершение запуска %d: %v", run.ID, err)
+		}
+		return true
+	}
+	if err != nil {
+		// Логируем временную ошибку сохранения категории; запуск повторит сохранение позже
+		log.Printf("[GENERATION ERROR] не удалось сохранить категорию запуска %d: %v", run.ID, err)
+		return false
+	}
+	log.Printf("[GENERATION INFO] запуск %d завершён, создана категория %d", run.ID, category.ID)
+	return true
+}
+
+// accountIDs возвращает идентификаторы аккаунтов для вывода в журнал.
+func accountIDs(accs []models.Account) []int {
+	ids := make([]int, 0, len(accs))
+	for _, a := range accs {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
@@ -0,0 +1,146 @@
+package generation_category_channels
+
+import (
+	"log"
+
+	"atg_go/models"
+	"atg_go/pkg/storage"
+)
+
+// search — обход рекомендаций каналов в ширину, разбитый на шаги.
+// После каждого шага состояние сохраняется в запуск, поэтому обход можно
+// продолжить с того же места после перезапуска сервиса.
+type search struct {
+	target int      // сколько ссылок нужно найти
+	inputs []string // исходные каналы запроса
+
+	// inputsChecked — сколько исходных каналов уже проверено на открытое обсуждение
+	inputsChecked int
+	// queue — каналы, для которых ещё нужно запросить рекомендации
+	queue []string
+	// processed помогает не запрашивать рекомендации повторно для одного и того же канала
+	processed map[string]struct{}
+	order     []string // processed в порядке обработки, для сохранения
+	// results хранит уникальные найденные ссылки в порядке появления
+	results []string
+	// seen используется для отслеживания уже добавленных ссылок
+	seen map[string]struct{}
+
+	accounts []models.Account
+	accIdx   int // индекс аккаунта для последовательного использования
+
+	recommend  func(acc models.Account, url string) ([]string, error)
+	accessible func(acc models.Account, url string) (bool, error)
+	pause      func() // пауза после каждой принятой ссылки
+}
+
+// newSearch восстанавливает обход из сохранённого состояния запуска.
+func newSearch(run *models.GenerationRun, accounts []models.Account) *search {
+	s := &search{
+		target:        run.ResultCountLinks,
+		inputs:        run.InputChannels,
+		inputsChecked: run.InputsChecked,
+		queue:         append([]string(nil), run.Queue...),
+		processed:     make(map[string]struct{}, len(run.Processed)),
+		order:         append([]string(nil), run.Processed...),
+		results:       append(make([]string, 0, run.ResultCountLinks), run.Results...),
+		seen:          make(map[string]struct{}, len(run.Results)),
+		accounts:      accounts,
+		pause:         func() {},
+	}
+	for _, url := range run.Processed {
+		s.processed[url] = struct{}{}
+	}
+	for _, link := range run.Results {
+		s.seen[link] = struct{}{}
+	}
+	return s
+}
+
+// done сообщает, что ссылок найдено достаточно или обходить больше нечего.
+func (s *search) done() bool {
+	if len(s.results) >= s.target {
+		return true
+	}
+	return s.inputsChecked >= len(s.inputs) && len(s.queue) == 0
+}
+
+// step выполняет один шаг обхода: сначала проверяет очередной исходный канал,
+// затем запрашивает рекомендации для очередного канала из очереди.
+func (s *search) step() {
+	if s.inputsChecked < len(s.inputs) {
+		link := s.inputs[s.inputsChecked]
+		s.inputsChecked++
+		s.appendIfAccessible(s.nextAccount(), link)
+		return
+	}
+	if len(s.queue) == 0 {
+		return
+	}
+
+	url := s.queue[0]
+	s.queue = s.queue[1:]
+	if _, ok := s.processed[url]; ok {
+		return
+	}
+	s.processed[url] = struct{}{}
+	s.order = append(s.order, url)
+
+	acc := s.nextAccount()
+	recs, err := s.recommend(acc, url)
+	if err != nil {
+		log.Printf("[GENERATION WARN] не удалось получить рекомендации для %s аккаунтом %d: %v", url, acc.ID, err)
+		return
+	}
+
+	for _, link := range recs {
+		if !s.appendIfAccessible(s.nextAccount(), link) {
+			continue
+		}
+		if len(s.results)%10 == 0 {
+			log.Printf("[GENERATION INFO] записано %d похожих каналов, последний: %s", len(s.results), link)
+		}
+		if len(s.results) >= s.target {
+			return
+		}
+		s.queue = append(s.queue, link)
+		s.pause()
+	}
+}
+
+// progress возвращает снимок состояния обхода для сохранения.
+func (s *search) progress() storage.GenerationProgress {
+	return storage.GenerationProgress{
+		InputsChecked: s.inputsChecked,
+		Queue:         s.queue,
+		Processed:     s.order,
+		Results:       s.results,
+	}
+}
+
+// nextAccount возвращает следующий аккаунт по кругу.
+func (s *search) nextAccount() models.Account {
+	acc := s.accounts[s.accIdx%len(s.accounts)]
+	s.accIdx++
+	return acc
+}
+
+// appendIfAccessible проверяет, что у канала есть открытое обсуждение,
+// и добавляет ссылку в результаты, если её ещё не было.
+// Возвращает true, если ссылка добавлена.
+func (s *search) appendIfAccessible(acc models.Account, link string) bool {
+	ok, err := s.accessible(acc, link)
+	if err != nil {
+		log.Printf("[GENERATION WARN] не удалось проверить обсуждение для %s аккаунтом %d: %v", link, acc.ID, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	if _, exists := s.seen[link]; exists {
+		return false
+	}
+	s.seen[link] = struct{}{}
+	s.results = append(s.results, link)
+	return true
+}
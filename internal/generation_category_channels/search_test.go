@@ -0,0 +1,93 @@
+package generation_category_channels
+
+import (
+	"reflect"
+	"testing"
+
+	"atg_go/models"
+)
+
+// fakeGraph задаёт рекомендации каналов и каналы без открытого обсуждения.
+type fakeGraph struct {
+	recs     map[string][]string
+	closed   map[string]bool
+	requests []string
+}
+
+func (g *fakeGraph) attach(s *search) {
+	s.recommend = func(acc models.Account, url string) ([]string, error) {
+		g.requests = append(g.requests, url)
+		return g.recs[url], nil
+	}
+	s.accessible = func(acc models.Account, url string) (bool, error) {
+		return !g.closed[url], nil
+	}
+}
+
+func newGraph() *fakeGraph {
+	return &fakeGraph{
+		recs: map[string][]string{
+			"a": {"c", "d", "a"},
+			"b": {"e"},
+			"c": {"f", "g"},
+			"e": {"h"},
+		},
+		closed: map[string]bool{"d": true},
+	}
+}
+
+func newRun() *models.GenerationRun {
+	return &models.GenerationRun{
+		InputChannels:    []string{"a", "b"},
+		ResultCountLinks: 6,
+		Queue:            []string{"a", "b"},
+	}
+}
+
+// TestSearchFull проверяет порядок обхода: исходные каналы, затем рекомендации в ширину.
+func TestSearchFull(t *testing.T) {
+	g := newGraph()
+	s := newSearch(newRun(), []models.Account{{ID: 1}, {ID: 2}})
+	g.attach(s)
+	for !s.done() {
+		s.step()
+	}
+	want := []string{"a", "b", "c", "e", "f", "g"}
+	if !reflect.DeepEqual(s.results, want) {
+		t.Fatalf("results = %v, want %v", s.results, want)
+	}
+}
+
+// TestSearchResume проверяет, что обход, восстановленный из сохранённого прогресса,
+// приходит к тому же результату и не запрашивает рекомендации повторно.
+func TestSearchResume(t *testing.T) {
+	g := newGraph()
+	s := newSearch(newRun(), []models.Account{{ID: 1}})
+	g.attach(s)
+	// Проверка двух исходных каналов и рекомендации для a
+	for i := 0; i < 3; i++ {
+		s.step()
+	}
+	p := s.progress()
+
+	// Имитируем перезапуск: состояние читается из сохранённого запуска
+	run := newRun()
+	run.InputsChecked = p.InputsChecked
+	run.Queue = p.Queue
+	run.Processed = p.Processed
+	run.Results = p.Results
+	resumed := newSearch(run, []models.Account{{ID: 3}})
+	g.attach(resumed)
+	for !resumed.done() {
+		resumed.step()
+	}
+
+	want := []string{"a", "b", "c", "e", "f", "g"}
+	if !reflect.DeepEqual(resumed.results, want) {
+		t.Fatalf("results = %v, want %v", resumed.results, want)
+	}
+	// После продолжения рекомендации для уже обработанного канала a не запрашиваются
+	if !reflect.DeepEqual(g.requests, []string{"a", "b", "c"}) {
+		t.Fatalf("requests = %v", g.requests)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"atg_go/internal/a_technical/activity"
 	"atg_go/internal/a_technical/common"
 	"atg_go/internal/a_technical/httputil"
+	"atg_go/internal/a_technical/jobs"
 	"atg_go/models"
 	"atg_go/pkg/storage"
 	userpkg "atg_go/pkg/telegram/a_base/user"
 	invact "atg_go/pkg/telegram/invite_activities"
 	stats "atg_go/pkg/telegram/invite_activities_statistics"
+	"context"
 	"log"
 	"net/http"
 
@@ -16,19 +18,21 @@ import (
 )
 
 // Handler объединяет обработку комментариев и реакций.
+// Сама рассылка выполняется фоновым заданием, хэндлер лишь проверяет запрос и запускает его.
 type Handler struct {
 	DB        *storage.DB
 	CommentDB *storage.CommentDB
+	Jobs      *jobs.Manager
 }
 
 // NewHandler создаёт обработчик.
-func NewHandler(db *storage.DB, commentDB *storage.CommentDB) *Handler {
-	return &Handler{DB: db, CommentDB: commentDB}
+func NewHandler(db *storage.DB, commentDB *storage.CommentDB, jm *jobs.Manager) *Handler {
+	return &Handler{DB: db, CommentDB: commentDB, Jobs: jm}
 }
 
 // getOrderedAccounts возвращает аккаунты, закреплённые за заказом и без мониторинга.
-func (h *Handler) getOrderedAccounts() ([]models.Account, error) {
-	accounts, err := h.DB.GetAuthorizedAccounts()
+func (h *Handler) getOrderedAccounts(ctx context.Context) ([]models.Account, error) {
+	accounts, err := h.DB.GetAuthorizedAccounts(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +42,7 @@ func (h *Handler) getOrderedAccounts() ([]models.Account, error) {
 }
 
 // SendComment публикует комментарии к чужим постам.
+// Отвечает 202 с job_id, ход рассылки доступен через GET /jobs/:id.
 func (h *Handler) SendComment(c *gin.Context) {
 	var request struct {
 		PostsCount int `json:"posts_count" binding:"required"`
@@ -51,7 +56,7 @@ func (h *Handler) SendComment(c *gin.Context) {
 		return
 	}
 
-	accounts, err := h.getOrderedAccounts()
+	accounts, err := h.getOrderedAccounts(c.Request.Context())
 	if err != nil {
 		log.Printf("[HANDLER ERROR] Account lookup failed: %v", err)
 		httputil.RespondError(c, http.StatusInternalServerError, "Failed to get accounts")
@@ -63,63 +68,75 @@ func (h *Handler) SendComment(c *gin.Context) {
 		return
 	}
 
-	var userIDs []int
-	for _, acc := range accounts {
-		id, err := userpkg.GetUserID(h.DB, acc.ID, acc.Phone, acc.ApiID, acc.ApiHash, acc.Proxy)
-		if err != nil {
-			log.Printf("[HANDLER WARN] Не удалось получить ID для %s: %v", acc.Phone, err)
-			continue
+	jobID, err := h.Jobs.Start(c.Request.Context(), jobs.KindCommentSend, request, func(ctx context.Context, p *jobs.Progress) (any, error) {
+		var userIDs []int
+		for _, acc := range accounts {
+			id, err := userpkg.GetUserID(h.DB, acc.ID, acc.Phone, acc.ApiID, acc.ApiHash, acc.Proxy)
+			if err != nil {
+				log.Printf("[HANDLER WARN] Не удалось получить ID для аккаунта %d: %v", acc.ID, err)
+				continue
+			}
+			userIDs = append(userIDs, id)
 		}
-		userIDs = append(userIDs, id)
-	}
 
-	successCount, errorCount, err := activity.ProcessAccounts(c, accounts, h.CommentDB, func(account models.Account, channelURL string) (bool, error) {
-		msgID, _, err := invact.SendComment(
-			h.DB,
-			account.ID,
-			account.Phone,
-			channelURL,
-			account.ApiID,
-			account.ApiHash,
-			request.PostsCount,
-			func(channelID, messageID int) (bool, error) {
-				exists, err := h.DB.HasCommentForPost(channelID, messageID)
-				if err != nil {
-					return false, err
-				}
-				return !exists, nil
-			},
-			userIDs,
-			account.Proxy,
-		)
+		successCount, errorCount, err := activity.ProcessAccounts(ctx, accounts, h.CommentDB, p, func(account models.Account, channelURL string) (bool, error) {
+			msgID, _, err := invact.SendComment(
+				ctx,
+				h.DB,
+				account.ID,
+				account.Phone,
+				channelURL,
+				account.ApiID,
+				account.ApiHash,
+				request.PostsCount,
+				func(channelID, messageID int) (bool, error) {
+					exists, err := h.DB.HasCommentForPost(ctx, channelID, messageID)
+					if err != nil {
+						return false, err
+					}
+					return !exists, nil
+				},
+				userIDs,
+				account.Proxy,
+			)
+			if err != nil {
+				return false, err
+			}
+			if msgID == 0 {
+				log.Printf("[HANDLER INFO] На пост уже оставлен комментарий, пропуск для аккаунта %d", account.ID)
+				return false, nil
+			}
+			return true, nil
+		})
+
+		// Статистику обновляем и при прерванной рассылке: отправленные комментарии уже опубликованы,
+		// а ctx прерванной рассылки уже отменён, поэтому запись выполняем без отмены
+		if err := stats.IncrementComment(context.WithoutCancel(ctx), h.DB, successCount); err != nil {
+			log.Printf("[HANDLER ERROR] не удалось обновить статистику: %v", err)
+		}
 		if err != nil {
-			return false, err
+			return nil, err
 		}
-		if msgID == 0 {
-			log.Printf("[HANDLER INFO] На пост уже оставлен комментарий, пропуск для %s", account.Phone)
-			return false, nil
+
+		result := gin.H{
+			"status":         "Processing complete",
+			"total_accounts": len(accounts),
+			"successful":     successCount,
+			"failed":         errorCount,
 		}
-		return true, nil
+		log.Printf("[HANDLER INFO] Final result: %+v", result)
+		return result, nil
 	})
 	if err != nil {
+		log.Printf("[HANDLER ERROR] Не удалось запустить задание: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "Failed to start job")
 		return
 	}
-
-	if err := stats.IncrementComment(h.DB, successCount); err != nil {
-		log.Printf("[HANDLER ERROR] не удалось обновить статистику: %v", err)
-	}
-
-	result := gin.H{
-		"status":         "Processing complete",
-		"total_accounts": len(accounts),
-		"successful":     successCount,
-		"failed":         errorCount,
-	}
-	log.Printf("[HANDLER INFO] Final result: %+v", result)
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusAccepted, gin.H{"status": "queued", "job_id": jobID})
 }
 
 // SendReaction отправляет реакции к чужим комментариям.
+// Отвечает 202 с job_id, ход рассылки доступен через GET /jobs/:id.
 func (h *Handler) SendReaction(c *gin.Context) {
 	var request struct {
 		MsgCount int `json:"msg_count" binding:"required"`
@@ -133,7 +150,7 @@ func (h *Handler) SendReaction(c *gin.Context) {
 		return
 	}
 
-	accounts, err := h.getOrderedAccounts()
+	accounts, err := h.getOrderedAccounts(c.Request.Context())
 	if err != nil {
 		log.Printf("[HANDLER ERROR] Ошибка получения аккаунтов: %v", err)
 		httputil.RespondError(c, http.StatusInternalServerError, "Failed to get accounts")
@@ -145,40 +162,51 @@ func (h *Handler) SendReaction(c *gin.Context) {
 		return
 	}
 
-	successCount, errorCount, err := activity.ProcessAccounts(c, accounts, h.CommentDB, func(account models.Account, channelURL string) (bool, error) {
-		msgID, _, err := invact.SendReaction(
-			h.DB,
-			account.ID,
-			account.Phone,
-			channelURL,
-			account.ApiID,
-			account.ApiHash,
-			request.MsgCount,
-			account.Proxy,
-		)
+	jobID, err := h.Jobs.Start(c.Request.Context(), jobs.KindReactionSend, request, func(ctx context.Context, p *jobs.Progress) (any, error) {
+		successCount, errorCount, err := activity.ProcessAccounts(ctx, accounts, h.CommentDB, p, func(account models.Account, channelURL string) (bool, error) {
+			msgID, _, err := invact.SendReaction(
+				ctx,
+				h.DB,
+				account.ID,
+				account.Phone,
+				channelURL,
+				account.ApiID,
+				account.ApiHash,
+				request.MsgCount,
+				account.Proxy,
+			)
+			if err != nil {
+				return false, err
+			}
+			if msgID == 0 {
+				log.Printf("[HANDLER INFO] Не найдено подходящих сообщений для аккаунта %d", account.ID)
+				return false, nil
+			}
+			return true, nil
+		})
+
+		// Статистику обновляем и при прерванной рассылке: отправленные реакции уже поставлены,
+		// а ctx прерванной рассылки уже отменён, поэтому запись выполняем без отмены
+		if err := stats.IncrementReaction(context.WithoutCancel(ctx), h.DB, successCount); err != nil {
+			log.Printf("[HANDLER ERROR] не удалось обновить статистику: %v", err)
+		}
 		if err != nil {
-			return false, err
+			return nil, err
 		}
-		if msgID == 0 {
-			log.Printf("[HANDLER INFO] Не найдено подходящих сообщений для аккаунта %s", account.Phone)
-			return false, nil
+
+		result := gin.H{
+			"status":         "Processing complete",
+			"total_accounts": len(accounts),
+			"successful":     successCount,
+			"failed":         errorCount,
 		}
-		return true, nil
+		log.Printf("[HANDLER INFO] Итог: %+v", result)
+		return result, nil
 	})
 	if err != nil {
+		log.Printf("[HANDLER ERROR] Не удалось запустить задание: %v", err)
+		httputil.RespondError(c, http.StatusInternalServerError, "Failed to start job")
 		return
 	}
-
-	if err := stats.IncrementReaction(h.DB, successCount); err != nil {
-		log.Printf("[HANDLER ERROR] не удалось обновить статистику: %v", err)
-	}
-
-	result := gin.H{
-		"status":         "Processing complete",
-		"total_accounts": len(accounts),
-		"successful":     successCount,
-		"failed":         errorCount,
-	}
-	log.Printf("[HANDLER INFO] Итог: %+v", result)
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusAccepted, gin.H{"status": "queued", "job_id": jobID})
 }
@@ -22,7 +22,7 @@ func NewHandler(db *storage.DB) *Handler {
 
 // Collect рассчитывает показатели и сохраняет их в таблицу invite_activities_statistics.
 func (h *Handler) Collect(c *gin.Context) {
-	stat, err := stats.Calculate(h.DB)
+	stat, err := stats.Calculate(c.Request.Context(), h.DB)
 	if err != nil {
 		log.Printf("[HANDLER ERROR] не удалось посчитать статистику: %v", err)
 		httputil.RespondError(c, http.StatusInternalServerError, "не удалось посчитать статистику")
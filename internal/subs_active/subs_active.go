@@ -1,6 +1,7 @@
 package subs_active
 
 import (
+	"context"
 	"log"
 	"math/rand"
 	"time"
@@ -13,8 +14,8 @@ import (
 var rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
 
 // ActivateSubscriptions проверяет заказы и подписывает недостающие аккаунты на их каналы.
-func ActivateSubscriptions(db *storage.DB) error {
-	orders, err := db.GetOrdersForMonitoring()
+func ActivateSubscriptions(ctx context.Context, db *storage.DB) error {
+	orders, err := db.GetOrdersForMonitoring(ctx)
 	if err != nil {
 		return err
 	}
@@ -22,7 +23,7 @@ func ActivateSubscriptions(db *storage.DB) error {
 		if o.SubsActiveCount == nil || *o.SubsActiveCount <= 0 || o.URLDefault == "" {
 			continue
 		}
-		current, err := db.CountOrderSubs(o.ID)
+		current, err := db.CountOrderSubs(ctx, o.ID)
 		if err != nil {
 			log.Printf("[SUBS_ACTIVE] не удалось получить количество подписок для заказа %d: %v", o.ID, err)
 			continue
@@ -31,17 +32,17 @@ func ActivateSubscriptions(db *storage.DB) error {
 		if need <= 0 {
 			continue
 		}
-		accounts, err := db.GetRandomAccountsForOrder(o.ID, need)
+		accounts, err := db.GetRandomAccountsForOrder(ctx, o.ID, need)
 		if err != nil {
 			log.Printf("[SUBS_ACTIVE] не удалось выбрать аккаунты для заказа %d: %v", o.ID, err)
 			continue
 		}
 		for _, acc := range accounts {
-			if err := telegramsubs.SubscribeAccount(db, acc, o.URLDefault); err != nil {
+			if err := telegramsubs.SubscribeAccount(ctx, db, acc, o.URLDefault); err != nil {
 				log.Printf("[SUBS_ACTIVE] аккаунт %d не смог подписаться на заказ %d: %v", acc.ID, o.ID, err)
 				continue
 			}
-			if err := db.AddOrderAccountSub(o.ID, acc.ID); err != nil {
+			if err := db.AddOrderAccountSub(ctx, o.ID, acc.ID); err != nil {
 				log.Printf("[SUBS_ACTIVE] не удалось записать подписку аккаунта %d на заказ %d: %v", acc.ID, o.ID, err)
 			}
 			time.Sleep(time.Duration(rnd.Intn(3)+2) * time.Second)
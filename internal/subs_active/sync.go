@@ -1,6 +1,7 @@
 package subs_active
 
 import (
+	"context"
 	"log"
 	"time"
 
@@ -12,13 +13,13 @@ import (
 
 // SyncWithSubsActiveCount приводит количество подписок в order_account_subs
 // в соответствие с полем subs_active_count заказа.
-func SyncWithSubsActiveCount(db *storage.DB) error {
-	orders, err := db.GetOrdersForMonitoring()
+func SyncWithSubsActiveCount(ctx context.Context, db *storage.DB) error {
+	orders, err := db.GetOrdersForMonitoring(ctx)
 	if err != nil {
 		return err
 	}
 	for _, o := range orders {
-		current, err := db.CountOrderSubs(o.ID)
+		current, err := db.CountOrderSubs(ctx, o.ID)
 		if err != nil {
 			log.Printf("[SUBS_FACT] не удалось получить количество подписок для заказа %d: %v", o.ID, err)
 			continue
@@ -29,24 +30,24 @@ func SyncWithSubsActiveCount(db *storage.DB) error {
 		}
 		if current < target {
 			need := target - current
-			accounts, err := db.GetRandomAccountsForOrder(o.ID, need)
+			accounts, err := db.GetRandomAccountsForOrder(ctx, o.ID, need)
 			if err != nil {
 				log.Printf("[SUBS_FACT] не удалось выбрать аккаунты для заказа %d: %v", o.ID, err)
 				continue
 			}
 			for _, acc := range accounts {
-				if err := telegramsubs.SubscribeAccount(db, acc, o.URLDefault); err != nil {
+				if err := telegramsubs.SubscribeAccount(ctx, db, acc, o.URLDefault); err != nil {
 					log.Printf("[SUBS_FACT] аккаунт %d не смог подписаться на заказ %d: %v", acc.ID, o.ID, err)
 					continue
 				}
-				if err := db.AddOrderAccountSub(o.ID, acc.ID); err != nil {
+				if err := db.AddOrderAccountSub(ctx, o.ID, acc.ID); err != nil {
 					log.Printf("[SUBS_FACT] не удалось записать подписку аккаунта %d на заказ %d: %v", acc.ID, o.ID, err)
 				}
 				time.Sleep(time.Duration(rnd.Intn(3)+2) * time.Second)
 			}
 		} else if current > target {
 			excess := current - target
-			if err := db.RemoveOrderAccountSubs(o.ID, excess); err != nil {
+			if err := db.RemoveOrderAccountSubs(ctx, o.ID, excess); err != nil {
 				log.Printf("[SUBS_FACT] не удалось удалить лишние подписки заказа %d: %v", o.ID, err)
 			}
 		}
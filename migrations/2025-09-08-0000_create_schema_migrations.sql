@@ -0,0 +1,6 @@
+-- Таблица учёта применённых миграций: по ней проверка готовности сверяет версию схемы.
+-- Версии записывает `atg_go migrate up`, сами миграции в неё не пишут
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version TEXT PRIMARY KEY, -- Имя файла миграции без расширения
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW() -- Время применения
+);
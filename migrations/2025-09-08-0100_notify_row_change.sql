@@ -0,0 +1,29 @@
+-- Универсальный триггер уведомлений: JSON с операцией, таблицей, ID и строкой.
+-- Имя канала передаётся аргументом триггера, удаления тоже попадают в уведомления.
+CREATE OR REPLACE FUNCTION notify_row_change() RETURNS trigger AS $$
+DECLARE
+    rec RECORD;
+    payload TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := OLD;
+    ELSE
+        rec := NEW;
+    END IF;
+    payload := json_build_object('op', TG_OP, 'table', TG_TABLE_NAME, 'id', rec.id, 'row', row_to_json(rec))::text;
+    -- pg_notify ограничен 8000 байтами: крупные строки отправляем без содержимого
+    IF octet_length(payload) > 7900 THEN
+        payload := json_build_object('op', TG_OP, 'table', TG_TABLE_NAME, 'id', rec.id)::text;
+    END IF;
+    PERFORM pg_notify(TG_ARGV[0], payload);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Заменяем старый триггер channel_duplicate, отправлявший только ID
+DROP TRIGGER IF EXISTS channel_duplicate_notify_trg ON channel_duplicate;
+DROP FUNCTION IF EXISTS notify_channel_duplicate();
+
+CREATE TRIGGER channel_duplicate_notify_trg
+AFTER INSERT OR UPDATE OR DELETE ON channel_duplicate
+FOR EACH ROW EXECUTE FUNCTION notify_row_change('channel_duplicate_changed');
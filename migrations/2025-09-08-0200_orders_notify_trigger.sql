@@ -0,0 +1,6 @@
+-- Уведомления об изменениях заказов для модуля мониторинга.
+-- Реагируем только на поля, влияющие на отслеживание канала: счётчики аккаунтов
+-- меняются триггерами постоянно и не должны порождать лишних уведомлений.
+CREATE TRIGGER orders_notify_trg
+AFTER INSERT OR DELETE OR UPDATE OF url_default, channel_tgid ON orders
+FOR EACH ROW EXECUTE FUNCTION notify_row_change('orders_changed');
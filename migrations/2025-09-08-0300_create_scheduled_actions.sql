@@ -0,0 +1,24 @@
+-- Долговременная очередь запланированных действий (просмотры, реакции, репосты постов).
+-- Воркеры захватывают строки с арендой lease_until: если процесс упал,
+-- аренда истекает и действие забирает другой воркер.
+CREATE TABLE scheduled_actions (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    kind TEXT NOT NULL, -- Тип действия, по нему выбирается исполнитель
+    order_id INTEGER REFERENCES orders(id) ON DELETE CASCADE, -- Заказ; при удалении заказа действия удаляются
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE, -- Аккаунт-исполнитель
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb, -- Параметры действия
+    run_at TIMESTAMPTZ NOT NULL, -- Когда выполнить
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'done', 'failed', 'cancelled')),
+    attempts INTEGER NOT NULL DEFAULT 0, -- Сколько раз действие захватывалось
+    max_attempts INTEGER NOT NULL DEFAULT 3, -- После стольких неудач действие помечается failed
+    lease_until TIMESTAMPTZ, -- До какого момента действие принадлежит воркеру
+    last_error TEXT, -- Текст последней ошибки
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Частичный индекс для выборки готовых к запуску действий
+CREATE INDEX scheduled_actions_due_idx ON scheduled_actions (run_at)
+    WHERE status IN ('pending', 'running');
+CREATE INDEX scheduled_actions_order_idx ON scheduled_actions (order_id);
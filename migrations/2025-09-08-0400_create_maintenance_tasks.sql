@@ -0,0 +1,34 @@
+-- Расписание регулярных служебных задач в формате cron и история их запусков
+CREATE TABLE maintenance_tasks (
+    name TEXT PRIMARY KEY, -- Имя задачи, по нему планировщик находит обработчик
+    cron_expr TEXT NOT NULL, -- Расписание: минута час день месяц день_недели (МСК)
+    enabled BOOLEAN NOT NULL DEFAULT TRUE, -- Выключенные задачи не запускаются по расписанию
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE maintenance_task_runs (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    task_name TEXT NOT NULL REFERENCES maintenance_tasks(name) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    status TEXT NOT NULL DEFAULT 'running'
+        CHECK (status IN ('running', 'success', 'failed')),
+    trigger TEXT NOT NULL DEFAULT 'schedule' -- Кто запустил: schedule или api
+        CHECK (trigger IN ('schedule', 'api')),
+    result TEXT, -- Краткий итог выполнения
+    error TEXT -- Текст ошибки при неудаче
+);
+
+-- Не более одного незавершённого запуска задачи: защита от наложения запусков
+CREATE UNIQUE INDEX maintenance_task_runs_running_uidx ON maintenance_task_runs (task_name)
+    WHERE status = 'running';
+CREATE INDEX maintenance_task_runs_task_idx ON maintenance_task_runs (task_name, started_at DESC);
+
+-- Отключение сессий переносится из кода с прежним расписанием, остальные задачи
+-- раньше запускались только вручную, поэтому создаются выключенными
+INSERT INTO maintenance_tasks (name, cron_expr, enabled) VALUES
+    ('accounts_sessions_disconnect', '0 2,11 * * *', TRUE),
+    ('invite_activities_statistics_collect', '55 23 * * *', FALSE),
+    ('accounts_state_check', '0 */6 * * *', FALSE),
+    ('order_link_update', '0 4 * * *', FALSE)
+ON CONFLICT (name) DO NOTHING;
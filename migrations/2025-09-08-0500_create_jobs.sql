@@ -0,0 +1,23 @@
+-- Фоновые задания длительных операций (массовые комментарии, реакции, отписка).
+-- HTTP-запрос сразу возвращает ID задания, а ход выполнения читается из этой таблицы.
+CREATE TABLE jobs (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    kind TEXT NOT NULL, -- Тип операции
+    status TEXT NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'cancelled')),
+    params JSONB NOT NULL DEFAULT '{}'::jsonb, -- Параметры запуска
+    total INTEGER NOT NULL DEFAULT 0, -- Сколько элементов предстоит обработать
+    processed INTEGER NOT NULL DEFAULT 0, -- Сколько уже обработано
+    successful INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    current_item TEXT, -- Элемент, который обрабатывается сейчас
+    result JSONB, -- Итог выполнения
+    error TEXT, -- Причина неудачи
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX jobs_created_idx ON jobs (created_at DESC);
+CREATE INDEX jobs_active_idx ON jobs (status) WHERE status IN ('queued', 'running');
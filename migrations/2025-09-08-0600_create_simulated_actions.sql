@@ -0,0 +1,12 @@
+-- Действия Telegram, перехваченные в режиме dry-run вместо реальной отправки.
+-- По журналу видно, что именно сделал бы сервис при обычном запуске.
+CREATE TABLE simulated_actions (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL,
+    method TEXT NOT NULL, -- Имя метода Telegram API, например messages.sendMessage
+    payload JSONB, -- Параметры запроса
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX simulated_actions_created_idx ON simulated_actions (created_at DESC);
+CREATE INDEX simulated_actions_account_idx ON simulated_actions (account_id, created_at DESC);
@@ -0,0 +1,40 @@
+-- Сроки хранения данных растущих таблиц и индексы для их очистки и частых выборок
+CREATE TABLE retention_policies (
+    table_name TEXT PRIMARY KEY -- Таблица, для которой задан срок хранения
+        CHECK (table_name IN ('activity', 'channel_post', 'Sos',
+            'invite_activities_statistics', 'accounts_sessions_disconnect_statistics')),
+    keep_days INTEGER NOT NULL CHECK (keep_days > 0), -- Сколько суток хранить строки
+    mode TEXT NOT NULL DEFAULT 'delete' -- delete — удалять, archive — выгружать в gzip-файл и удалять
+        CHECK (mode IN ('delete', 'archive')),
+    batch_size INTEGER NOT NULL DEFAULT 1000 CHECK (batch_size > 0), -- Строк за один DELETE
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_run_at TIMESTAMPTZ, -- Время последней очистки
+    last_removed BIGINT NOT NULL DEFAULT 0, -- Сколько строк удалено последней очисткой
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Политики создаются выключенными: удаление данных включается осознанно через API
+INSERT INTO retention_policies (table_name, keep_days, mode) VALUES
+    ('activity', 90, 'archive'),
+    ('channel_post', 60, 'archive'),
+    ('Sos', 30, 'delete'),
+    ('invite_activities_statistics', 365, 'archive'),
+    ('accounts_sessions_disconnect_statistics', 90, 'delete')
+ON CONFLICT (table_name) DO NOTHING;
+
+INSERT INTO maintenance_tasks (name, cron_expr, enabled) VALUES
+    ('retention_prune', '30 3 * * *', TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+-- GetLastCommentMessageID и GetLastReactionMessageID ищут последнюю активность аккаунта в канале
+CREATE INDEX IF NOT EXISTS activity_account_channel_type_idx
+    ON activity (id_account, id_channel, activity_type, date_time DESC);
+-- Очистка и суточная статистика выбирают активность по времени
+CREATE INDEX IF NOT EXISTS activity_date_time_idx ON activity (date_time);
+CREATE INDEX IF NOT EXISTS channel_post_post_date_time_idx ON channel_post (post_date_time);
+-- Каскадное удаление постов ищет теорию и факт по внешним ключам
+CREATE INDEX IF NOT EXISTS channel_post_theory_channel_post_id_idx ON channel_post_theory (channel_post_id);
+CREATE INDEX IF NOT EXISTS channel_post_fact_channel_post_theory_id_idx ON channel_post_fact (channel_post_theory_id);
+CREATE INDEX IF NOT EXISTS sos_date_time_idx ON "Sos" (date_time);
+CREATE INDEX IF NOT EXISTS accounts_sessions_disconnect_statistics_date_time_idx
+    ON accounts_sessions_disconnect_statistics (date_time);
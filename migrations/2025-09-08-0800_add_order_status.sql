@@ -0,0 +1,18 @@
+-- Статус заказа вместо удаления: приостановленные и архивные заказы сохраняют историю
+CREATE TYPE order_status AS ENUM ('active', 'paused', 'archived');
+
+ALTER TABLE orders
+    ADD COLUMN status order_status NOT NULL DEFAULT 'active', -- paused — без мониторинга и распределения, archived — аккаунты освобождены
+    ADD COLUMN archived_at TIMESTAMPTZ; -- Время перевода в архив
+
+CREATE INDEX orders_status_idx ON orders (status);
+
+-- Ссылка уникальна только среди неархивных заказов: канал из архива можно заказать заново
+ALTER TABLE orders DROP CONSTRAINT orders_url_default_unique;
+CREATE UNIQUE INDEX orders_url_default_unique ON orders (url_default) WHERE status <> 'archived';
+
+-- Мониторинг должен узнавать о приостановке и возобновлении заказа
+DROP TRIGGER IF EXISTS orders_notify_trg ON orders;
+CREATE TRIGGER orders_notify_trg
+AFTER INSERT OR DELETE OR UPDATE OF url_default, channel_tgid, status ON orders
+FOR EACH ROW EXECUTE FUNCTION notify_row_change('orders_changed');
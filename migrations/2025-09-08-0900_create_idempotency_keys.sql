@@ -0,0 +1,19 @@
+-- Ключи идемпотентности POST-запросов: повтор с тем же ключом получает сохранённый ответ
+CREATE TABLE idempotency_keys (
+    key TEXT NOT NULL, -- Значение заголовка Idempotency-Key
+    method TEXT NOT NULL,
+    path TEXT NOT NULL, -- Ключ действует в пределах одного адреса
+    request_hash TEXT NOT NULL, -- SHA-256 тела и параметров запроса
+    status_code INTEGER, -- NULL, пока первый запрос выполняется
+    content_type TEXT,
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key, method, path)
+);
+
+CREATE INDEX idempotency_keys_expires_idx ON idempotency_keys (expires_at);
+
+INSERT INTO maintenance_tasks (name, cron_expr, enabled) VALUES
+    ('idempotency_keys_cleanup', '15 * * * *', TRUE)
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,5 @@
+-- Индексы для распределения аккаунтов по заказам: подсчёт аккаунтов заказа и выбор свободных
+CREATE INDEX IF NOT EXISTS accounts_order_id_idx ON accounts (order_id);
+CREATE INDEX IF NOT EXISTS accounts_free_idx ON accounts (id)
+    WHERE order_id IS NULL AND is_authorized = TRUE
+        AND account_monitoring = FALSE AND account_generator_category = FALSE;
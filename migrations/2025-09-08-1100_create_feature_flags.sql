@@ -0,0 +1,34 @@
+-- Флаги включения подсистем без изменения кода и журнал административных изменений
+CREATE TABLE feature_flags (
+    id SERIAL PRIMARY KEY, -- Нужен триггеру notify_row_change
+    name TEXT NOT NULL UNIQUE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    description TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO feature_flags (name, description) VALUES
+    ('monitoring', 'Мониторинг новых постов в каналах заказов'),
+    ('channel_duplicate', 'Дублирование постов из каналов-доноров'),
+    ('accounts_sessions_disconnect', 'Фоновое отключение подозрительных сессий аккаунтов'),
+    ('post_view_scheduler', 'Планирование просмотров, реакций и репостов новых постов')
+ON CONFLICT (name) DO NOTHING;
+
+-- Реплики обновляют кэш флагов по уведомлениям, не перечитывая таблицу на каждое действие
+CREATE TRIGGER feature_flags_notify_trg
+AFTER INSERT OR UPDATE OR DELETE ON feature_flags
+FOR EACH ROW EXECUTE FUNCTION notify_row_change('feature_flags_changed');
+
+-- Журнал изменений, сделанных через административные методы API
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT NOT NULL, -- Кто изменил: заголовок X-Actor или api
+    action TEXT NOT NULL, -- Например, feature_flag.update
+    entity TEXT NOT NULL, -- Тип изменённого объекта
+    entity_id TEXT NOT NULL,
+    old_value JSONB,
+    new_value JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, created_at DESC);
@@ -0,0 +1,33 @@
+-- Явный статус аккаунта вместо разрозненных is_authorized, channels_limit_until и floodwait_until
+-- и история переходов между статусами
+CREATE TYPE account_status AS ENUM ('active', 'unauthorized', 'limited', 'disabled');
+
+ALTER TABLE accounts
+    ADD COLUMN status account_status NOT NULL DEFAULT 'active', -- is_authorized истинен для active и limited
+    ADD COLUMN status_changed_at TIMESTAMPTZ; -- Время последнего перехода
+
+UPDATE accounts SET status = CASE
+        WHEN NOT COALESCE(is_authorized, false) THEN 'unauthorized'
+        WHEN floodwait_until > NOW() OR channels_limit_until > NOW() THEN 'limited'
+        ELSE 'active'
+    END::account_status,
+    status_changed_at = NOW();
+
+-- Новый аккаунт становится active после подтверждения кода
+ALTER TABLE accounts ALTER COLUMN status SET DEFAULT 'unauthorized';
+
+CREATE TABLE account_events (
+    id BIGSERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    from_status account_status, -- NULL для начальной записи
+    to_status account_status NOT NULL,
+    reason TEXT NOT NULL, -- Причина перехода: ошибка Telegram, срок ограничения, комментарий оператора
+    source TEXT NOT NULL, -- Модуль, выполнивший переход
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX account_events_account_idx ON account_events (account_id, created_at DESC);
+
+-- Начальная точка истории каждого аккаунта
+INSERT INTO account_events (account_id, to_status, reason, source)
+SELECT id, status, 'статус определён по is_authorized и ограничениям', 'migration' FROM accounts;
@@ -0,0 +1,4 @@
+-- Пароль 2FA хранится у каждого аккаунта в зашифрованном виде (AES-GCM, ключ ATG_SECRET_KEY)
+ALTER TABLE accounts
+    ADD COLUMN two_fa_password_enc TEXT, -- NULL, если пароль не задан
+    ADD COLUMN two_fa_updated_at TIMESTAMPTZ;
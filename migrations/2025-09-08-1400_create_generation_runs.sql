@@ -0,0 +1,23 @@
+-- Запуски генерации подборки каналов. HTTP-запрос сразу возвращает ID запуска,
+-- а ход обхода рекомендаций сохраняется после каждого шага, чтобы продолжить его после перезапуска.
+CREATE TABLE generation_runs (
+    id BIGSERIAL PRIMARY KEY,
+    name_category TEXT NOT NULL, -- Название создаваемой категории
+    input_channels TEXT[] NOT NULL, -- Исходные каналы запроса
+    result_count_links INTEGER NOT NULL CHECK (result_count_links > 0),
+    status TEXT NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'cancelled')),
+    inputs_checked INTEGER NOT NULL DEFAULT 0, -- Сколько исходных каналов уже проверено
+    queue TEXT[] NOT NULL DEFAULT '{}', -- Каналы, ожидающие запроса рекомендаций
+    processed TEXT[] NOT NULL DEFAULT '{}', -- Каналы, рекомендации которых уже получены
+    results TEXT[] NOT NULL DEFAULT '{}', -- Найденные каналы с открытым обсуждением
+    category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL, -- Созданная категория
+    error TEXT, -- Причина неудачи
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX generation_runs_created_idx ON generation_runs (created_at DESC);
+CREATE INDEX generation_runs_active_idx ON generation_runs (status) WHERE status IN ('queued', 'running');
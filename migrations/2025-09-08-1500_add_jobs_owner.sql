@@ -0,0 +1,7 @@
+-- Владелец задания: задания выполняются в памяти реплики, поэтому прерванными
+-- считаются только задания этой же реплики или те, чей updated_at давно не обновлялся
+ALTER TABLE jobs
+    ADD COLUMN owner TEXT, -- Экземпляр сервиса (хост:pid), выполняющий задание
+    ADD COLUMN cancel_requested BOOLEAN NOT NULL DEFAULT FALSE; -- Отмена запрошена через другую реплику
+
+CREATE INDEX jobs_owner_active_idx ON jobs (owner) WHERE status IN ('queued', 'running');
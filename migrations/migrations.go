@@ -0,0 +1,51 @@
+// Package migrations встраивает SQL-миграции в бинарник,
+// чтобы приложение знало ожидаемую версию схемы без доступа к исходникам.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// Versions возвращает версии всех миграций в порядке применения.
+// Версией считается имя файла без расширения: формат даты в начале имени
+// гарантирует правильный порядок при обычной строковой сортировке.
+func Versions() []string {
+	entries, err := fs.ReadDir(FS, ".")
+	if err != nil {
+		// Встроенная ФС собирается компилятором, ошибка здесь невозможна
+		return nil
+	}
+	versions := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		versions = append(versions, strings.TrimSuffix(e.Name(), ".sql"))
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Latest возвращает версию последней миграции, известной приложению.
+func Latest() string {
+	versions := Versions()
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[len(versions)-1]
+}
+
+// Read возвращает SQL миграции по её версии.
+func Read(version string) (string, error) {
+	b, err := fs.ReadFile(FS, version+".sql")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Статусы аккаунта.
+const (
+	AccountStatusActive       = "active"
+	AccountStatusUnauthorized = "unauthorized" // Сессия недействительна, нужна повторная авторизация
+	AccountStatusLimited      = "limited"      // Флуд-вейт или лимит подписок Telegram
+	AccountStatusDisabled     = "disabled"     // Выключен оператором или заблокирован Telegram
+)
+
+// ValidAccountStatus проверяет, что статус аккаунта допустим.
+func ValidAccountStatus(s string) bool {
+	switch s {
+	case AccountStatusActive, AccountStatusUnauthorized, AccountStatusLimited, AccountStatusDisabled:
+		return true
+	}
+	return false
+}
+
+// CanTransitionAccountStatus сообщает, допустим ли переход между статусами.
+// Ограничение ставится только рабочему аккаунту, потеря авторизации не снимает
+// ручное отключение, а отключённый аккаунт возвращает в работу только оператор (byOperator).
+func CanTransitionAccountStatus(from, to string, byOperator bool) bool {
+	if from == to {
+		return false
+	}
+	switch to {
+	case AccountStatusLimited:
+		return from == AccountStatusActive
+	case AccountStatusUnauthorized:
+		return from == AccountStatusActive || from == AccountStatusLimited
+	case AccountStatusActive:
+		return from != AccountStatusDisabled || byOperator
+	case AccountStatusDisabled:
+		return true
+	}
+	return false
+}
+
+// AccountEvent — переход аккаунта между статусами.
+type AccountEvent struct {
+	ID         int64     `json:"id"`
+	AccountID  int       `json:"account_id"`
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	Source     string    `json:"source"`
+	CreatedAt  time.Time `json:"created_at"`
+}
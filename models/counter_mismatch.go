@@ -0,0 +1,11 @@
+package models
+
+// CounterMismatch — расхождение денормализованного счётчика с реальными строками.
+type CounterMismatch struct {
+	Check    string `json:"check"`     // Имя проверки, например orders.accounts_number_fact
+	EntityID int    `json:"entity_id"` // ID заказа или прокси
+	Stored   int    `json:"stored"`    // Значение, записанное в таблице
+	Actual   int    `json:"actual"`    // Значение, посчитанное по строкам
+	Fixable  bool   `json:"fixable"`   // Можно ли исправить без обращения к Telegram
+	Fixed    bool   `json:"fixed"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// FeatureFlag — флаг включения подсистемы.
+type FeatureFlag struct {
+	Name        string    `json:"name"`
+	Enabled     bool      `json:"enabled"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
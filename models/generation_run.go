@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// GenerationRun — запуск генерации подборки каналов с сохранённым ходом обхода рекомендаций.
+// Статусы совпадают со статусами фоновых заданий (JobQueued и др.).
+type GenerationRun struct {
+	ID               int64      `json:"id"`
+	NameCategory     string     `json:"name_category"`
+	InputChannels    []string   `json:"input_channels"`
+	ResultCountLinks int        `json:"result_count_links"`
+	Status           string     `json:"status"`
+	InputsChecked    int        `json:"inputs_checked"`
+	Queue            []string   `json:"queue"`
+	Processed        []string   `json:"processed"`
+	Results          []string   `json:"results"`
+	CategoryID       *int       `json:"category_id"`
+	Error            *string    `json:"error"`
+	CreatedAt        time.Time  `json:"created_at"`
+	StartedAt        *time.Time `json:"started_at"`
+	FinishedAt       *time.Time `json:"finished_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// IdempotencyKey — сохранённый результат запроса с заголовком Idempotency-Key.
+type IdempotencyKey struct {
+	Key         string
+	Method      string
+	Path        string
+	RequestHash string
+	StatusCode  *int // nil, пока первый запрос выполняется
+	ContentType string
+	Body        []byte
+}
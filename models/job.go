@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы фонового задания.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Job — фоновое задание длительной операции с прогрессом и итогом.
+type Job struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Status      string          `json:"status"`
+	Params      json.RawMessage `json:"params"`
+	Total       int             `json:"total"`
+	Processed   int             `json:"processed"`
+	Successful  int             `json:"successful"`
+	Failed      int             `json:"failed"`
+	CurrentItem *string         `json:"current_item"`
+	Result      json.RawMessage `json:"result"`
+	Error       *string         `json:"error"`
+	Owner       *string         `json:"owner"` // Экземпляр сервиса, выполняющий задание
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
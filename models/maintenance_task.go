@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// MaintenanceTask — регулярная служебная задача с расписанием в формате cron.
+type MaintenanceTask struct {
+	Name      string    `json:"name"`
+	CronExpr  string    `json:"cron_expr"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Статусы и источники запуска служебной задачи.
+const (
+	MaintenanceRunRunning = "running"
+	MaintenanceRunSuccess = "success"
+	MaintenanceRunFailed  = "failed"
+
+	MaintenanceTriggerSchedule = "schedule"
+	MaintenanceTriggerAPI      = "api"
+)
+
+// MaintenanceTaskRun — запись истории запуска служебной задачи.
+type MaintenanceTaskRun struct {
+	ID         int64      `json:"id"`
+	TaskName   string     `json:"task_name"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Status     string     `json:"status"`
+	Trigger    string     `json:"trigger"`
+	Result     *string    `json:"result"`
+	Error      *string    `json:"error"`
+}
@@ -12,6 +12,7 @@ import (
 // url_default - уникальная ссылка по умолчанию, которая хранится в заказе
 // accounts_number_theory - желаемое количество аккаунтов
 // accounts_number_fact - количество фактически задействованных аккаунтов
+// status - состояние заказа: active, paused или archived
 // date_time - время создания заказа
 //
 // Комментарии в коде на русском языке по требованию пользователя
@@ -28,5 +29,23 @@ type Order struct {
 	SubsActiveCount      *int           `json:"subs_active_count"` // Сколько аккаунтов должны активничать на канале; NULL, если не задано
 	PostReactions        pq.StringArray `json:"post_reactions"`    // Перечень реакций, задаётся в виде {"😀","😂"}; NULL — стандартный выбор
 	Gender               pq.StringArray `json:"gender"`            // Пол(ы) аккаунтов для заказа
+	Status               string         `json:"status"`            // active, paused или archived
+	ArchivedAt           *time.Time     `json:"archived_at"`
 	DateTime             time.Time      `json:"date_time"`
 }
+
+// Статусы заказа.
+const (
+	OrderStatusActive   = "active"
+	OrderStatusPaused   = "paused"   // Мониторинг и распределение аккаунтов остановлены, данные сохраняются
+	OrderStatusArchived = "archived" // Аккаунты освобождены, история постов и отчётов сохраняется
+)
+
+// ValidOrderStatus проверяет, что статус заказа допустим.
+func ValidOrderStatus(s string) bool {
+	switch s {
+	case OrderStatusActive, OrderStatusPaused, OrderStatusArchived:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Режимы очистки по сроку хранения.
+const (
+	RetentionModeDelete  = "delete"
+	RetentionModeArchive = "archive"
+)
+
+// RetentionPolicy — срок хранения строк таблицы и способ их очистки.
+type RetentionPolicy struct {
+	TableName   string     `json:"table_name"`
+	KeepDays    int        `json:"keep_days"`
+	Mode        string     `json:"mode"`
+	BatchSize   int        `json:"batch_size"`
+	Enabled     bool       `json:"enabled"`
+	LastRunAt   *time.Time `json:"last_run_at"`
+	LastRemoved int64      `json:"last_removed"` // Строк удалено последней очисткой
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы запланированного действия.
+const (
+	ScheduledActionPending   = "pending"
+	ScheduledActionRunning   = "running"
+	ScheduledActionDone      = "done"
+	ScheduledActionFailed    = "failed"
+	ScheduledActionCancelled = "cancelled"
+)
+
+// ScheduledAction — действие, отложенное до времени RunAt и хранящееся в БД,
+// чтобы перезапуск сервиса не терял запланированную работу.
+type ScheduledAction struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	OrderID     *int            `json:"order_id"`
+	AccountID   *int            `json:"account_id"`
+	Payload     json.RawMessage `json:"payload"`
+	RunAt       time.Time       `json:"run_at"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LeaseUntil  *time.Time      `json:"lease_until"`
+	LastError   *string         `json:"last_error"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SimulatedAction — запрос к Telegram, перехваченный в режиме dry-run.
+type SimulatedAction struct {
+	ID        int64           `json:"id"`
+	AccountID *int            `json:"account_id"`
+	Method    string          `json:"method"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+// Package clock отделяет планировщики от системного времени и глобального math/rand,
+// чтобы расчёты окон и задержек можно было проверять детерминированными тестами.
+package clock
+
+import "time"
+
+// Clock — источник текущего времени и таймеров.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// System — часы, работающие по системному времени.
+var System Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
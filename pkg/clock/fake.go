@@ -0,0 +1,74 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake — управляемые часы для тестов. Время меняется только через Advance и Set,
+// а каналы After срабатывают, когда время доходит до их срока.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFake создаёт часы, остановленные на моменте now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now возвращает текущее время фейковых часов.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After возвращает канал, который получит значение, когда часы дойдут до now+d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	deadline := f.now.Add(d)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, fakeWaiter{deadline: deadline, ch: ch})
+	return ch
+}
+
+// Advance сдвигает часы вперёд и будит ожидания, срок которых наступил.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set переводит часы на указанный момент.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = t
+	remaining := f.waiters[:0]
+	for _, w := range f.waiters {
+		if !w.deadline.After(t) {
+			w.ch <- t
+			continue
+		}
+		remaining = append(remaining, w)
+	}
+	f.waiters = remaining
+}
+
+// Waiters возвращает число незавершённых ожиданий After.
+// Тесты используют его, чтобы дождаться, пока проверяемый код встанет на таймер.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
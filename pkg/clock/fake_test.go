@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFakeAfter проверяет, что канал After срабатывает только после сдвига часов до срока.
+func TestFakeAfter(t *testing.T) {
+	start := time.Date(2025, 9, 8, 10, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	ch := f.After(10 * time.Second)
+	if f.Waiters() != 1 {
+		t.Fatalf("ожидалось одно ожидание, получено %d", f.Waiters())
+	}
+
+	f.Advance(9 * time.Second)
+	select {
+	case <-ch:
+		t.Fatalf("таймер сработал раньше срока")
+	default:
+	}
+
+	f.Advance(time.Second)
+	select {
+	case got := <-ch:
+		if !got.Equal(start.Add(10 * time.Second)) {
+			t.Fatalf("неожиданное время срабатывания: %v", got)
+		}
+	default:
+		t.Fatalf("таймер не сработал в срок")
+	}
+	if f.Waiters() != 0 {
+		t.Fatalf("после срабатывания ожиданий быть не должно")
+	}
+
+	// Нулевая задержка срабатывает сразу
+	select {
+	case <-f.After(0):
+	default:
+		t.Fatalf("нулевая задержка должна срабатывать сразу")
+	}
+}
+
+// TestNewRNGDeterministic проверяет, что одинаковое зерно даёт одинаковую последовательность.
+func TestNewRNGDeterministic(t *testing.T) {
+	a, b := NewRNG(42), NewRNG(42)
+	for i := 0; i < 10; i++ {
+		if a.Intn(1000) != b.Intn(1000) {
+			t.Fatalf("последовательности с одинаковым зерном разошлись на шаге %d", i)
+		}
+	}
+}
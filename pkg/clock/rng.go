@@ -0,0 +1,51 @@
+package clock
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RNG — источник случайных чисел, который можно подменить в тестах.
+type RNG interface {
+	Intn(n int) int
+	Float64() float64
+	Int63() int64
+}
+
+// lockedRNG защищает *rand.Rand мьютексом: в отличие от глобального генератора,
+// отдельный экземпляр небезопасен для одновременного использования.
+type lockedRNG struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// NewRNG создаёт потокобезопасный генератор с заданным зерном.
+// Одинаковое зерно даёт одинаковую последовательность, что удобно в тестах.
+func NewRNG(seed int64) RNG {
+	return &lockedRNG{r: rand.New(rand.NewSource(seed))}
+}
+
+// NewRandomRNG создаёт генератор, засеянный текущим временем.
+// Заменяет повторные вызовы rand.Seed в разных частях приложения.
+func NewRandomRNG() RNG {
+	return NewRNG(time.Now().UnixNano())
+}
+
+func (l *lockedRNG) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+func (l *lockedRNG) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+func (l *lockedRNG) Int63() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63()
+}
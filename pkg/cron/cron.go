@@ -0,0 +1,173 @@
+// Package cron разбирает классические пятипольные cron-выражения
+// (минута, час, день месяца, месяц, день недели) и вычисляет ближайший запуск.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule — разобранное выражение: для каждого поля хранится множество допустимых значений.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar и dowStar нужны для правила cron: если ограничены оба поля дня,
+	// достаточно совпадения любого из них
+	domStar, dowStar bool
+}
+
+// field описывает допустимый диапазон поля выражения.
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"минута", 0, 59},
+	{"час", 0, 23},
+	{"день месяца", 1, 31},
+	{"месяц", 1, 12},
+	{"день недели", 0, 7},
+}
+
+// macros — сокращения для частых расписаний.
+var macros = map[string]string{
+	"@yearly":  "0 0 1 1 *",
+	"@monthly": "0 0 1 * *",
+	"@weekly":  "0 0 * * 0",
+	"@daily":   "0 0 * * *",
+	"@hourly":  "0 * * * *",
+}
+
+// Parse разбирает выражение. Поддерживаются *, списки через запятую,
+// диапазоны a-b, шаги */n и a-b/n, а также макросы @daily, @hourly и др.
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if m, ok := macros[expr]; ok {
+		expr = m
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("ожидается 5 полей, получено %d", len(parts))
+	}
+
+	var bits [5]uint64
+	for i, p := range parts {
+		b, err := parseField(p, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = b
+	}
+	// Воскресенье допускается и как 0, и как 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &Schedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: strings.HasPrefix(parts[2], "*"),
+		dowStar: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseField разбирает одно поле в битовую маску допустимых значений.
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			rangePart = item[:i]
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("поле %s: некорректный шаг в %q", f.name, item)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			a, err1 := strconv.Atoi(bounds[0])
+			b, err2 := strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil || a > b {
+				return 0, fmt.Errorf("поле %s: некорректный диапазон %q", f.name, rangePart)
+			}
+			lo, hi = a, b
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("поле %s: некорректное значение %q", f.name, rangePart)
+			}
+			lo = v
+			// Одиночное значение со степом (5/15) означает «от 5 до конца диапазона»
+			if step == 1 {
+				hi = v
+			}
+		}
+		if lo < f.min || hi > f.max {
+			return 0, fmt.Errorf("поле %s: значение вне диапазона %d-%d", f.name, f.min, f.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// maxSearch ограничивает поиск следующего запуска: выражения вроде «30 февраля» не срабатывают никогда.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Next возвращает первый момент строго после t, подходящий под расписание.
+// Расчёт ведётся в часовом поясе t. Если момент не найден, возвращается нулевое время.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches применяет правило cron для дня месяца и дня недели.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<uint(t.Day())) != 0
+	dowOK := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// Matches сообщает, подходит ли минута t под расписание. Секунды не учитываются.
+func (s *Schedule) Matches(t time.Time) bool {
+	t = t.Truncate(time.Minute)
+	return s.month&(1<<uint(t.Month())) != 0 &&
+		s.dayMatches(t) &&
+		s.hour&(1<<uint(t.Hour())) != 0 &&
+		s.minute&(1<<uint(t.Minute())) != 0
+}
@@ -0,0 +1,84 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNext проверяет расчёт ближайшего запуска для типичных выражений.
+func TestNext(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	from := time.Date(2025, 9, 8, 10, 17, 30, 0, loc) // понедельник
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 9, 8, 10, 18, 0, 0, loc)},
+		{"0 2,11 * * *", time.Date(2025, 9, 8, 11, 0, 0, 0, loc)},
+		{"*/15 * * * *", time.Date(2025, 9, 8, 10, 30, 0, 0, loc)},
+		{"5/20 * * * *", time.Date(2025, 9, 8, 10, 25, 0, 0, loc)},
+		{"0 9-17/4 * * *", time.Date(2025, 9, 8, 13, 0, 0, 0, loc)},
+		{"30 4 * * *", time.Date(2025, 9, 9, 4, 30, 0, 0, loc)},
+		{"0 0 1 * *", time.Date(2025, 10, 1, 0, 0, 0, 0, loc)},
+		{"0 12 * * 0", time.Date(2025, 9, 14, 12, 0, 0, 0, loc)},
+		{"0 12 * * 7", time.Date(2025, 9, 14, 12, 0, 0, 0, loc)},
+		// Ограничены оба поля дня — достаточно любого совпадения
+		{"0 0 15 * 3", time.Date(2025, 9, 10, 0, 0, 0, 0, loc)},
+		{"@daily", time.Date(2025, 9, 9, 0, 0, 0, 0, loc)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, loc)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			if err != nil {
+				t.Fatalf("разбор завершился ошибкой: %v", err)
+			}
+			if got := s.Next(from); !got.Equal(tc.want) {
+				t.Fatalf("получено %v, ожидалось %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestNextNever проверяет, что невозможная дата не зацикливает поиск.
+func TestNextNever(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("разбор завершился ошибкой: %v", err)
+	}
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Fatalf("ожидалось нулевое время, получено %v", got)
+	}
+}
+
+// TestParseErrors проверяет отказ на некорректных выражениях.
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Fatalf("ожидалась ошибка для %q", expr)
+		}
+	}
+}
+
+// TestMatches проверяет совпадение минуты с расписанием.
+func TestMatches(t *testing.T) {
+	s, err := Parse("0 2,11 * * *")
+	if err != nil {
+		t.Fatalf("разбор завершился ошибкой: %v", err)
+	}
+	cases := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2025, 9, 8, 2, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 9, 8, 11, 0, 42, 0, time.UTC), true},
+		{time.Date(2025, 9, 8, 11, 1, 0, 0, time.UTC), false},
+		{time.Date(2025, 9, 8, 3, 0, 0, 0, time.UTC), false},
+	}
+	for _, tc := range cases {
+		if got := s.Matches(tc.at); got != tc.want {
+			t.Fatalf("%v: получено %v, ожидалось %v", tc.at, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package featureflags хранит в памяти флаги включения подсистем из таблицы feature_flags.
+// Кэш загружается при старте и обновляется по уведомлениям feature_flags_changed,
+// поэтому модули проверяют флаг перед каждым действием без обращения к БД.
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"atg_go/models"
+	"atg_go/pkg/storage"
+)
+
+// Флаги подсистем; строки совпадают с feature_flags.name.
+const (
+	Monitoring                 = "monitoring"
+	ChannelDuplicate           = "channel_duplicate"
+	AccountsSessionsDisconnect = "accounts_sessions_disconnect"
+	PostViewScheduler          = "post_view_scheduler"
+)
+
+// notifyChannel — канал уведомлений триггера feature_flags_notify_trg.
+const notifyChannel = "feature_flags_changed"
+
+// Cache — значения флагов по имени. Неизвестный флаг считается включённым:
+// до загрузки кэша и без строки в таблице подсистемы работают как раньше.
+type Cache struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// NewCache создаёт пустой кэш.
+func NewCache() *Cache {
+	return &Cache{flags: make(map[string]bool)}
+}
+
+// Enabled сообщает, включена ли подсистема.
+func (c *Cache) Enabled(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	enabled, ok := c.flags[name]
+	return !ok || enabled
+}
+
+// Set запоминает значение флага.
+func (c *Cache) Set(name string, enabled bool) {
+	c.mu.Lock()
+	c.flags[name] = enabled
+	c.mu.Unlock()
+}
+
+// replace заменяет содержимое кэша списком из БД.
+func (c *Cache) replace(list []models.FeatureFlag) {
+	flags := make(map[string]bool, len(list))
+	for _, f := range list {
+		flags[f.Name] = f.Enabled
+	}
+	c.mu.Lock()
+	c.flags = flags
+	c.mu.Unlock()
+}
+
+// Load перечитывает все флаги из БД.
+func (c *Cache) Load(ctx context.Context, db *storage.DB) error {
+	list, err := db.GetFeatureFlags(ctx)
+	if err != nil {
+		return err
+	}
+	c.replace(list)
+	return nil
+}
+
+// apply применяет уведомление об изменении строки. Возвращает false,
+// если в уведомлении нет строки и кэш нужно перечитать целиком.
+func (c *Cache) apply(n storage.Notification) bool {
+	if len(n.Row) == 0 {
+		return false
+	}
+	var row struct {
+		Name    string `json:"name"`
+		Enabled bool   `json:"enabled"`
+	}
+	if err := json.Unmarshal(n.Row, &row); err != nil || row.Name == "" {
+		return false
+	}
+	if n.Op == storage.NotifyDelete {
+		c.mu.Lock()
+		delete(c.flags, row.Name)
+		c.mu.Unlock()
+		return true
+	}
+	c.Set(row.Name, row.Enabled)
+	return true
+}
+
+// Watch подписывает кэш на уведомления feature_flags_changed до отмены ctx.
+// После переподключения слушателя флаги перечитываются целиком.
+func (c *Cache) Watch(ctx context.Context, db *storage.DB, notifier *storage.Notifier) error {
+	reload := func() {
+		if err := c.Load(ctx, db); err != nil {
+			log.Printf("[FEATURE FLAGS] загрузка флагов: %v", err)
+		}
+	}
+	unsubscribe, err := notifier.Subscribe(notifyChannel, func(n storage.Notification) {
+		if !c.apply(n) {
+			reload()
+		}
+	}, reload)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+	return nil
+}
+
+// flags — кэш процесса, который проверяют модули.
+var flags = NewCache()
+
+// Default возвращает кэш процесса.
+func Default() *Cache {
+	return flags
+}
+
+// Enabled сообщает, включена ли подсистема, по кэшу процесса.
+func Enabled(name string) bool {
+	return flags.Enabled(name)
+}
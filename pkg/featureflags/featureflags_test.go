@@ -0,0 +1,40 @@
+package featureflags
+
+import (
+	"testing"
+
+	"atg_go/models"
+	"atg_go/pkg/storage"
+)
+
+// TestCacheApply проверяет обновление кэша уведомлениями и значение по умолчанию.
+func TestCacheApply(t *testing.T) {
+	c := NewCache()
+	if !c.Enabled(Monitoring) {
+		t.Fatal("неизвестный флаг должен считаться включённым")
+	}
+
+	c.replace([]models.FeatureFlag{{Name: Monitoring, Enabled: false}, {Name: ChannelDuplicate, Enabled: true}})
+	if c.Enabled(Monitoring) || !c.Enabled(ChannelDuplicate) {
+		t.Fatal("кэш не заполнен из списка")
+	}
+
+	if !c.apply(storage.Notification{Op: storage.NotifyUpdate, Row: []byte(`{"id":1,"name":"monitoring","enabled":true}`)}) {
+		t.Fatal("уведомление со строкой не применено")
+	}
+	if !c.Enabled(Monitoring) {
+		t.Fatal("флаг не включён уведомлением")
+	}
+
+	if !c.apply(storage.Notification{Op: storage.NotifyUpdate, Row: []byte(`{"id":2,"name":"channel_duplicate","enabled":false}`)}) || c.Enabled(ChannelDuplicate) {
+		t.Fatal("флаг не выключен уведомлением")
+	}
+	if !c.apply(storage.Notification{Op: storage.NotifyDelete, Row: []byte(`{"id":2,"name":"channel_duplicate","enabled":false}`)}) || !c.Enabled(ChannelDuplicate) {
+		t.Fatal("удалённый флаг должен считаться включённым")
+	}
+
+	// Без строки уведомление требует полной перезагрузки
+	if c.apply(storage.Notification{Op: storage.NotifyUpdate, ID: 1}) {
+		t.Fatal("уведомление без строки не должно применяться")
+	}
+}
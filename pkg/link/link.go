@@ -0,0 +1,227 @@
+// Package link разбирает ссылки Telegram на каналы, посты и приглашения.
+// Все модули используют один разбор, чтобы одинаково понимать ссылки из заказов,
+// донорских каналов и API.
+package link
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind — тип ссылки.
+type Kind string
+
+const (
+	// KindPublic — публичный канал или пост по username: t.me/name, t.me/name/123, @name.
+	KindPublic Kind = "public"
+	// KindPrivate — пост закрытого канала по внутреннему ID: t.me/c/<id>/<msg>.
+	KindPrivate Kind = "private"
+	// KindInvite — пригласительная ссылка: t.me/+hash, t.me/joinchat/hash.
+	KindInvite Kind = "invite"
+)
+
+// ErrInvalid означает, что строка не является поддерживаемой ссылкой Telegram.
+var ErrInvalid = errors.New("некорректная ссылка Telegram")
+
+// Link — результат разбора ссылки. Заполнены только поля, относящиеся к Kind.
+type Link struct {
+	Kind       Kind
+	Username   string // Для KindPublic
+	ChannelID  int64  // Для KindPrivate
+	MessageID  int    // ID поста, 0 если ссылка ведёт на сам канал
+	InviteHash string // Для KindInvite
+}
+
+// usernameRe повторяет правила Telegram: латиница, цифры и подчёркивание, начинается с буквы.
+var usernameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{3,31}$`)
+
+// hosts — домены, ссылки на которые считаются ссылками Telegram.
+var hosts = map[string]struct{}{
+	"t.me":        {},
+	"telegram.me": {},
+}
+
+// reserved — служебные пути t.me, которые не являются username.
+var reserved = map[string]struct{}{
+	"addstickers": {},
+	"addemoji":    {},
+	"addtheme":    {},
+	"share":       {},
+	"proxy":       {},
+	"socks":       {},
+	"login":       {},
+	"setlanguage": {},
+	"iv":          {},
+}
+
+// Parse разбирает ссылку. Поддерживаются схемы http/https и ссылки без схемы,
+// домены t.me и telegram.me, запись @username, превью /s/, приватные ссылки /c/<id>/<msg>,
+// приглашения, а также параметры запроса и завершающий слэш.
+func Parse(raw string) (Link, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return Link{}, fmt.Errorf("%w: пустая строка", ErrInvalid)
+	}
+	if strings.HasPrefix(s, "@") {
+		return publicLink(s[1:], "", raw)
+	}
+
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return Link{}, fmt.Errorf("%w: %q", ErrInvalid, raw)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Link{}, fmt.Errorf("%w: неподдерживаемая схема %q", ErrInvalid, u.Scheme)
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if _, ok := hosts[host]; !ok {
+		return Link{}, fmt.Errorf("%w: домен %q", ErrInvalid, u.Host)
+	}
+
+	var segs []string
+	for _, p := range strings.Split(u.Path, "/") {
+		if p != "" {
+			segs = append(segs, p)
+		}
+	}
+	// Превью канала t.me/s/name ведёт туда же, что и t.me/name
+	if len(segs) > 0 && segs[0] == "s" {
+		segs = segs[1:]
+	}
+	if len(segs) == 0 {
+		return Link{}, fmt.Errorf("%w: нет пути в %q", ErrInvalid, raw)
+	}
+
+	switch {
+	case strings.HasPrefix(segs[0], "+"):
+		return inviteLink(strings.TrimPrefix(segs[0], "+"), len(segs), raw)
+	case segs[0] == "joinchat":
+		if len(segs) < 2 {
+			return Link{}, fmt.Errorf("%w: пустое приглашение %q", ErrInvalid, raw)
+		}
+		return inviteLink(segs[1], len(segs)-1, raw)
+	case segs[0] == "c":
+		return privateLink(segs[1:], raw)
+	}
+
+	switch len(segs) {
+	case 1:
+		return publicLink(segs[0], "", raw)
+	case 2:
+		return publicLink(segs[0], segs[1], raw)
+	case 3:
+		// Сообщение в теме форума: t.me/name/<thread>/<msg>
+		if _, err := parseMessageID(segs[1]); err != nil {
+			return Link{}, fmt.Errorf("%w: %q", ErrInvalid, raw)
+		}
+		return publicLink(segs[0], segs[2], raw)
+	}
+	return Link{}, fmt.Errorf("%w: %q", ErrInvalid, raw)
+}
+
+// Username возвращает username публичного канала из ссылки.
+// Для приватных ссылок и приглашений возвращается ошибка: их нельзя разрешить по имени.
+func Username(raw string) (string, error) {
+	l, err := Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if l.Kind != KindPublic {
+		return "", fmt.Errorf("%w: ссылка %q не содержит username", ErrInvalid, raw)
+	}
+	return l.Username, nil
+}
+
+// Post разбирает ссылку на пост публичного канала и возвращает username и ID сообщения.
+func Post(raw string) (string, int, error) {
+	l, err := Parse(raw)
+	if err != nil {
+		return "", 0, err
+	}
+	if l.Kind != KindPublic || l.MessageID == 0 {
+		return "", 0, fmt.Errorf("%w: %q не является ссылкой на пост публичного канала", ErrInvalid, raw)
+	}
+	return l.Username, l.MessageID, nil
+}
+
+// String возвращает каноническую https-ссылку.
+func (l Link) String() string {
+	switch l.Kind {
+	case KindPublic:
+		if l.MessageID != 0 {
+			return fmt.Sprintf("https://t.me/%s/%d", l.Username, l.MessageID)
+		}
+		return "https://t.me/" + l.Username
+	case KindPrivate:
+		if l.MessageID != 0 {
+			return fmt.Sprintf("https://t.me/c/%d/%d", l.ChannelID, l.MessageID)
+		}
+		return fmt.Sprintf("https://t.me/c/%d", l.ChannelID)
+	case KindInvite:
+		return "https://t.me/+" + l.InviteHash
+	}
+	return ""
+}
+
+// publicLink проверяет username и необязательный ID сообщения.
+func publicLink(name, msg, raw string) (Link, error) {
+	if _, ok := reserved[strings.ToLower(name)]; ok || !usernameRe.MatchString(name) {
+		return Link{}, fmt.Errorf("%w: некорректный username в %q", ErrInvalid, raw)
+	}
+	l := Link{Kind: KindPublic, Username: name}
+	if msg != "" {
+		id, err := parseMessageID(msg)
+		if err != nil {
+			return Link{}, fmt.Errorf("%w: некорректный ID сообщения в %q", ErrInvalid, raw)
+		}
+		l.MessageID = id
+	}
+	return l, nil
+}
+
+// privateLink разбирает части пути после /c/: <id>[/<thread>]/<msg>.
+func privateLink(segs []string, raw string) (Link, error) {
+	if len(segs) == 0 || len(segs) > 3 {
+		return Link{}, fmt.Errorf("%w: %q", ErrInvalid, raw)
+	}
+	id, err := strconv.ParseInt(segs[0], 10, 64)
+	if err != nil || id <= 0 {
+		return Link{}, fmt.Errorf("%w: некорректный ID канала в %q", ErrInvalid, raw)
+	}
+	l := Link{Kind: KindPrivate, ChannelID: id}
+	if len(segs) > 1 {
+		msg, err := parseMessageID(segs[len(segs)-1])
+		if err != nil {
+			return Link{}, fmt.Errorf("%w: некорректный ID сообщения в %q", ErrInvalid, raw)
+		}
+		l.MessageID = msg
+	}
+	return l, nil
+}
+
+// inviteLink проверяет хэш приглашения; после хэша в пути ничего быть не должно.
+func inviteLink(hash string, segs int, raw string) (Link, error) {
+	if hash == "" || segs != 1 {
+		return Link{}, fmt.Errorf("%w: некорректное приглашение %q", ErrInvalid, raw)
+	}
+	return Link{Kind: KindInvite, InviteHash: hash}, nil
+}
+
+// parseMessageID принимает только положительные ID сообщений.
+func parseMessageID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, fmt.Errorf("ID сообщения должен быть положительным")
+	}
+	return id, nil
+}
@@ -0,0 +1,113 @@
+package link
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestParse проверяет разбор всех поддерживаемых форм ссылок.
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Link
+	}{
+		{"https://t.me/durov", Link{Kind: KindPublic, Username: "durov"}},
+		{"http://t.me/durov/", Link{Kind: KindPublic, Username: "durov"}},
+		{"t.me/durov", Link{Kind: KindPublic, Username: "durov"}},
+		{"https://telegram.me/durov", Link{Kind: KindPublic, Username: "durov"}},
+		{"https://www.t.me/durov", Link{Kind: KindPublic, Username: "durov"}},
+		{"HTTPS://T.ME/Durov", Link{Kind: KindPublic, Username: "Durov"}},
+		{"@durov", Link{Kind: KindPublic, Username: "durov"}},
+		{"  https://t.me/durov  ", Link{Kind: KindPublic, Username: "durov"}},
+		{"https://t.me/durov/123", Link{Kind: KindPublic, Username: "durov", MessageID: 123}},
+		{"https://t.me/durov/123?single", Link{Kind: KindPublic, Username: "durov", MessageID: 123}},
+		{"https://t.me/durov/123/?comment=5#x", Link{Kind: KindPublic, Username: "durov", MessageID: 123}},
+		{"https://t.me/s/durov", Link{Kind: KindPublic, Username: "durov"}},
+		{"https://t.me/s/durov/42", Link{Kind: KindPublic, Username: "durov", MessageID: 42}},
+		{"https://t.me/forum_chat/7/99", Link{Kind: KindPublic, Username: "forum_chat", MessageID: 99}},
+		{"https://t.me/c/1234567890/55", Link{Kind: KindPrivate, ChannelID: 1234567890, MessageID: 55}},
+		{"https://t.me/c/1234567890", Link{Kind: KindPrivate, ChannelID: 1234567890}},
+		{"https://t.me/c/1234567890/3/55", Link{Kind: KindPrivate, ChannelID: 1234567890, MessageID: 55}},
+		{"https://t.me/+AbCdEf_123", Link{Kind: KindInvite, InviteHash: "AbCdEf_123"}},
+		{"https://t.me/joinchat/AbCdEf", Link{Kind: KindInvite, InviteHash: "AbCdEf"}},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.in)
+		if err != nil {
+			t.Errorf("Parse(%q): неожиданная ошибка %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Parse(%q) = %+v, ожидалось %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestParseInvalid проверяет, что посторонние и битые ссылки отклоняются.
+func TestParseInvalid(t *testing.T) {
+	cases := []string{
+		"",
+		"https://example.com/durov",
+		"ftp://t.me/durov",
+		"https://t.me/",
+		"https://t.me/s/",
+		"https://t.me/du",
+		"https://t.me/1durov",
+		"https://t.me/durov/abc",
+		"https://t.me/durov/0",
+		"https://t.me/durov/1/2/3",
+		"https://t.me/c/abc/1",
+		"https://t.me/c/",
+		"https://t.me/+",
+		"https://t.me/joinchat",
+		"https://t.me/share/url",
+		"https://t.me/addstickers",
+		"@",
+	}
+	for _, in := range cases {
+		if l, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %+v, ожидалась ошибка", in, l)
+		} else if !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q): ошибка %v должна оборачивать ErrInvalid", in, err)
+		}
+	}
+}
+
+// TestUsernameAndPost проверяет вспомогательные функции для публичных ссылок.
+func TestUsernameAndPost(t *testing.T) {
+	if name, err := Username("https://t.me/durov/5"); err != nil || name != "durov" {
+		t.Fatalf("Username: %q, %v", name, err)
+	}
+	if _, err := Username("https://t.me/c/123/5"); err == nil {
+		t.Fatal("Username должен отклонять приватные ссылки")
+	}
+	if _, err := Username("https://t.me/+hash"); err == nil {
+		t.Fatal("Username должен отклонять приглашения")
+	}
+
+	name, msg, err := Post("t.me/durov/77/")
+	if err != nil || name != "durov" || msg != 77 {
+		t.Fatalf("Post: %q, %d, %v", name, msg, err)
+	}
+	if _, _, err := Post("https://t.me/durov"); err == nil {
+		t.Fatal("Post должен требовать ID сообщения")
+	}
+}
+
+// TestString проверяет каноническое представление.
+func TestString(t *testing.T) {
+	for in, want := range map[string]string{
+		"@durov":                    "https://t.me/durov",
+		"telegram.me/s/durov/9?x=1": "https://t.me/durov/9",
+		"t.me/c/100/2":              "https://t.me/c/100/2",
+		"t.me/joinchat/abc":         "https://t.me/+abc",
+	} {
+		l, err := Parse(in)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", in, err)
+		}
+		if got := l.String(); got != want {
+			t.Errorf("String(%q) = %q, ожидалось %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package secret шифрует секреты аккаунтов (пароли 2FA) перед записью в БД.
+// Используется AES-256-GCM с ключом из ATG_SECRET_KEY; в БД хранится только шифротекст.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrNoKey означает, что ключ шифрования не настроен.
+var ErrNoKey = errors.New("ключ шифрования ATG_SECRET_KEY не задан")
+
+// version — префикс формата шифротекста; смена алгоритма получит новый префикс.
+const version = "v1:"
+
+// keySize — длина ключа AES-256.
+const keySize = 32
+
+// Box шифрует и расшифровывает значения одним ключом.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// ParseKey разбирает ключ из base64 или hex; ключ должен содержать 32 байта.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == keySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(s); err == nil && len(key) == keySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("ключ шифрования должен содержать %d байта в base64 или hex", keySize)
+}
+
+// NewBox создаёт шифратор с ключом key длиной 32 байта.
+func NewBox(key []byte) (*Box, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("ключ шифрования должен содержать %d байта", keySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal шифрует plaintext. Результат содержит случайный nonce, поэтому
+// одинаковые пароли дают разные шифротексты.
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return version + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open расшифровывает значение, полученное от Seal.
+func (b *Box) Open(ciphertext string) (string, error) {
+	raw, ok := strings.CutPrefix(ciphertext, version)
+	if !ok {
+		return "", fmt.Errorf("неизвестный формат шифротекста")
+	}
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return "", fmt.Errorf("некорректный шифротекст: %w", err)
+	}
+	n := b.aead.NonceSize()
+	if len(data) < n {
+		return "", fmt.Errorf("некорректный шифротекст: слишком короткий")
+	}
+	plain, err := b.aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		// Обычно означает другой ключ: секрет записан с прежним ATG_SECRET_KEY
+		return "", fmt.Errorf("не удалось расшифровать: %w", err)
+	}
+	return string(plain), nil
+}
+
+// box — шифратор процесса; nil, пока ключ не настроен.
+var box atomic.Pointer[Box]
+
+// Configure задаёт ключ процесса из значения ATG_SECRET_KEY.
+// Пустое значение оставляет шифрование выключенным.
+func Configure(key string) error {
+	if key == "" {
+		box.Store(nil)
+		return nil
+	}
+	k, err := ParseKey(key)
+	if err != nil {
+		return err
+	}
+	b, err := NewBox(k)
+	if err != nil {
+		return err
+	}
+	box.Store(b)
+	return nil
+}
+
+// Seal шифрует значение ключом процесса.
+func Seal(plaintext string) (string, error) {
+	b := box.Load()
+	if b == nil {
+		return "", ErrNoKey
+	}
+	return b.Seal(plaintext)
+}
+
+// Open расшифровывает значение ключом процесса.
+func Open(ciphertext string) (string, error) {
+	b := box.Load()
+	if b == nil {
+		return "", ErrNoKey
+	}
+	return b.Open(ciphertext)
+}
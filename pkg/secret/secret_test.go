@@ -0,0 +1,77 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestBoxRoundTrip проверяет шифрование и расшифровку, а также отказ при чужом ключе.
+func TestBoxRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, keySize)
+	b, err := NewBox(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := b.Seal("пароль 2FA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := b.Seal("пароль 2FA")
+	if first == second {
+		t.Fatal("одинаковые пароли должны давать разные шифротексты")
+	}
+	if strings.Contains(first, "пароль") || !strings.HasPrefix(first, version) {
+		t.Fatalf("неожиданный шифротекст %q", first)
+	}
+	plain, err := b.Open(first)
+	if err != nil || plain != "пароль 2FA" {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+
+	other, _ := NewBox(bytes.Repeat([]byte{8}, keySize))
+	if _, err := other.Open(first); err == nil {
+		t.Fatal("чужой ключ не должен расшифровывать значение")
+	}
+	if _, err := b.Open("v0:abc"); err == nil {
+		t.Fatal("неизвестный формат должен отклоняться")
+	}
+}
+
+// TestParseKey проверяет форматы ATG_SECRET_KEY.
+func TestParseKey(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, keySize)
+	for _, s := range []string{base64.StdEncoding.EncodeToString(key), hex.EncodeToString(key)} {
+		got, err := ParseKey(s)
+		if err != nil || !bytes.Equal(got, key) {
+			t.Fatalf("ParseKey(%q) = %v, %v", s, got, err)
+		}
+	}
+	if _, err := ParseKey("short"); err == nil {
+		t.Fatal("короткий ключ должен отклоняться")
+	}
+}
+
+// TestConfigure проверяет работу без ключа и с ключом процесса.
+func TestConfigure(t *testing.T) {
+	if err := Configure(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Seal("x"); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("без ключа ожидалась ErrNoKey, получено %v", err)
+	}
+	if err := Configure(hex.EncodeToString(bytes.Repeat([]byte{2}, keySize))); err != nil {
+		t.Fatal(err)
+	}
+	defer Configure("")
+	enc, err := Seal("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := Open(enc); err != nil || plain != "x" {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+}
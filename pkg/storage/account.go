@@ -2,6 +2,7 @@ package storage
 
 import (
 	"atg_go/models"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -11,7 +12,9 @@ import (
 
 // CreateAccount записывает аккаунт в БД, чтобы в дальнейшем
 // не приходилось заново вводить параметры.
-func (db *DB) CreateAccount(account models.Account) (*models.Account, error) {
+func (db *DB) CreateAccount(ctx context.Context, account models.Account) (*models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	query := `
               INSERT INTO accounts (phone, api_id, api_hash, phone_code_hash, proxy_id, gender)
               VALUES ($1, $2, $3, $4, $5, $6)
@@ -21,7 +24,7 @@ func (db *DB) CreateAccount(account models.Account) (*models.Account, error) {
 	// Фильтруем список полов через общую функцию, чтобы избежать дублирования логики
 	gender := models.FilterGenders(account.Gender)
 
-	err := db.Conn.QueryRow(
+	err := db.Conn.QueryRowContext(ctx,
 		query,
 		account.Phone,
 		account.ApiID,
@@ -42,7 +45,9 @@ func (db *DB) CreateAccount(account models.Account) (*models.Account, error) {
 
 // GetAccountByID возвращает аккаунт вместе с привязкой к прокси,
 // чтобы сервисы могли работать с полными данными.
-func (db *DB) GetAccountByID(id int) (*models.Account, error) {
+func (db *DB) GetAccountByID(ctx context.Context, id int) (*models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var account models.Account
 
 	var (
@@ -65,7 +70,7 @@ func (db *DB) GetAccountByID(id int) (*models.Account, error) {
               LEFT JOIN proxy p ON a.proxy_id = p.id
               WHERE a.id = $1
        `
-	err := db.Conn.QueryRow(query, id).Scan(
+	err := db.Conn.QueryRowContext(ctx, query, id).Scan(
 		&account.ID,
 		&account.Phone,
 		&account.ApiID,
@@ -120,7 +125,9 @@ func (db *DB) GetAccountByID(id int) (*models.Account, error) {
 
 // GetLastAccount нужен, чтобы использовать свежесозданный аккаунт
 // без дополнительного запроса его идентификатора.
-func (db *DB) GetLastAccount() (*models.Account, error) {
+func (db *DB) GetLastAccount(ctx context.Context) (*models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var account models.Account
 
 	// Переменные для возможных NULL-значений связанных с прокси
@@ -147,7 +154,7 @@ func (db *DB) GetLastAccount() (*models.Account, error) {
        `
 
 	// Получаем последнюю запись из таблицы accounts
-	err := db.Conn.QueryRow(query).Scan(
+	err := db.Conn.QueryRowContext(ctx, query).Scan(
 		&account.ID,
 		&account.Phone,
 		&account.ApiID,
@@ -201,28 +208,18 @@ func (db *DB) GetLastAccount() (*models.Account, error) {
 }
 
 // MarkAccountAsAuthorized фиксирует факт авторизации, чтобы другие сервисы
-// понимали, что сессия активна.
-func (db *DB) MarkAccountAsAuthorized(accountID int) error {
-	_, err := db.Conn.Exec(
-		"UPDATE accounts SET is_authorized = true WHERE id = $1",
-		accountID,
-	)
-	return err
-}
-
-// MarkAccountAsUnauthorized сбрасывает флаг авторизации,
-// чтобы в БД отражалось фактическое отсутствие рабочей сессии.
-func (db *DB) MarkAccountAsUnauthorized(accountID int) error {
-	_, err := db.Conn.Exec(
-		"UPDATE accounts SET is_authorized = false WHERE id = $1",
-		accountID,
-	)
+// понимали, что сессия активна. Переход записывается в историю аккаунта.
+// Отключённый оператором аккаунт и аккаунт под флуд-вейтом статус не меняют.
+func (db *DB) MarkAccountAsAuthorized(ctx context.Context, accountID int) error {
+	_, err := db.SetAccountStatus(ctx, accountID, models.AccountStatusActive, "авторизация подтверждена кодом", AccountSourceAuth)
 	return err
 }
 
 // UpdatePhoneCodeHash обновляет hash, чтобы повторно не запрашивать код у пользователя.
-func (db *DB) UpdatePhoneCodeHash(accountID int, hash string) error {
-	_, err := db.Conn.Exec(
+func (db *DB) UpdatePhoneCodeHash(ctx context.Context, accountID int, hash string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx,
 		"UPDATE accounts SET phone_code_hash = $1 WHERE id = $2",
 		hash,
 		accountID,
@@ -231,7 +228,9 @@ func (db *DB) UpdatePhoneCodeHash(accountID int, hash string) error {
 }
 
 // GetAccountByPhone ищет аккаунт по номеру, чтобы избежать создания дубликатов.
-func (db *DB) GetAccountByPhone(phone string) (*models.Account, error) {
+func (db *DB) GetAccountByPhone(ctx context.Context, phone string) (*models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var account models.Account
 
 	var (
@@ -253,7 +252,7 @@ func (db *DB) GetAccountByPhone(phone string) (*models.Account, error) {
        LEFT JOIN proxy p ON a.proxy_id = p.id
        WHERE phone = $1
    `
-	err := db.Conn.QueryRow(query, phone).Scan(
+	err := db.Conn.QueryRowContext(ctx, query, phone).Scan(
 		&account.ID,
 		&account.Phone,
 		&account.ApiID,
@@ -306,22 +305,24 @@ func (db *DB) GetAccountByPhone(phone string) (*models.Account, error) {
 
 // AssignProxyToAccount привязывает прокси к аккаунту, учитывая лимит
 // количества аккаунтов на одном прокси, чтобы не перегружать его.
-func (db *DB) AssignProxyToAccount(accountID, proxyID int, limit int) error {
+func (db *DB) AssignProxyToAccount(ctx context.Context, accountID, proxyID int, limit int) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var count int
-	if err := db.Conn.QueryRow("SELECT account_count FROM proxy WHERE id = $1", proxyID).Scan(&count); err != nil {
+	if err := db.Conn.QueryRowContext(ctx, "SELECT account_count FROM proxy WHERE id = $1", proxyID).Scan(&count); err != nil {
 		return err
 	}
 	if limit > 0 && count >= limit {
 		return fmt.Errorf("proxy limit reached")
 	}
-	_, err := db.Conn.Exec("UPDATE accounts SET proxy_id = $1 WHERE id = $2", proxyID, accountID)
+	_, err := db.Conn.ExecContext(ctx, "UPDATE accounts SET proxy_id = $1 WHERE id = $2", proxyID, accountID)
 	return err
 }
 
 // getAccounts возвращает список аккаунтов по произвольному условию WHERE.
 // Это позволяет переиспользовать код выборки для разных типов аккаунтов
 // и не дублировать логику обработки NULL-полей.
-func (db *DB) getAccounts(where string, args ...any) ([]models.Account, error) {
+func (db *DB) getAccounts(ctx context.Context, where string, args ...any) ([]models.Account, error) {
 	query := `
       SELECT a.id, a.phone, a.api_id, a.api_hash, a.phone_code_hash, a.is_authorized, a.gender::text[], a.proxy_id, a.order_id,
              a.account_monitoring, a.account_generator_category,
@@ -330,7 +331,7 @@ func (db *DB) getAccounts(where string, args ...any) ([]models.Account, error) {
       LEFT JOIN proxy p ON a.proxy_id = p.id
       WHERE ` + where
 
-	rows, err := db.Conn.Query(query, args...)
+	rows, err := db.Conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		log.Printf("[DB ERROR] Failed to get accounts: %v", err)
 		return nil, fmt.Errorf("database error")
@@ -411,8 +412,10 @@ func (db *DB) getAccounts(where string, args ...any) ([]models.Account, error) {
 
 // GetAuthorizedAccounts возвращает все авторизованные аккаунты без мониторинга,
 // чтобы сервисы могли быстро получить список рабочих сессий.
-func (db *DB) GetAuthorizedAccounts() ([]models.Account, error) {
-	accounts, err := db.getAccounts("a.is_authorized = true AND a.account_monitoring = false AND a.account_generator_category = false")
+func (db *DB) GetAuthorizedAccounts(ctx context.Context) ([]models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	accounts, err := db.getAccounts(ctx, "a.is_authorized = true AND a.account_monitoring = false AND a.account_generator_category = false")
 	if err == nil {
 		log.Printf("[DB INFO] Found %d authorized accounts", len(accounts))
 	}
@@ -421,8 +424,10 @@ func (db *DB) GetAuthorizedAccounts() ([]models.Account, error) {
 
 // GetAllAuthorizedAccounts возвращает все авторизованные аккаунты,
 // включая мониторинговые и для генерации категорий.
-func (db *DB) GetAllAuthorizedAccounts() ([]models.Account, error) {
-	accounts, err := db.getAccounts("a.is_authorized = true")
+func (db *DB) GetAllAuthorizedAccounts(ctx context.Context) ([]models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	accounts, err := db.getAccounts(ctx, "a.is_authorized = true")
 	if err == nil {
 		log.Printf("[DB INFO] Found %d authorized accounts (all)", len(accounts))
 	}
@@ -431,14 +436,18 @@ func (db *DB) GetAllAuthorizedAccounts() ([]models.Account, error) {
 
 // GetMonitoringAccounts возвращает авторизованные аккаунты,
 // помеченные как мониторинговые.
-func (db *DB) GetMonitoringAccounts() ([]models.Account, error) {
-	return db.getAccounts("a.is_authorized = true AND a.account_monitoring = true")
+func (db *DB) GetMonitoringAccounts(ctx context.Context) ([]models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return db.getAccounts(ctx, "a.is_authorized = true AND a.account_monitoring = true")
 }
 
 // ReleaseMonitoringAccounts снимает привязку заказов с аккаунтов под мониторингом.
 // Это нужно, чтобы такие аккаунты не выполняли заказы даже если были назначены ранее.
-func (db *DB) ReleaseMonitoringAccounts() error {
-	if _, err := db.Conn.Exec(`UPDATE accounts SET order_id = NULL WHERE account_monitoring = TRUE AND order_id IS NOT NULL`); err != nil {
+func (db *DB) ReleaseMonitoringAccounts(ctx context.Context) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	if _, err := db.Conn.ExecContext(ctx, `UPDATE accounts SET order_id = NULL WHERE account_monitoring = TRUE AND order_id IS NOT NULL`); err != nil {
 		log.Printf("[DB ERROR] освобождение мониторинговых аккаунтов: %v", err)
 		return err
 	}
@@ -447,14 +456,18 @@ func (db *DB) ReleaseMonitoringAccounts() error {
 
 // GetGeneratorCategoryAccounts возвращает авторизованные аккаунты,
 // предназначенные только для генерации подборок каналов.
-func (db *DB) GetGeneratorCategoryAccounts() ([]models.Account, error) {
-	return db.getAccounts("a.is_authorized = true AND a.account_generator_category = true")
+func (db *DB) GetGeneratorCategoryAccounts(ctx context.Context) ([]models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return db.getAccounts(ctx, "a.is_authorized = true AND a.account_generator_category = true")
 }
 
 // ReleaseGeneratorCategoryAccounts снимает привязку заказов с аккаунтов генерации категорий.
 // Это страхует от случайного назначения таких аккаунтов на заказы.
-func (db *DB) ReleaseGeneratorCategoryAccounts() error {
-	if _, err := db.Conn.Exec(`UPDATE accounts SET order_id = NULL WHERE account_generator_category = TRUE AND order_id IS NOT NULL`); err != nil {
+func (db *DB) ReleaseGeneratorCategoryAccounts(ctx context.Context) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	if _, err := db.Conn.ExecContext(ctx, `UPDATE accounts SET order_id = NULL WHERE account_generator_category = TRUE AND order_id IS NOT NULL`); err != nil {
 		log.Printf("[DB ERROR] освобождение аккаунтов генерации категорий: %v", err)
 		return err
 	}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"atg_go/models"
+)
+
+// GetAccountTwoFAPassword возвращает зашифрованный пароль 2FA аккаунта;
+// пустая строка — пароль не задан. Если аккаунта нет, возвращается sql.ErrNoRows.
+func (db *DB) GetAccountTwoFAPassword(ctx context.Context, accountID int) (string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var enc sql.NullString
+	err := db.Conn.QueryRowContext(ctx, `SELECT two_fa_password_enc FROM accounts WHERE id = $1`, accountID).Scan(&enc)
+	return enc.String, err
+}
+
+// SetAccountTwoFAPassword сохраняет зашифрованный пароль 2FA; пустой enc удаляет пароль.
+// Изменение пишется в audit_log без значения пароля. Если аккаунта нет, возвращается sql.ErrNoRows.
+func (db *DB) SetAccountTwoFAPassword(ctx context.Context, accountID int, enc, actor string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var hadPassword bool
+	if err := tx.QueryRowContext(ctx, `SELECT two_fa_password_enc IS NOT NULL FROM accounts WHERE id = $1 FOR UPDATE`, accountID).Scan(&hadPassword); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE accounts SET two_fa_password_enc = NULLIF($2, ''), two_fa_updated_at = NOW()
+        WHERE id = $1`, accountID, enc); err != nil {
+		return err
+	}
+	action := "account.two_fa_set"
+	switch {
+	case enc == "":
+		action = "account.two_fa_clear"
+	case hadPassword:
+		action = "account.two_fa_rotate"
+	}
+	if err := insertAuditLog(ctx, tx, models.AuditLogEntry{
+		Actor:    actor,
+		Action:   action,
+		Entity:   "account",
+		EntityID: strconv.Itoa(accountID),
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"atg_go/models"
+)
+
+// Модули, выполняющие переходы статусов; пишутся в account_events.source.
+const (
+	AccountSourceAuth          = "accounts_auth"
+	AccountSourceStateCheck    = "accounts_state_check"
+	AccountSourceFloodWait     = "floodwait"
+	AccountSourceChannelsLimit = "channels_limit"
+	AccountSourceAPI           = "api"
+)
+
+// SetAccountStatus переводит аккаунт в статус to и записывает переход в account_events.
+// Недопустимый переход (см. models.CanTransitionAccountStatus) и повтор текущего статуса
+// ничего не меняют и возвращают false. Отключённый аккаунт возвращает в работу только
+// источник AccountSourceAPI, а ограниченный остаётся limited, пока не истёк флуд-вейт.
+// Если аккаунта нет, возвращается sql.ErrNoRows.
+func (db *DB) SetAccountStatus(ctx context.Context, accountID int, to, reason, source string) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var (
+		from      string
+		floodWait bool
+	)
+	if err := tx.QueryRowContext(ctx, `SELECT status, COALESCE(floodwait_until > NOW(), FALSE) FROM accounts WHERE id = $1 FOR UPDATE`, accountID).Scan(&from, &floodWait); err != nil {
+		return false, err
+	}
+	if !models.CanTransitionAccountStatus(from, to, source == AccountSourceAPI) {
+		return false, nil
+	}
+	// Успешный запрос не снимает флуд-вейт: Telegram ограничивает аккаунт до floodwait_until
+	if from == models.AccountStatusLimited && to == models.AccountStatusActive && floodWait {
+		return false, nil
+	}
+	// is_authorized остаётся для выборок аккаунтов: работают только active и limited
+	if _, err := tx.ExecContext(ctx, `UPDATE accounts
+        SET status = $2::account_status, status_changed_at = NOW(),
+            is_authorized = $2::account_status IN ('active', 'limited')
+        WHERE id = $1`, accountID, to); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO account_events (account_id, from_status, to_status, reason, source)
+        VALUES ($1, $2::account_status, $3::account_status, $4, $5)`, accountID, from, to, reason, source); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// ReleaseExpiredLimits возвращает в active аккаунты, у которых истекли флуд-вейт
+// и лимит подписок, и возвращает их количество.
+func (db *DB) ReleaseExpiredLimits(ctx context.Context, source string) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	res, err := db.Conn.ExecContext(ctx, `WITH released AS (
+            UPDATE accounts SET status = 'active', status_changed_at = NOW()
+            WHERE status = 'limited'
+              AND COALESCE(floodwait_until, '-infinity') <= NOW()
+              AND COALESCE(channels_limit_until, '-infinity') <= NOW()
+            RETURNING id
+        )
+        INSERT INTO account_events (account_id, from_status, to_status, reason, source)
+        SELECT id, 'limited', 'active', 'ограничения истекли', $1 FROM released`, source)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetAccountStatus возвращает текущий статус аккаунта и время последнего перехода.
+func (db *DB) GetAccountStatus(ctx context.Context, accountID int) (string, *time.Time, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var (
+		status  string
+		changed *time.Time
+	)
+	err := db.Conn.QueryRowContext(ctx, `SELECT status, status_changed_at FROM accounts WHERE id = $1`, accountID).Scan(&status, &changed)
+	return status, changed, err
+}
+
+// GetAccountEvents возвращает последние переходы аккаунта, новые первыми.
+func (db *DB) GetAccountEvents(ctx context.Context, accountID, limit int) ([]models.AccountEvent, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT id, account_id, from_status, to_status, reason, source, created_at
+        FROM account_events WHERE account_id = $1
+        ORDER BY created_at DESC, id DESC LIMIT $2`, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.AccountEvent
+	for rows.Next() {
+		var e models.AccountEvent
+		if err := rows.Scan(&e.ID, &e.AccountID, &e.FromStatus, &e.ToStatus, &e.Reason, &e.Source, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
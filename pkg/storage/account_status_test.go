@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"atg_go/models"
+)
+
+// statusTestDriver возвращает текущий статус аккаунта из statusCurrent и признак
+// флуд-вейта из statusFloodWait, а также запоминает запросы Exec перехода.
+type statusTestDriver struct{}
+
+type statusTestConn struct{}
+
+type statusTestTx struct{}
+
+type statusTestRows struct{ done bool }
+
+var (
+	statusCurrent   string
+	statusFloodWait bool
+	statusExecs     []string
+	statusCommitted bool
+)
+
+func (statusTestDriver) Open(name string) (driver.Conn, error) { return &statusTestConn{}, nil }
+
+func (c *statusTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *statusTestConn) Close() error              { return nil }
+func (c *statusTestConn) Begin() (driver.Tx, error) { return statusTestTx{}, nil }
+
+func (c *statusTestConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &statusTestRows{}, nil
+}
+
+func (c *statusTestConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	statusExecs = append(statusExecs, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (statusTestTx) Commit() error   { statusCommitted = true; return nil }
+func (statusTestTx) Rollback() error { return nil }
+
+func (r *statusTestRows) Columns() []string { return []string{"status", "flood_wait"} }
+func (r *statusTestRows) Close() error      { return nil }
+func (r *statusTestRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = statusCurrent
+	dest[1] = statusFloodWait
+	return nil
+}
+
+func init() { sql.Register("statusDummy", statusTestDriver{}) }
+
+// TestSetAccountStatus проверяет, что допустимый переход меняет статус и пишет событие,
+// а недопустимый не трогает аккаунт.
+func TestSetAccountStatus(t *testing.T) {
+	conn, err := sql.Open("statusDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	db := &DB{Conn: conn}
+
+	cases := []struct {
+		name      string
+		from      string
+		to        string
+		source    string
+		floodWait bool
+		changed   bool
+	}{
+		{"ограничение рабочего аккаунта", models.AccountStatusActive, models.AccountStatusLimited, AccountSourceAPI, false, true},
+		{"потеря авторизации при ограничении", models.AccountStatusLimited, models.AccountStatusUnauthorized, AccountSourceAPI, false, true},
+		{"ограничение не снимает отключение", models.AccountStatusDisabled, models.AccountStatusLimited, AccountSourceAPI, false, false},
+		{"авторизация не снимает отключение без оператора", models.AccountStatusDisabled, models.AccountStatusUnauthorized, AccountSourceAPI, false, false},
+		{"повтор статуса", models.AccountStatusActive, models.AccountStatusActive, AccountSourceAPI, false, false},
+		{"возврат в работу оператором", models.AccountStatusDisabled, models.AccountStatusActive, AccountSourceAPI, false, true},
+		{"авторизация не возвращает отключённый аккаунт", models.AccountStatusDisabled, models.AccountStatusActive, AccountSourceAuth, false, false},
+		{"проверка сессии не возвращает отключённый аккаунт", models.AccountStatusDisabled, models.AccountStatusActive, AccountSourceStateCheck, false, false},
+		{"флуд-вейт ещё действует", models.AccountStatusLimited, models.AccountStatusActive, AccountSourceAuth, true, false},
+		{"флуд-вейт истёк", models.AccountStatusLimited, models.AccountStatusActive, AccountSourceAuth, false, true},
+		{"повторная авторизация", models.AccountStatusUnauthorized, models.AccountStatusActive, AccountSourceAuth, false, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			statusCurrent, statusFloodWait, statusExecs, statusCommitted = tc.from, tc.floodWait, nil, false
+			changed, err := db.SetAccountStatus(context.Background(), 1, tc.to, "причина", tc.source)
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if changed != tc.changed {
+				t.Fatalf("changed = %v, ожидалось %v", changed, tc.changed)
+			}
+			if !tc.changed {
+				if len(statusExecs) != 0 || statusCommitted {
+					t.Fatalf("недопустимый переход изменил БД: %v", statusExecs)
+				}
+				return
+			}
+			if len(statusExecs) != 2 || !statusCommitted {
+				t.Fatalf("ожидались обновление и событие в одной транзакции: %v", statusExecs)
+			}
+			if !strings.Contains(statusExecs[0], "is_authorized") || !strings.Contains(statusExecs[1], "INSERT INTO account_events") {
+				t.Fatalf("неожиданные запросы: %v", statusExecs)
+			}
+		})
+	}
+}
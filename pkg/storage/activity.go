@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"strconv"
 	"time"
@@ -23,11 +24,13 @@ const ActivityTypeSubsActiveView = "subs_active_view"
 // SaveActivity сохраняет действие аккаунта в таблице activity вместе со временем.
 // messageID — идентификатор сообщения: для ActivityTypeReaction это ID сообщения
 // из чата обсуждения, для ActivityTypeComment — ID поста канала.
-func (db *DB) SaveActivity(accountID, channelID, messageID int, activityType string) error {
+func (db *DB) SaveActivity(ctx context.Context, accountID, channelID, messageID int, activityType string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	chID := strconv.FormatInt(int64(channelID), 10) // сохраняем ID как строку
 	msgID := strconv.FormatInt(int64(messageID), 10)
 	// Добавляем ON CONFLICT, чтобы игнорировать повторные записи без ошибки
-	_, err := db.Conn.Exec(
+	_, err := db.Conn.ExecContext(ctx,
 		`INSERT INTO activity (id_account, id_channel, id_message, activity_type, date_time)
                 VALUES ($1, $2, $3, $4, $5)
                 ON CONFLICT DO NOTHING`,
@@ -39,31 +42,39 @@ func (db *DB) SaveActivity(accountID, channelID, messageID int, activityType str
 // SaveReaction сохраняет информацию о реакции в таблице activity.
 // messageID должен быть идентификатором сообщения из обсуждения, которому
 // поставлена реакция, а не ID поста канала.
-func (db *DB) SaveReaction(accountID, channelID, messageID int) error {
-	return db.SaveActivity(accountID, channelID, messageID, ActivityTypeReaction)
+func (db *DB) SaveReaction(ctx context.Context, accountID, channelID, messageID int) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return db.SaveActivity(ctx, accountID, channelID, messageID, ActivityTypeReaction)
 }
 
 // SaveComment сохраняет информацию о комментарии в таблице activity.
 // messageID должен быть ID поста канала, к которому оставлен комментарий,
 // а не идентификатор сообщения из обсуждения.
-func (db *DB) SaveComment(accountID, channelID, messageID int) error {
-	return db.SaveActivity(accountID, channelID, messageID, ActivityTypeComment)
+func (db *DB) SaveComment(ctx context.Context, accountID, channelID, messageID int) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return db.SaveActivity(ctx, accountID, channelID, messageID, ActivityTypeComment)
 }
 
 // SaveSubsActiveView сохраняет информацию о просмотре поста активной аудиторией.
 // messageID должен быть ID поста, который был открыт для увеличения счётчика просмотров.
-func (db *DB) SaveSubsActiveView(accountID, channelID, messageID int) error {
-	return db.SaveActivity(accountID, channelID, messageID, ActivityTypeSubsActiveView)
+func (db *DB) SaveSubsActiveView(ctx context.Context, accountID, channelID, messageID int) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return db.SaveActivity(ctx, accountID, channelID, messageID, ActivityTypeSubsActiveView)
 }
 
 // HasCommentForPost проверяет, существует ли комментарий для поста с указанным
 // ID в заданном канале. messageID должен быть ID поста канала. Возвращает true
 // при наличии записи с типом 'comment'.
-func (db *DB) HasCommentForPost(channelID, messageID int) (bool, error) {
+func (db *DB) HasCommentForPost(ctx context.Context, channelID, messageID int) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var exists bool
 	chID := strconv.FormatInt(int64(channelID), 10)
 	msgID := strconv.FormatInt(int64(messageID), 10)
-	err := db.Conn.QueryRow(
+	err := db.Conn.QueryRowContext(ctx,
 		`SELECT EXISTS(SELECT 1 FROM activity WHERE id_channel = $1 AND id_message = $2 AND activity_type = 'comment')`,
 		chID, msgID,
 	).Scan(&exists)
@@ -73,10 +84,12 @@ func (db *DB) HasCommentForPost(channelID, messageID int) (bool, error) {
 // GetLastReactionMessageID возвращает ID сообщения из обсуждения, на которое
 // аккаунт поставил реакцию последним в рамках указанного канала. Если реакций
 // ещё не было, возвращает 0.
-func (db *DB) GetLastReactionMessageID(accountID, channelID int) (int, error) {
+func (db *DB) GetLastReactionMessageID(ctx context.Context, accountID, channelID int) (int, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var messageIDStr string
 	chID := strconv.FormatInt(int64(channelID), 10)
-	err := db.Conn.QueryRow(
+	err := db.Conn.QueryRowContext(ctx,
 		`SELECT id_message FROM activity
                  WHERE id_account = $1 AND id_channel = $2 AND activity_type = $3
                  ORDER BY date_time DESC LIMIT 1`,
@@ -100,10 +113,12 @@ func (db *DB) GetLastReactionMessageID(accountID, channelID int) (int, error) {
 // Если комментариев ещё не было, возвращается 0 и nil.
 // В случае ошибки запроса или если значение id_message невозможно
 // преобразовать в целое число, функция возвращает 0 и ошибку.
-func (db *DB) GetLastCommentMessageID(accountID, channelID int) (int, error) {
+func (db *DB) GetLastCommentMessageID(ctx context.Context, accountID, channelID int) (int, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var messageIDStr string
 	chID := strconv.FormatInt(int64(channelID), 10)
-	err := db.Conn.QueryRow(
+	err := db.Conn.QueryRowContext(ctx,
 		`SELECT id_message FROM activity
                  WHERE id_account = $1 AND id_channel = $2 AND activity_type = $3
                  ORDER BY date_time DESC LIMIT 1`,
@@ -126,8 +141,10 @@ func (db *DB) GetLastCommentMessageID(accountID, channelID int) (int, error) {
 // сообщение обсуждения с указанным ID в заданном канале. Разница между ID
 // должна быть не менее 10. Если аккаунт ещё не ставил реакций в канале,
 // возвращает true.
-func (db *DB) CanReactOnMessage(accountID, channelID, messageID int) (bool, error) {
-	lastID, err := db.GetLastReactionMessageID(accountID, channelID)
+func (db *DB) CanReactOnMessage(ctx context.Context, accountID, channelID, messageID int) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	lastID, err := db.GetLastReactionMessageID(ctx, accountID, channelID)
 	if err != nil {
 		return false, err
 	}
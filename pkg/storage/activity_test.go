@@ -55,10 +55,10 @@ func TestSaveActivityDuplicate(t *testing.T) {
 	}
 	storageDB := &DB{Conn: db}
 
-	if err := storageDB.SaveActivity(1, 2, 3, ActivityTypeComment); err != nil {
+	if err := storageDB.SaveActivity(context.Background(), 1, 2, 3, ActivityTypeComment); err != nil {
 		t.Fatalf("первая вставка завершилась ошибкой: %v", err)
 	}
-	if err := storageDB.SaveActivity(1, 2, 3, ActivityTypeComment); err != nil {
+	if err := storageDB.SaveActivity(context.Background(), 1, 2, 3, ActivityTypeComment); err != nil {
 		t.Fatalf("повторная вставка завершилась ошибкой: %v", err)
 	}
 	if len(executedQueries) != 2 {
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"atg_go/models"
+)
+
+// insertAuditLog добавляет запись журнала в транзакции изменения,
+// чтобы изменение и его след в журнале фиксировались вместе.
+func insertAuditLog(ctx context.Context, tx *sql.Tx, e models.AuditLogEntry) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO audit_log (actor, action, entity, entity_id, old_value, new_value)
+        VALUES ($1, $2, $3, $4, $5, $6)`, e.Actor, e.Action, e.Entity, e.EntityID, nullJSON(e.OldValue), nullJSON(e.NewValue))
+	return err
+}
+
+// nullJSON превращает пустое значение в NULL для колонки JSONB.
+func nullJSON(v []byte) any {
+	if len(v) == 0 {
+		return nil
+	}
+	return string(v)
+}
+
+// GetAuditLog возвращает последние записи журнала по типу объекта, новые первыми.
+// Пустой entity означает все записи.
+func (db *DB) GetAuditLog(ctx context.Context, entity string, limit int) ([]models.AuditLogEntry, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT id, actor, action, entity, entity_id,
+            COALESCE(old_value::text, 'null'), COALESCE(new_value::text, 'null'), created_at
+        FROM audit_log WHERE $1 = '' OR entity = $1
+        ORDER BY id DESC LIMIT $2`, entity, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.AuditLogEntry
+	for rows.Next() {
+		var e models.AuditLogEntry
+		var oldValue, newValue string
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Entity, &e.EntityID, &oldValue, &newValue, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.OldValue, e.NewValue = []byte(oldValue), []byte(newValue)
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"log"
@@ -12,8 +13,10 @@ import (
 
 // GetCategoryNames возвращает список названий всех категорий
 // Используется для заполнения выпадающего списка категорий заказов
-func (db *DB) GetCategoryNames() ([]string, error) {
-	rows, err := db.Conn.Query(`SELECT name FROM categories`)
+func (db *DB) GetCategoryNames(ctx context.Context) ([]string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT name FROM categories`)
 	if err != nil {
 		return nil, err
 	}
@@ -37,11 +40,25 @@ func (db *DB) GetCategoryNames() ([]string, error) {
 
 // CreateCategory добавляет новую категорию с набором ссылок на каналы.
 // Ссылки сохраняются в JSONB, поэтому предварительно кодируем их в JSON.
-func (db *DB) CreateCategory(name string, urls []string) (*models.Category, error) {
+func (db *DB) CreateCategory(ctx context.Context, name string, urls []string) (*models.Category, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return insertCategory(ctx, db.Conn, name, urls)
+}
+
+// categoryWriter — общий интерфейс *sql.DB и *sql.Tx для записи категории.
+type categoryWriter interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// insertCategory сохраняет ссылки в channels и создаёт категорию.
+// Вынесено отдельно, чтобы завершение генерации создавало категорию в своей транзакции.
+func insertCategory(ctx context.Context, w categoryWriter, name string, urls []string) (*models.Category, error) {
 	// Сохраняем ссылки в отдельной таблице, исключая дубли.
 	if len(urls) > 0 {
 		// Вставляем только уникальные ссылки; существующие записи игнорируются.
-		if _, err := db.Conn.Exec(`
+		if _, err := w.ExecContext(ctx, `
                        INSERT INTO channels (url)
                        SELECT DISTINCT unnest($1::text[])
                        ON CONFLICT (url) DO NOTHING
@@ -60,7 +77,7 @@ func (db *DB) CreateCategory(name string, urls []string) (*models.Category, erro
 
 	// Сохраняем категорию и возвращаем её идентификатор.
 	var id int
-	err = db.Conn.QueryRow(`INSERT INTO categories (name, urls) VALUES ($1, $2) RETURNING id`, name, data).Scan(&id)
+	err = w.QueryRowContext(ctx, `INSERT INTO categories (name, urls) VALUES ($1, $2) RETURNING id`, name, data).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
@@ -1,8 +1,12 @@
 package storage
 
+import "context"
+
 // SaveCategoryChannelDelete сохраняет ссылку канала и причину его недоступности.
-func (db *DB) SaveCategoryChannelDelete(url, reason string) error {
-	_, err := db.Conn.Exec(
+func (db *DB) SaveCategoryChannelDelete(ctx context.Context, url, reason string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx,
 		`INSERT INTO category_channels_delete (channel_url, reason) VALUES ($1, $2)`,
 		url, reason,
 	)
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 
 	"atg_go/models"
@@ -55,14 +56,16 @@ type ChannelDuplicateOrder struct {
 	OrderChannelTGID *string // ID нашего канала
 }
 
-// GetChannelDuplicates возвращает список каналов-источников и связанные с ними заказы.
-func (db *DB) GetChannelDuplicates() ([]ChannelDuplicateOrder, error) {
-	rows, err := db.Conn.Query(`
+// GetChannelDuplicates возвращает список каналов-источников и связанные с ними активные заказы.
+func (db *DB) GetChannelDuplicates(ctx context.Context) ([]ChannelDuplicateOrder, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `
                 SELECT cd.id, cd.order_id, cd.url_channel_donor, cd.channel_donor_tgid, cd.post_text_remove, cd.post_text_add, cd.post_skip, cd.last_post_id, cd.post_count_day,
                        o.url_default, o.channel_tgid
                 FROM channel_duplicate cd
                 JOIN orders o ON cd.order_id = o.id
-                WHERE cd.url_channel_donor <> '' AND o.url_default <> ''`)
+                WHERE cd.url_channel_donor <> '' AND o.url_default <> '' AND o.status = 'active'`)
 	if err != nil {
 		return nil, err
 	}
@@ -83,13 +86,16 @@ func (db *DB) GetChannelDuplicates() ([]ChannelDuplicateOrder, error) {
 }
 
 // GetChannelDuplicateOrderByID возвращает запись дублирования с привязанным заказом по её ID.
-func (db *DB) GetChannelDuplicateOrderByID(id int) (*ChannelDuplicateOrder, error) {
-	row := db.Conn.QueryRow(`
+// Для неактивного заказа возвращается sql.ErrNoRows: дублирование в его канал не ведётся.
+func (db *DB) GetChannelDuplicateOrderByID(ctx context.Context, id int) (*ChannelDuplicateOrder, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	row := db.Conn.QueryRowContext(ctx, `
                 SELECT cd.id, cd.order_id, cd.url_channel_donor, cd.channel_donor_tgid, cd.post_text_remove, cd.post_text_add, cd.post_skip, cd.last_post_id, cd.post_count_day,
                        o.url_default, o.channel_tgid
                 FROM channel_duplicate cd
                 JOIN orders o ON cd.order_id = o.id
-                WHERE cd.id = $1`, id)
+                WHERE cd.id = $1 AND o.status = 'active'`, id)
 	cd, err := scanChannelDuplicateOrder(row)
 	if err != nil {
 		return nil, err
@@ -97,11 +103,22 @@ func (db *DB) GetChannelDuplicateOrderByID(id int) (*ChannelDuplicateOrder, erro
 	return &cd, nil
 }
 
+// OrderHasChannelDuplicates сообщает, есть ли у заказа записи дублирования каналов.
+func (db *DB) OrderHasChannelDuplicates(ctx context.Context, orderID int) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var has bool
+	err := db.Conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM channel_duplicate WHERE order_id = $1)`, orderID).Scan(&has)
+	return has, err
+}
+
 // GetChannelDonorURLs возвращает список ссылок на каналы-доноры.
 // Эти каналы используются при дублировании контента,
 // поэтому отписка от них для мониторинговых аккаунтов запрещена.
-func (db *DB) GetChannelDonorURLs() ([]string, error) {
-	rows, err := db.Conn.Query(`SELECT url_channel_donor FROM channel_duplicate WHERE url_channel_donor <> ''`)
+func (db *DB) GetChannelDonorURLs(ctx context.Context) ([]string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT url_channel_donor FROM channel_duplicate WHERE url_channel_donor <> ''`)
 	if err != nil {
 		return nil, err
 	}
@@ -124,15 +141,19 @@ func (db *DB) GetChannelDonorURLs() ([]string, error) {
 }
 
 // SetChannelDonorTGID сохраняет ID донорского канала.
-func (db *DB) SetChannelDonorTGID(id int, tgid string) error {
-	_, err := db.Conn.Exec(`UPDATE channel_duplicate SET channel_donor_tgid = $1 WHERE id = $2`, tgid, id)
+func (db *DB) SetChannelDonorTGID(ctx context.Context, id int, tgid string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE channel_duplicate SET channel_donor_tgid = $1 WHERE id = $2`, tgid, id)
 	return err
 }
 
 // TrySetLastPostID обновляет last_post_id и возвращает актуальные тексты для обработки.
 // Если запись не изменилась, updated будет false, а строки равны nil.
-func (db *DB) TrySetLastPostID(id int, postID int) (updated bool, remove *string, add *string, err error) {
-	row := db.Conn.QueryRow(`UPDATE channel_duplicate SET last_post_id = $2 WHERE id = $1 AND (last_post_id IS NULL OR last_post_id < $2) RETURNING post_text_remove, post_text_add`, id, postID)
+func (db *DB) TrySetLastPostID(ctx context.Context, id int, postID int) (updated bool, remove *string, add *string, err error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	row := db.Conn.QueryRowContext(ctx, `UPDATE channel_duplicate SET last_post_id = $2 WHERE id = $1 AND (last_post_id IS NULL OR last_post_id < $2) RETURNING post_text_remove, post_text_add`, id, postID)
 	var (
 		postRemove sql.NullString
 		postAdd    sql.NullString
@@ -154,8 +175,10 @@ func (db *DB) TrySetLastPostID(id int, postID int) (updated bool, remove *string
 }
 
 // UpdateChannelDuplicateTimes обновляет расписание публикаций (post_count_day) для записи channel_duplicate.
-func (db *DB) UpdateChannelDuplicateTimes(id int, times pq.StringArray) error {
-	_, err := db.Conn.Exec(
+func (db *DB) UpdateChannelDuplicateTimes(ctx context.Context, id int, times pq.StringArray) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx,
 		`UPDATE channel_duplicate SET post_count_day = $1 WHERE id = $2`,
 		pq.Array(times), id,
 	)
@@ -164,10 +187,12 @@ func (db *DB) UpdateChannelDuplicateTimes(id int, times pq.StringArray) error {
 
 // GetPostReactionsForOrder возвращает список реакций для постов указанного заказа.
 // При отсутствии записи или NULL возвращает nil без ошибки.
-func (db *DB) GetPostReactionsForOrder(orderID int) (pq.StringArray, error) {
+func (db *DB) GetPostReactionsForOrder(ctx context.Context, orderID int) (pq.StringArray, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var raw sql.NullString
 	// Приводим массив к тексту, чтобы получить строку вида {"😀","😢"}
-	err := db.Conn.QueryRow(
+	err := db.Conn.QueryRowContext(ctx,
 		`SELECT post_reactions::text FROM orders WHERE id = $1`,
 		orderID,
 	).Scan(&raw)
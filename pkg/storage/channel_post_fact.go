@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 
 	"atg_go/models"
@@ -8,8 +9,10 @@ import (
 )
 
 // CreateChannelPostFact создаёт запись фактических просмотров с нулевыми значениями.
-func (db *DB) CreateChannelPostFact(f models.ChannelPostFact) error {
-	_, err := db.Conn.Exec(`
+func (db *DB) CreateChannelPostFact(ctx context.Context, f models.ChannelPostFact) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `
                INSERT INTO channel_post_fact (
                        channel_post_theory_id,
                        reaction_24hour_fact,
@@ -27,9 +30,11 @@ func (db *DB) CreateChannelPostFact(f models.ChannelPostFact) error {
 
 // IncrementChannelPostFact увеличивает счётчик просмотров в заданном столбце на единицу.
 // column ожидает одно из имён полей вида view_1hour_fact, view_2_3hour_fact и т.д.
-func (db *DB) IncrementChannelPostFact(theoryID int, column string) error {
+func (db *DB) IncrementChannelPostFact(ctx context.Context, theoryID int, column string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	query := fmt.Sprintf("UPDATE channel_post_fact SET %s = %s + 1 WHERE channel_post_theory_id = $1", column, column)
-	if _, err := db.Conn.Exec(query, theoryID); err != nil {
+	if _, err := db.Conn.ExecContext(ctx, query, theoryID); err != nil {
 		log.Printf("[DB ERROR] обновление фактических просмотров: %v", err)
 		return err
 	}
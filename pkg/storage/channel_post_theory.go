@@ -2,14 +2,17 @@ package storage
 
 import (
 	"atg_go/models"
+	"context"
 	"log"
 )
 
 // CreateChannelPostTheory сохраняет прогноз распределения просмотров по группам часов
 // и возвращает идентификатор созданной записи.
-func (db *DB) CreateChannelPostTheory(t models.ChannelPostTheory) (int, error) {
+func (db *DB) CreateChannelPostTheory(ctx context.Context, t models.ChannelPostTheory) (int, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var id int
-	err := db.Conn.QueryRow(`
+	err := db.Conn.QueryRowContext(ctx, `
                 INSERT INTO channel_post_theory (
                         channel_post_id,
                         view_7_24hour_theory,
@@ -1,10 +1,15 @@
 package storage
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+)
 
 // GetChannelsNotUnsubscribeURLs возвращает список ссылок, от которых нельзя отписываться.
-func (db *DB) GetChannelsNotUnsubscribeURLs() ([]string, error) {
-	rows, err := db.Conn.Query(`SELECT url_channel FROM channels_not_unsubscribe`)
+func (db *DB) GetChannelsNotUnsubscribeURLs(ctx context.Context) ([]string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT url_channel FROM channels_not_unsubscribe`)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"atg_go/models"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -13,11 +14,17 @@ import (
 )
 
 type CommentDB struct {
-	Conn *sql.DB
+	Conn         *sql.DB
+	QueryTimeout time.Duration // Срок одного метода; 0 отключает ограничение
 }
 
 func NewCommentDB(conn *sql.DB) *CommentDB {
-	return &CommentDB{Conn: conn}
+	return &CommentDB{Conn: conn, QueryTimeout: DefaultQueryTimeout}
+}
+
+// withTimeout ограничивает ctx сроком QueryTimeout.
+func (cdb *CommentDB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return queryContext(ctx, cdb.QueryTimeout)
 }
 
 // ErrNoChannel сообщает, что в базе нет ни одного канала,
@@ -28,10 +35,12 @@ var ErrNoChannel = errors.New("no channel for order")
 // GetRandomChannel выбирает случайный URL из каналов, подходящих под категории заказа.
 // Если у заказа нет категорий, берём случайный канал из всех доступных,
 // чтобы заказ без тематик тоже мог получать активность.
-func (cdb *CommentDB) GetRandomChannel(orderID int) (string, error) {
+func (cdb *CommentDB) GetRandomChannel(ctx context.Context, orderID int) (string, error) {
+	ctx, cancel := cdb.withTimeout(ctx)
+	defer cancel()
 	// 1. Загружаем список категорий заказа, иначе не сможем сузить выбор каналов.
 	var categories pq.StringArray
-	if err := cdb.Conn.QueryRow(`SELECT category FROM orders WHERE id = $1`, orderID).Scan(&categories); err != nil {
+	if err := cdb.Conn.QueryRowContext(ctx, `SELECT category FROM orders WHERE id = $1`, orderID).Scan(&categories); err != nil {
 		log.Printf("[DB ERROR] получение категорий заказа %d: %v", orderID, err)
 		return "", err
 	}
@@ -41,7 +50,7 @@ func (cdb *CommentDB) GetRandomChannel(orderID int) (string, error) {
 	var row *sql.Row
 	if len(categories) == 0 {
 		// Категорий нет — выбираем любую категорию.
-		row = cdb.Conn.QueryRow(`
+		row = cdb.Conn.QueryRowContext(ctx, `
                SELECT id, name, urls
                FROM categories
                ORDER BY RANDOM()
@@ -49,7 +58,7 @@ func (cdb *CommentDB) GetRandomChannel(orderID int) (string, error) {
            `)
 	} else {
 		// Категории есть — ограничиваем выбор.
-		row = cdb.Conn.QueryRow(`
+		row = cdb.Conn.QueryRowContext(ctx, `
                SELECT id, name, urls
                FROM categories
                WHERE name = ANY($1)
@@ -78,7 +87,7 @@ func (cdb *CommentDB) GetRandomChannel(orderID int) (string, error) {
 
 	// 4. Получаем список каналов, которые ранее были признаны недоступными,
 	// и исключаем их из выборки.
-	rows, err := cdb.Conn.Query(
+	rows, err := cdb.Conn.QueryContext(ctx,
 		`SELECT channel_url FROM category_channels_delete WHERE channel_url = ANY($1)`,
 		pq.Array(category.URLs),
 	)
@@ -114,7 +123,6 @@ func (cdb *CommentDB) GetRandomChannel(orderID int) (string, error) {
 	}
 
 	// 5. Выбираем одну ссылку случайным образом из оставшихся.
-	rand.Seed(time.Now().UnixNano())
 	url := allowed[rand.Intn(len(allowed))]
 	log.Printf("[DB] выбран канал %s для заказа %d", url, orderID)
 
@@ -124,8 +132,8 @@ func (cdb *CommentDB) GetRandomChannel(orderID int) (string, error) {
 // PickRandomChannel выбирает канал для отправки, если это возможно.
 // Возвращаемая ошибка приводит sql.ErrNoRows к ErrNoChannel, чтобы вызовы вне
 // пакета знали, когда каналов нет, и могли корректно ответить 404.
-func PickRandomChannel(commentDB *CommentDB, orderID int) (string, error) {
-	url, err := commentDB.GetRandomChannel(orderID)
+func PickRandomChannel(ctx context.Context, commentDB *CommentDB, orderID int) (string, error) {
+	url, err := commentDB.GetRandomChannel(ctx, orderID)
 	if errors.Is(err, sql.ErrNoRows) {
 		// Преобразуем стандартную ошибку отсутствия строки в доменную
 		// ErrNoChannel, чтобы не раскрывать детали хранилища наружу.
@@ -83,7 +83,7 @@ func TestPickRandomChannelWithoutCategories(t *testing.T) {
 	defer func() { _ = db.Close() }()
 
 	cdb := &CommentDB{Conn: db}
-	url, err := PickRandomChannel(cdb, 1)
+	url, err := PickRandomChannel(context.Background(), cdb, 1)
 	if err != nil {
 		t.Fatalf("ожидался канал, получена ошибка: %v", err)
 	}
@@ -102,7 +102,7 @@ func TestPickRandomChannelExcluded(t *testing.T) {
 	defer func() { _ = db.Close() }()
 
 	cdb := &CommentDB{Conn: db}
-	_, err = PickRandomChannel(cdb, 1)
+	_, err = PickRandomChannel(context.Background(), cdb, 1)
 	if !errors.Is(err, ErrNoChannel) {
 		t.Fatalf("ожидалась ошибка ErrNoChannel, получена: %v", err)
 	}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"atg_go/models"
+)
+
+// Имена проверок денормализованных счётчиков.
+const (
+	CheckOrderAccountsFact    = "orders.accounts_number_fact"
+	CheckProxyAccountCount    = "proxy.account_count"
+	CheckOrderSubsInactive    = "order_account_subs.inactive_accounts"
+	CheckOrderSubsActiveCount = "orders.subs_active_count"
+)
+
+// counterCheck описывает одну проверку: запрос расхождений (id, хранимое, фактическое)
+// и исправление для одной сущности с параметром $1.
+type counterCheck struct {
+	name  string
+	query string
+	fix   string // Пусто — только отчёт
+}
+
+// counterChecks выполняются по порядку: подписки неактивных аккаунтов удаляются
+// до сверки количества подписок с subs_active_count.
+var counterChecks = []counterCheck{
+	{
+		name: CheckOrderAccountsFact,
+		query: `SELECT o.id, o.accounts_number_fact, COUNT(a.id)
+            FROM orders o LEFT JOIN accounts a ON a.order_id = o.id
+            GROUP BY o.id HAVING o.accounts_number_fact <> COUNT(a.id) ORDER BY o.id`,
+		fix: `UPDATE orders SET accounts_number_fact = (SELECT COUNT(*) FROM accounts WHERE order_id = $1) WHERE id = $1`,
+	},
+	{
+		name: CheckProxyAccountCount,
+		query: `SELECT p.id, p.account_count, COUNT(a.id)
+            FROM proxy p LEFT JOIN accounts a ON a.proxy_id = p.id
+            GROUP BY p.id HAVING p.account_count <> COUNT(a.id) ORDER BY p.id`,
+		fix: `UPDATE proxy SET account_count = (SELECT COUNT(*) FROM accounts WHERE proxy_id = $1) WHERE id = $1`,
+	},
+	{
+		// Подписки разлогиненных и мониторинговых аккаунтов не поддерживаются:
+		// после их удаления синхронизация подпишет вместо них рабочие аккаунты
+		name: CheckOrderSubsInactive,
+		query: `SELECT s.order_id, COUNT(*),
+                COUNT(*) FILTER (WHERE COALESCE(a.is_authorized, false) AND NOT a.account_monitoring)
+            FROM order_account_subs s JOIN accounts a ON a.id = s.account_id
+            GROUP BY s.order_id
+            HAVING COUNT(*) <> COUNT(*) FILTER (WHERE COALESCE(a.is_authorized, false) AND NOT a.account_monitoring)
+            ORDER BY s.order_id`,
+		fix: `DELETE FROM order_account_subs s USING accounts a
+            WHERE a.id = s.account_id AND s.order_id = $1
+              AND NOT (COALESCE(a.is_authorized, false) AND NOT a.account_monitoring)`,
+	},
+	{
+		// Недостающие подписки оформляются в Telegram задачей order_link_update, поэтому только отчёт;
+		// приостановленные и архивные заказы синхронизацией не обслуживаются
+		name: CheckOrderSubsActiveCount,
+		query: `SELECT o.id, COALESCE(o.subs_active_count, 0), COUNT(s.id)
+            FROM orders o LEFT JOIN order_account_subs s ON s.order_id = o.id
+            WHERE o.status = 'active'
+            GROUP BY o.id HAVING COALESCE(o.subs_active_count, 0) <> COUNT(s.id) ORDER BY o.id`,
+	},
+}
+
+// queryer — общий интерфейс *sql.DB и *sql.Tx для выборок.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// CheckCounters сверяет денормализованные счётчики с реальными строками и возвращает расхождения.
+// При fix исправимые расхождения устраняются в одной транзакции; на время исправления
+// изменения accounts и order_account_subs блокируются, чтобы триггеры не сдвинули счётчики повторно.
+func (db *DB) CheckCounters(ctx context.Context, fix bool) ([]models.CounterMismatch, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	if !fix {
+		var all []models.CounterMismatch
+		for _, c := range counterChecks {
+			list, err := findMismatches(ctx, db.Conn, c)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, list...)
+		}
+		return all, nil
+	}
+
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE accounts, order_account_subs IN SHARE MODE`); err != nil {
+		return nil, err
+	}
+
+	var all []models.CounterMismatch
+	for _, c := range counterChecks {
+		list, err := findMismatches(ctx, tx, c)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			if c.fix == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, c.fix, list[i].EntityID); err != nil {
+				return nil, fmt.Errorf("%s %d: %w", c.name, list[i].EntityID, err)
+			}
+			list[i].Fixed = true
+		}
+		all = append(all, list...)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// findMismatches выполняет запрос проверки.
+func findMismatches(ctx context.Context, q queryer, c counterCheck) ([]models.CounterMismatch, error) {
+	rows, err := q.QueryContext(ctx, c.query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+	defer rows.Close()
+
+	var list []models.CounterMismatch
+	for rows.Next() {
+		m := models.CounterMismatch{Check: c.name, Fixable: c.fix != ""}
+		if err := rows.Scan(&m.EntityID, &m.Stored, &m.Actual); err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
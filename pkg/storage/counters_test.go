@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// countersTestDriver на каждую проверку возвращает одно расхождение и запоминает запросы Exec.
+type countersTestDriver struct{}
+
+type countersTestConn struct{}
+
+type countersTestTx struct{}
+
+type countersTestRows struct{ done bool }
+
+type countersTestResult struct{}
+
+var countersExecs []string
+
+func (countersTestDriver) Open(name string) (driver.Conn, error) { return &countersTestConn{}, nil }
+
+func (c *countersTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *countersTestConn) Close() error              { return nil }
+func (c *countersTestConn) Begin() (driver.Tx, error) { return countersTestTx{}, nil }
+
+func (c *countersTestConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &countersTestRows{}, nil
+}
+
+func (c *countersTestConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	countersExecs = append(countersExecs, query)
+	return countersTestResult{}, nil
+}
+
+func (countersTestTx) Commit() error   { return nil }
+func (countersTestTx) Rollback() error { return nil }
+
+func (r *countersTestRows) Columns() []string { return []string{"id", "stored", "actual"} }
+func (r *countersTestRows) Close() error      { return nil }
+func (r *countersTestRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0], dest[1], dest[2] = int64(3), int64(5), int64(4)
+	return nil
+}
+
+func (countersTestResult) LastInsertId() (int64, error) { return 0, nil }
+func (countersTestResult) RowsAffected() (int64, error) { return 1, nil }
+
+func init() { sql.Register("countersDummy", countersTestDriver{}) }
+
+// TestCheckCountersFix проверяет, что исправляются только расхождения, не требующие Telegram,
+// а без fix база не изменяется.
+func TestCheckCountersFix(t *testing.T) {
+	conn, err := sql.Open("countersDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	db := &DB{Conn: conn}
+
+	countersExecs = nil
+	list, err := db.CheckCounters(context.Background(), false)
+	if err != nil {
+		t.Fatalf("проверка завершилась ошибкой: %v", err)
+	}
+	if len(list) != len(counterChecks) || len(countersExecs) != 0 {
+		t.Fatalf("без fix ожидался только отчёт: %+v, запросы %v", list, countersExecs)
+	}
+
+	list, err = db.CheckCounters(context.Background(), true)
+	if err != nil {
+		t.Fatalf("исправление завершилось ошибкой: %v", err)
+	}
+	for _, m := range list {
+		if m.Stored != 5 || m.Actual != 4 || m.EntityID != 3 {
+			t.Fatalf("неверно прочитано расхождение: %+v", m)
+		}
+		if m.Fixed != m.Fixable {
+			t.Fatalf("%s: исправлено %v, исправимо %v", m.Check, m.Fixed, m.Fixable)
+		}
+		if m.Check == CheckOrderSubsActiveCount && m.Fixed {
+			t.Fatal("количество подписок не исправляется без Telegram")
+		}
+	}
+	if len(countersExecs) == 0 || !strings.HasPrefix(countersExecs[0], "LOCK TABLE") {
+		t.Fatalf("исправление должно начинаться с блокировки таблиц: %v", countersExecs)
+	}
+	if len(countersExecs) != 4 {
+		t.Fatalf("ожидались блокировка и три исправления, запросы: %v", countersExecs)
+	}
+}
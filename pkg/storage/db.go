@@ -1,14 +1,56 @@
 package storage
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DefaultQueryTimeout ограничивает время одного вызова хранилища, если вызывающий
+// не задал более ранний срок. Без него зависший запрос держит обработчик бесконечно.
+const DefaultQueryTimeout = 30 * time.Second
 
 // DB инкапсулирует соединение с БД, чтобы упростить передачу его
 // по слоям приложения.
 type DB struct {
 	Conn *sql.DB
+	// QueryTimeout — срок одного метода хранилища; 0 отключает ограничение.
+	QueryTimeout time.Duration
 }
 
 // NewDB создаёт обёртку над соединением для единообразной работы с БД.
 func NewDB(conn *sql.DB) *DB {
-	return &DB{Conn: conn}
+	return &DB{Conn: conn, QueryTimeout: DefaultQueryTimeout}
+}
+
+// withTimeout добавляет к ctx срок QueryTimeout. Отмена ctx (например, разрыв
+// HTTP-соединения) прерывает запрос раньше срока.
+func (db *DB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return queryContext(ctx, db.QueryTimeout)
+}
+
+// queryContext ограничивает ctx сроком timeout, если он положителен.
+func queryContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// PoolConfig — настройки пула соединений database/sql.
+type PoolConfig struct {
+	MaxOpenConns    int           // 0 — без ограничения
+	MaxIdleConns    int           // 0 — значение database/sql по умолчанию
+	ConnMaxIdleTime time.Duration // 0 — простаивающие соединения не закрываются
+	ConnMaxLifetime time.Duration // 0 — соединения не пересоздаются
+}
+
+// ConfigurePool применяет настройки пула к соединению.
+func ConfigurePool(conn *sql.DB, cfg PoolConfig) {
+	conn.SetMaxOpenConns(cfg.MaxOpenConns)
+	if cfg.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	conn.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	conn.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 }
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+// slowTestDriver не отвечает на запросы, пока не истечёт контекст.
+type slowTestDriver struct{}
+
+type slowTestConn struct{}
+
+func (slowTestDriver) Open(name string) (driver.Conn, error) { return &slowTestConn{}, nil }
+
+func (c *slowTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *slowTestConn) Close() error              { return nil }
+func (c *slowTestConn) Begin() (driver.Tx, error) { return nil, errors.New("not implemented") }
+
+func (c *slowTestConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func init() { sql.Register("slowDummy", slowTestDriver{}) }
+
+// TestQueryTimeout проверяет, что зависший запрос прерывается по QueryTimeout
+// и по отмене контекста вызывающего.
+func TestQueryTimeout(t *testing.T) {
+	conn, err := sql.Open("slowDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	db := NewDB(conn)
+	db.QueryTimeout = 20 * time.Millisecond
+
+	start := time.Now()
+	if err := db.SaveSos(context.Background(), "x"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ожидался DeadlineExceeded, получено %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("запрос не прерван по сроку: %v", time.Since(start))
+	}
+
+	db.QueryTimeout = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := db.SaveSos(ctx, "x"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ожидался Canceled, получено %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+
+	"atg_go/models"
+)
+
+const featureFlagColumns = `name, enabled, description, updated_at`
+
+func scanFeatureFlag(row interface{ Scan(...any) error }) (*models.FeatureFlag, error) {
+	var f models.FeatureFlag
+	if err := row.Scan(&f.Name, &f.Enabled, &f.Description, &f.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// GetFeatureFlags возвращает все флаги подсистем.
+func (db *DB) GetFeatureFlags(ctx context.Context) ([]models.FeatureFlag, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT `+featureFlagColumns+` FROM feature_flags ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.FeatureFlag
+	for rows.Next() {
+		f, err := scanFeatureFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *f)
+	}
+	return list, rows.Err()
+}
+
+// SetFeatureFlag меняет флаг и в той же транзакции пишет изменение в audit_log.
+// Возвращает sql.ErrNoRows, если флага нет.
+func (db *DB) SetFeatureFlag(ctx context.Context, name string, enabled bool, actor string) (*models.FeatureFlag, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	old, err := scanFeatureFlag(tx.QueryRowContext(ctx, `SELECT `+featureFlagColumns+`
+        FROM feature_flags WHERE name = $1 FOR UPDATE`, name))
+	if err != nil {
+		return nil, err
+	}
+	flag, err := scanFeatureFlag(tx.QueryRowContext(ctx, `UPDATE feature_flags SET enabled = $2, updated_at = NOW()
+        WHERE name = $1 RETURNING `+featureFlagColumns, name, enabled))
+	if err != nil {
+		return nil, err
+	}
+	oldValue, _ := json.Marshal(map[string]bool{"enabled": old.Enabled})
+	newValue, _ := json.Marshal(map[string]bool{"enabled": flag.Enabled})
+	if err := insertAuditLog(ctx, tx, models.AuditLogEntry{
+		Actor:    actor,
+		Action:   "feature_flag.update",
+		Entity:   "feature_flag",
+		EntityID: name,
+		OldValue: oldValue,
+		NewValue: newValue,
+	}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// MarkFloodWait сохраняет время окончания флуд-вейта аккаунта.
+// Более раннее значение не затирает уже записанное более позднее.
+func (db *DB) MarkFloodWait(ctx context.Context, accountID int, until time.Time) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE accounts
+        SET floodwait_until = GREATEST(COALESCE(floodwait_until, $2), $2)
+        WHERE id = $1`, accountID, until)
+	return err
+}
+
+// GetActiveFloodWaits возвращает незавершённые флуд-вейты по ID аккаунта.
+func (db *DB) GetActiveFloodWaits(ctx context.Context) (map[int]time.Time, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT id, floodwait_until FROM accounts WHERE floodwait_until > NOW()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			id    int
+			until time.Time
+		)
+		if err := rows.Scan(&id, &until); err != nil {
+			return nil, err
+		}
+		res[id] = until
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"atg_go/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrGenerationRunRejected означает, что база отклонила итог генерации
+// (название категории занято, некорректная ссылка): повтор не поможет.
+var ErrGenerationRunRejected = errors.New("итог генерации отклонён")
+
+// generationRunColumns перечисляет поля запусков генерации, читаемые во всех выборках.
+const generationRunColumns = `id, name_category, input_channels, result_count_links, status, inputs_checked, queue, processed, results, category_id, error, created_at, started_at, finished_at, updated_at`
+
+// scanGenerationRun читает строку generation_runs с учётом NULL-полей.
+func scanGenerationRun(s rowScanner) (models.GenerationRun, error) {
+	var (
+		r          models.GenerationRun
+		inputs     pq.StringArray
+		queue      pq.StringArray
+		processed  pq.StringArray
+		results    pq.StringArray
+		categoryID sql.NullInt64
+		errMsg     sql.NullString
+		started    sql.NullTime
+		finished   sql.NullTime
+	)
+	if err := s.Scan(&r.ID, &r.NameCategory, &inputs, &r.ResultCountLinks, &r.Status, &r.InputsChecked, &queue, &processed, &results, &categoryID, &errMsg, &r.CreatedAt, &started, &finished, &r.UpdatedAt); err != nil {
+		return r, err
+	}
+	r.InputChannels = []string(inputs)
+	r.Queue = []string(queue)
+	r.Processed = []string(processed)
+	r.Results = []string(results)
+	if categoryID.Valid {
+		id := int(categoryID.Int64)
+		r.CategoryID = &id
+	}
+	if errMsg.Valid {
+		r.Error = &errMsg.String
+	}
+	if started.Valid {
+		r.StartedAt = &started.Time
+	}
+	if finished.Valid {
+		r.FinishedAt = &finished.Time
+	}
+	return r, nil
+}
+
+// CreateGenerationRun регистрирует запуск генерации в статусе queued и возвращает его ID.
+// Очередь обхода изначально совпадает с исходными каналами.
+func (db *DB) CreateGenerationRun(ctx context.Context, name string, inputs []string, count int) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var id int64
+	err := db.Conn.QueryRowContext(ctx, `INSERT INTO generation_runs (name_category, input_channels, result_count_links, queue)
+        VALUES ($1, $2, $3, $2) RETURNING id`, name, pq.Array(inputs), count).Scan(&id)
+	return id, err
+}
+
+// ClaimGenerationRun переводит в running следующий запуск и возвращает его.
+// Сначала берутся запуски, прерванные перезапуском в статусе running, затем самые старые из очереди.
+// Если продолжать нечего, возвращает sql.ErrNoRows.
+func (db *DB) ClaimGenerationRun(ctx context.Context) (*models.GenerationRun, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	r, err := scanGenerationRun(db.Conn.QueryRowContext(ctx, `UPDATE generation_runs
+        SET status = 'running', started_at = COALESCE(started_at, NOW()), updated_at = NOW()
+        WHERE id = (
+            SELECT id FROM generation_runs
+            WHERE status IN ('queued', 'running')
+            ORDER BY status = 'running' DESC, created_at, id
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING `+generationRunColumns))
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GenerationProgress — снимок обхода рекомендаций, достаточный для его продолжения.
+type GenerationProgress struct {
+	InputsChecked int
+	Queue         []string
+	Processed     []string
+	Results       []string
+}
+
+// SaveGenerationRunProgress сохраняет ход обхода запуска.
+// Возвращает false, если запуск уже не в статусе running (например, отменён).
+func (db *DB) SaveGenerationRunProgress(ctx context.Context, id int64, p GenerationProgress) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	res, err := db.Conn.ExecContext(ctx, `UPDATE generation_runs
+        SET inputs_checked = $2, queue = $3, processed = $4, results = $5, updated_at = NOW()
+        WHERE id = $1 AND status = 'running'`,
+		id, p.InputsChecked, pq.Array(p.Queue), pq.Array(p.Processed), pq.Array(p.Results))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CompleteGenerationRun создаёт категорию из найденных ссылок и помечает запуск succeeded.
+// Всё выполняется в одной транзакции, поэтому повторное продолжение запуска после сбоя
+// не создаёт категорию дважды. Если запуск уже не выполняется, возвращает sql.ErrNoRows;
+// если база отклонила категорию или ссылки — ErrGenerationRunRejected.
+func (db *DB) CompleteGenerationRun(ctx context.Context, id int64, results []string) (*models.Category, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var name string
+	if err := tx.QueryRowContext(ctx, `SELECT name_category FROM generation_runs WHERE id = $1 AND status = 'running' FOR UPDATE`, id).Scan(&name); err != nil {
+		return nil, err
+	}
+	category, err := insertCategory(ctx, tx, name, results)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, fmt.Errorf("%w: категория %q уже существует", ErrGenerationRunRejected, name)
+	}
+	// Классы 22 и 23 — ошибки данных и ограничений: те же ссылки отклонятся и при повторе
+	if errors.As(err, &pqErr) && (pqErr.Code.Class() == "22" || pqErr.Code.Class() == "23") {
+		return nil, fmt.Errorf("%w: %v", ErrGenerationRunRejected, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE generation_runs
+        SET status = 'succeeded', results = $2, queue = '{}', category_id = $3, finished_at = NOW(), updated_at = NOW()
+        WHERE id = $1`, id, pq.Array(results), category.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// GenerationNameTaken сообщает, что категория с таким названием уже есть
+// или её создаёт другой незавершённый запуск.
+func (db *DB) GenerationNameTaken(ctx context.Context, name string) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var taken bool
+	err := db.Conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE name = $1)
+        OR EXISTS (SELECT 1 FROM generation_runs WHERE name_category = $1 AND status IN ('queued', 'running'))`, name).Scan(&taken)
+	return taken, err
+}
+
+// FailGenerationRun завершает выполняющийся запуск статусом failed с причиной errMsg.
+func (db *DB) FailGenerationRun(ctx context.Context, id int64, errMsg string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE generation_runs
+        SET status = 'failed', error = NULLIF($2, ''), finished_at = NOW(), updated_at = NOW()
+        WHERE id = $1 AND status = 'running'`, id, errMsg)
+	return err
+}
+
+// CancelGenerationRun отменяет запуск в очереди или в работе.
+// Выполняющий запуск обработчик заметит отмену при следующем сохранении прогресса.
+// Возвращает false, если запуск уже завершён или не найден.
+func (db *DB) CancelGenerationRun(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	res, err := db.Conn.ExecContext(ctx, `UPDATE generation_runs
+        SET status = 'cancelled', finished_at = NOW(), updated_at = NOW()
+        WHERE id = $1 AND status IN ('queued', 'running')`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetGenerationRun возвращает запуск генерации по ID или sql.ErrNoRows.
+func (db *DB) GetGenerationRun(ctx context.Context, id int64) (*models.GenerationRun, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	r, err := scanGenerationRun(db.Conn.QueryRowContext(ctx, `SELECT `+generationRunColumns+` FROM generation_runs WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListGenerationRuns возвращает последние запуски генерации, новые — первыми.
+func (db *DB) ListGenerationRuns(ctx context.Context, limit int) ([]models.GenerationRun, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := db.Conn.QueryContext(ctx, `SELECT `+generationRunColumns+` FROM generation_runs ORDER BY created_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.GenerationRun
+	for rows.Next() {
+		r, err := scanGenerationRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
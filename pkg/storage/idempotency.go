@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"atg_go/models"
+)
+
+// idempotencyInFlightTimeout — через сколько незавершённый запрос считается брошенным
+// (процесс упал, не сохранив ответ), и его ключ может занять повтор.
+const idempotencyInFlightTimeout = 15 * time.Minute
+
+// ReserveIdempotencyKey занимает ключ для нового запроса. Если ключ уже занят и не истёк,
+// возвращается сохранённая запись и reserved = false. Истёкший ключ и ключ брошенного
+// запроса без ответа старше idempotencyInFlightTimeout занимаются заново.
+func (db *DB) ReserveIdempotencyKey(ctx context.Context, key, method, path, requestHash string, ttl time.Duration) (*models.IdempotencyKey, bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var reserved bool
+	err := db.Conn.QueryRowContext(ctx, `INSERT INTO idempotency_keys (key, method, path, request_hash, expires_at)
+        VALUES ($1, $2, $3, $4, NOW() + make_interval(secs => $5))
+        ON CONFLICT (key, method, path) DO UPDATE
+            SET request_hash = EXCLUDED.request_hash, status_code = NULL, content_type = NULL,
+                response_body = NULL, created_at = NOW(), expires_at = EXCLUDED.expires_at
+            WHERE idempotency_keys.expires_at <= NOW()
+               OR (idempotency_keys.status_code IS NULL
+                   AND idempotency_keys.created_at <= NOW() - make_interval(secs => $6))
+        RETURNING TRUE`, key, method, path, requestHash, ttl.Seconds(), idempotencyInFlightTimeout.Seconds()).Scan(&reserved)
+	if err == nil {
+		return nil, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	rec := models.IdempotencyKey{Key: key, Method: method, Path: path}
+	var (
+		status      sql.NullInt64
+		contentType sql.NullString
+	)
+	err = db.Conn.QueryRowContext(ctx, `SELECT request_hash, status_code, content_type, response_body
+        FROM idempotency_keys WHERE key = $1 AND method = $2 AND path = $3`, key, method, path).
+		Scan(&rec.RequestHash, &status, &contentType, &rec.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	if status.Valid {
+		code := int(status.Int64)
+		rec.StatusCode = &code
+	}
+	rec.ContentType = contentType.String
+	return &rec, false, nil
+}
+
+// SaveIdempotentResponse сохраняет ответ на запрос, занявший ключ.
+func (db *DB) SaveIdempotentResponse(ctx context.Context, key, method, path string, status int, contentType string, body []byte) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE idempotency_keys
+        SET status_code = $4, content_type = $5, response_body = $6
+        WHERE key = $1 AND method = $2 AND path = $3`, key, method, path, status, contentType, body)
+	return err
+}
+
+// ReleaseIdempotencyKey освобождает ключ, чтобы запрос можно было повторить,
+// например после внутренней ошибки сервера.
+func (db *DB) ReleaseIdempotencyKey(ctx context.Context, key, method, path string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND method = $2 AND path = $3`, key, method, path)
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys удаляет истёкшие ключи и возвращает их количество.
+func (db *DB) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	res, err := db.Conn.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
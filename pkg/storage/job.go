@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"atg_go/models"
+)
+
+// jobColumns перечисляет поля заданий, читаемые во всех выборках.
+const jobColumns = `id, kind, status, params, total, processed, successful, failed, current_item, result, error, owner, created_at, started_at, finished_at, updated_at`
+
+// scanJob читает строку jobs с учётом NULL-полей.
+func scanJob(s rowScanner) (models.Job, error) {
+	var (
+		j        models.Job
+		params   []byte
+		result   []byte
+		current  sql.NullString
+		errMsg   sql.NullString
+		owner    sql.NullString
+		started  sql.NullTime
+		finished sql.NullTime
+	)
+	if err := s.Scan(&j.ID, &j.Kind, &j.Status, &params, &j.Total, &j.Processed, &j.Successful, &j.Failed, &current, &result, &errMsg, &owner, &j.CreatedAt, &started, &finished, &j.UpdatedAt); err != nil {
+		return j, err
+	}
+	j.Params = params
+	if len(result) > 0 {
+		j.Result = result
+	}
+	if current.Valid {
+		j.CurrentItem = &current.String
+	}
+	if errMsg.Valid {
+		j.Error = &errMsg.String
+	}
+	if owner.Valid {
+		j.Owner = &owner.String
+	}
+	if started.Valid {
+		j.StartedAt = &started.Time
+	}
+	if finished.Valid {
+		j.FinishedAt = &finished.Time
+	}
+	return j, nil
+}
+
+// CreateJob регистрирует новое задание экземпляра owner в статусе queued и возвращает его ID.
+func (db *DB) CreateJob(ctx context.Context, kind string, params []byte, owner string) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	if len(params) == 0 {
+		params = []byte("{}")
+	}
+	var id int64
+	err := db.Conn.QueryRowContext(ctx, `INSERT INTO jobs (kind, params, owner) VALUES ($1, $2, NULLIF($3, '')) RETURNING id`, kind, params, owner).Scan(&id)
+	return id, err
+}
+
+// StartJob переводит задание в статус running.
+func (db *DB) StartJob(ctx context.Context, id int64) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE jobs SET status = 'running', started_at = NOW(), updated_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// JobProgress — снимок прогресса задания.
+type JobProgress struct {
+	Total       int
+	Processed   int
+	Successful  int
+	Failed      int
+	CurrentItem string
+}
+
+// UpdateJobProgress сохраняет текущий прогресс задания.
+func (db *DB) UpdateJobProgress(ctx context.Context, id int64, p JobProgress) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE jobs
+        SET total = $2, processed = $3, successful = $4, failed = $5, current_item = NULLIF($6, ''), updated_at = NOW()
+        WHERE id = $1`, id, p.Total, p.Processed, p.Successful, p.Failed, p.CurrentItem)
+	return err
+}
+
+// FinishJob сохраняет итоговый статус, результат и ошибку задания.
+func (db *DB) FinishJob(ctx context.Context, id int64, status string, result []byte, errMsg string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE jobs
+        SET status = $2, result = $3, error = NULLIF($4, ''), current_item = NULL, finished_at = NOW(), updated_at = NOW()
+        WHERE id = $1`, id, status, nullableJSON(result), errMsg)
+	return err
+}
+
+// FailInterruptedJobs помечает как failed незавершённые задания, которые уже никто не выполняет:
+// задания самого owner (остались от прошлого запуска процесса), задания без владельца и задания,
+// чей updated_at не обновлялся дольше staleAfter (реплика-владелец остановилась).
+// Задания, выполняющиеся на других живых репликах, не трогает.
+func (db *DB) FailInterruptedJobs(ctx context.Context, owner string, staleAfter time.Duration) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	res, err := db.Conn.ExecContext(ctx, `UPDATE jobs
+        SET status = 'failed', error = 'прервано перезапуском сервиса', finished_at = NOW(), updated_at = NOW()
+        WHERE status IN ('queued', 'running')
+          AND (owner IS NULL OR owner = $1 OR updated_at < NOW() - make_interval(secs => $2))`, owner, staleAfter.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// HeartbeatJobs обновляет updated_at незавершённых заданий owner, показывая, что они ещё выполняются,
+// и возвращает ID тех из них, отмену которых запросили через другую реплику.
+func (db *DB) HeartbeatJobs(ctx context.Context, owner string) ([]int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `UPDATE jobs SET updated_at = NOW()
+        WHERE owner = $1 AND status IN ('queued', 'running')
+        RETURNING id, cancel_requested`, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cancelled []int64
+	for rows.Next() {
+		var (
+			id        int64
+			requested bool
+		)
+		if err := rows.Scan(&id, &requested); err != nil {
+			return nil, err
+		}
+		if requested {
+			cancelled = append(cancelled, id)
+		}
+	}
+	return cancelled, rows.Err()
+}
+
+// RequestJobCancel отмечает, что незавершённое задание нужно отменить; реплика-владелец
+// заметит отметку при следующем обновлении updated_at. Возвращает false, если задание уже завершено.
+func (db *DB) RequestJobCancel(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	res, err := db.Conn.ExecContext(ctx, `UPDATE jobs SET cancel_requested = TRUE
+        WHERE id = $1 AND status IN ('queued', 'running')`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetJob возвращает задание по ID или sql.ErrNoRows.
+func (db *DB) GetJob(ctx context.Context, id int64) (*models.Job, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	j, err := scanJob(db.Conn.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// JobFilter задаёт условия выборки заданий для API.
+type JobFilter struct {
+	Kind   string
+	Status string
+	Limit  int
+}
+
+// ListJobs возвращает задания по фильтру, новые — первыми.
+func (db *DB) ListJobs(ctx context.Context, f JobFilter) ([]models.Job, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var (
+		conds []string
+		args  []any
+	)
+	if f.Kind != "" {
+		args = append(args, f.Kind)
+		conds = append(conds, fmt.Sprintf("kind = $%d", len(args)))
+	}
+	if f.Status != "" {
+		args = append(args, f.Status)
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	query := `SELECT ` + jobColumns + ` FROM jobs`
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d`, len(args))
+
+	rows, err := db.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// CountActiveJobs возвращает число заданий в очереди и в работе.
+func (db *DB) CountActiveJobs(ctx context.Context) (int, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var n int
+	err := db.Conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM jobs WHERE status IN ('queued', 'running')`).Scan(&n)
+	return n, err
+}
+
+// nullableJSON превращает пустой JSON в NULL, чтобы не сохранять пустые строки в jsonb.
+func nullableJSON(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
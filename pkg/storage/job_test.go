@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// TestListJobsFilter проверяет условия выборки заданий и лимит по умолчанию.
+// Используется мок-драйвер из scheduled_action_test.go: он запоминает последний запрос.
+func TestListJobsFilter(t *testing.T) {
+	conn, err := sql.Open("scheduledDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	db := &DB{Conn: conn}
+
+	if _, err := db.ListJobs(context.Background(), JobFilter{Kind: "unsubscribe", Status: "running"}); err != nil {
+		t.Fatalf("выборка завершилась ошибкой: %v", err)
+	}
+	if !strings.Contains(scheduledLastQuery, "FROM jobs WHERE kind = $1 AND status = $2") {
+		t.Fatalf("неожиданное условие запроса: %s", scheduledLastQuery)
+	}
+	if !strings.Contains(scheduledLastQuery, "ORDER BY created_at DESC LIMIT $3") {
+		t.Fatalf("лимит должен быть третьим параметром: %s", scheduledLastQuery)
+	}
+	if len(scheduledLastArgs) != 3 || scheduledLastArgs[2] != int64(50) {
+		t.Fatalf("ожидался лимит по умолчанию 50, аргументы: %v", scheduledLastArgs)
+	}
+
+	if _, err := db.ListJobs(context.Background(), JobFilter{Limit: 10}); err != nil {
+		t.Fatalf("выборка без фильтров завершилась ошибкой: %v", err)
+	}
+	if strings.Contains(scheduledLastQuery, "WHERE") {
+		t.Fatalf("без фильтров WHERE не нужен: %s", scheduledLastQuery)
+	}
+}
+
+// TestHeartbeatJobsOwner проверяет, что обновляются только незавершённые задания своей реплики.
+func TestHeartbeatJobsOwner(t *testing.T) {
+	conn, err := sql.Open("scheduledDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	db := &DB{Conn: conn}
+
+	ids, err := db.HeartbeatJobs(context.Background(), "host:1")
+	if err != nil {
+		t.Fatalf("обновление заданий завершилось ошибкой: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Fatalf("без заданий отменять нечего, получено %v", ids)
+	}
+	if !strings.Contains(scheduledLastQuery, "WHERE owner = $1 AND status IN ('queued', 'running')") {
+		t.Fatalf("неожиданное условие запроса: %s", scheduledLastQuery)
+	}
+	if len(scheduledLastArgs) != 1 || scheduledLastArgs[0] != "host:1" {
+		t.Fatalf("ожидался владелец host:1, аргументы: %v", scheduledLastArgs)
+	}
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"atg_go/models"
+)
+
+// ErrTaskAlreadyRunning означает, что у задачи уже есть незавершённый запуск.
+var ErrTaskAlreadyRunning = errors.New("задача уже выполняется")
+
+// GetMaintenanceTasks возвращает все служебные задачи с расписаниями.
+func (db *DB) GetMaintenanceTasks(ctx context.Context) ([]models.MaintenanceTask, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT name, cron_expr, enabled, updated_at FROM maintenance_tasks ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.MaintenanceTask
+	for rows.Next() {
+		var t models.MaintenanceTask
+		if err := rows.Scan(&t.Name, &t.CronExpr, &t.Enabled, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// UpdateMaintenanceTask меняет расписание и/или признак включения задачи.
+// nil-поля не изменяются. Возвращает sql.ErrNoRows, если задачи нет.
+func (db *DB) UpdateMaintenanceTask(ctx context.Context, name string, cronExpr *string, enabled *bool) (*models.MaintenanceTask, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var t models.MaintenanceTask
+	err := db.Conn.QueryRowContext(ctx, `UPDATE maintenance_tasks
+        SET cron_expr = COALESCE($2, cron_expr), enabled = COALESCE($3, enabled), updated_at = NOW()
+        WHERE name = $1
+        RETURNING name, cron_expr, enabled, updated_at`, name, cronExpr, enabled).
+		Scan(&t.Name, &t.CronExpr, &t.Enabled, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// StartMaintenanceRun фиксирует начало запуска задачи.
+// Уникальный частичный индекс не даёт создать второй незавершённый запуск —
+// в этом случае возвращается ErrTaskAlreadyRunning.
+func (db *DB) StartMaintenanceRun(ctx context.Context, name, trigger string) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var id int64
+	err := db.Conn.QueryRowContext(ctx, `INSERT INTO maintenance_task_runs (task_name, trigger) VALUES ($1, $2)
+        ON CONFLICT (task_name) WHERE status = 'running' DO NOTHING
+        RETURNING id`, name, trigger).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrTaskAlreadyRunning
+	}
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// FinishMaintenanceRun сохраняет итог запуска.
+func (db *DB) FinishMaintenanceRun(ctx context.Context, id int64, status, result, errMsg string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE maintenance_task_runs
+        SET finished_at = NOW(), status = $2, result = NULLIF($3, ''), error = NULLIF($4, '')
+        WHERE id = $1`, id, status, result, errMsg)
+	return err
+}
+
+// AbortStaleMaintenanceRuns закрывает запуски, оставшиеся в статусе running дольше olderThan.
+// Такие записи остаются после падения процесса и иначе навсегда блокировали бы задачу.
+func (db *DB) AbortStaleMaintenanceRuns(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	res, err := db.Conn.ExecContext(ctx, `UPDATE maintenance_task_runs
+        SET finished_at = NOW(), status = 'failed', error = 'запуск прерван'
+        WHERE status = 'running' AND started_at < NOW() - make_interval(secs => $1)`, olderThan.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetMaintenanceRuns возвращает последние запуски задачи, новые — первыми.
+func (db *DB) GetMaintenanceRuns(ctx context.Context, name string, limit int) ([]models.MaintenanceTaskRun, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT id, task_name, started_at, finished_at, status, trigger, result, error
+        FROM maintenance_task_runs WHERE task_name = $1 ORDER BY started_at DESC LIMIT $2`, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.MaintenanceTaskRun
+	for rows.Next() {
+		var (
+			r        models.MaintenanceTaskRun
+			finished sql.NullTime
+			result   sql.NullString
+			errMsg   sql.NullString
+		)
+		if err := rows.Scan(&r.ID, &r.TaskName, &r.StartedAt, &finished, &r.Status, &r.Trigger, &result, &errMsg); err != nil {
+			return nil, err
+		}
+		if finished.Valid {
+			r.FinishedAt = &finished.Time
+		}
+		if result.Valid {
+			r.Result = &result.String
+		}
+		if errMsg.Valid {
+			r.Error = &errMsg.String
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,278 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Операции, которые присылает триггер notify_row_change.
+const (
+	NotifyInsert = "INSERT"
+	NotifyUpdate = "UPDATE"
+	NotifyDelete = "DELETE"
+)
+
+// Notification — разобранное уведомление об изменении строки таблицы.
+// Row может отсутствовать, если строка не поместилась в лимит pg_notify.
+type Notification struct {
+	Channel string          `json:"-"`
+	Op      string          `json:"op"`
+	Table   string          `json:"table"`
+	ID      int             `json:"id"`
+	Row     json.RawMessage `json:"row,omitempty"`
+}
+
+// NotifyHandler обрабатывает одно уведомление.
+type NotifyHandler func(n Notification)
+
+// ResyncFunc перечитывает состояние целиком. Вызывается после переподключения,
+// так как изменения, произошедшие без соединения, PostgreSQL не доставляет.
+type ResyncFunc func()
+
+// subscription — обработчики одного подписчика на канал.
+type subscription struct {
+	id      int
+	handler NotifyHandler
+	resync  ResyncFunc
+}
+
+// Notifier держит одно соединение LISTEN и раздаёт уведомления подписчикам.
+type Notifier struct {
+	dsn string
+
+	mu       sync.Mutex
+	subs     map[string][]subscription
+	nextID   int
+	listener *pq.Listener
+
+	connected atomic.Bool
+}
+
+// NewNotifier создаёт подсистему уведомлений поверх указанного DSN.
+func NewNotifier(dsn string) *Notifier {
+	return &Notifier{dsn: dsn, subs: make(map[string][]subscription)}
+}
+
+// Connected сообщает, есть ли сейчас соединение слушателя с БД.
+func (n *Notifier) Connected() bool {
+	return n.connected.Load()
+}
+
+// Subscribe подписывает обработчик на канал уведомлений.
+// resync может быть nil, если подписчику не нужно перечитывать состояние.
+// Возвращает функцию отписки.
+func (n *Notifier) Subscribe(channel string, handler NotifyHandler, resync ResyncFunc) (func(), error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.subs[channel]) == 0 && n.listener != nil {
+		if err := n.listener.Listen(channel); err != nil && err != pq.ErrChannelAlreadyOpen {
+			return nil, fmt.Errorf("LISTEN %s: %w", channel, err)
+		}
+	}
+	n.nextID++
+	id := n.nextID
+	n.subs[channel] = append(n.subs[channel], subscription{id: id, handler: handler, resync: resync})
+
+	return func() { n.unsubscribe(channel, id) }, nil
+}
+
+// unsubscribe удаляет подписчика; последний ушедший снимает LISTEN с канала.
+func (n *Notifier) unsubscribe(channel string, id int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	subs := n.subs[channel]
+	for i, s := range subs {
+		if s.id == id {
+			subs = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(subs) > 0 {
+		n.subs[channel] = subs
+		return
+	}
+	delete(n.subs, channel)
+	if n.listener != nil {
+		if err := n.listener.Unlisten(channel); err != nil && err != pq.ErrChannelNotOpen {
+			log.Printf("[NOTIFY] UNLISTEN %s: %v", channel, err)
+		}
+	}
+}
+
+// Run держит соединение и раздаёт уведомления до отмены контекста.
+// Переподключение выполняет pq.Listener, после него вызываются resync всех подписчиков.
+func (n *Notifier) Run(ctx context.Context) {
+	listener := pq.NewListener(n.dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+			n.connected.Store(true)
+		case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+			n.connected.Store(false)
+			if err != nil {
+				log.Printf("[NOTIFY] соединение потеряно: %v", err)
+			}
+		}
+	})
+
+	n.mu.Lock()
+	n.listener = listener
+	for channel := range n.subs {
+		if err := listener.Listen(channel); err != nil && err != pq.ErrChannelAlreadyOpen {
+			log.Printf("[NOTIFY] LISTEN %s: %v", channel, err)
+		}
+	}
+	n.mu.Unlock()
+
+	defer func() {
+		n.mu.Lock()
+		n.listener = nil
+		n.mu.Unlock()
+		listener.Close()
+		n.connected.Store(false)
+	}()
+
+	// Периодический ping помогает быстрее заметить обрыв соединения
+	ping := time.NewTicker(time.Minute)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			go listener.Ping()
+		case pn := <-listener.Notify:
+			if pn == nil {
+				// pq присылает nil после восстановления соединения
+				log.Printf("[NOTIFY] соединение восстановлено, выполняем полную синхронизацию")
+				n.resyncAll()
+				continue
+			}
+			n.dispatch(pn.Channel, pn.Extra)
+		}
+	}
+}
+
+// dispatch разбирает payload и передаёт уведомление подписчикам канала.
+func (n *Notifier) dispatch(channel, payload string) {
+	note, err := ParseNotification(channel, payload)
+	if err != nil {
+		log.Printf("[NOTIFY] некорректный payload %q в %s: %v", payload, channel, err)
+		return
+	}
+	for _, s := range n.snapshot(channel) {
+		s.handler(note)
+	}
+}
+
+// resyncAll вызывает полную синхронизацию у всех подписчиков.
+func (n *Notifier) resyncAll() {
+	n.mu.Lock()
+	var all []subscription
+	for _, subs := range n.subs {
+		all = append(all, subs...)
+	}
+	n.mu.Unlock()
+
+	for _, s := range all {
+		if s.resync != nil {
+			s.resync()
+		}
+	}
+}
+
+// snapshot копирует подписчиков, чтобы вызывать их без удержания мьютекса.
+func (n *Notifier) snapshot(channel string) []subscription {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]subscription(nil), n.subs[channel]...)
+}
+
+// ParseNotification разбирает JSON-уведомление триггера notify_row_change.
+// Для совместимости со старым триггером поддерживается payload из одного ID,
+// такое уведомление трактуется как UPDATE.
+func ParseNotification(channel, payload string) (Notification, error) {
+	payload = strings.TrimSpace(payload)
+	if id, err := strconv.Atoi(payload); err == nil {
+		return Notification{Channel: channel, Op: NotifyUpdate, ID: id}, nil
+	}
+	var note Notification
+	if err := json.Unmarshal([]byte(payload), &note); err != nil {
+		return Notification{}, err
+	}
+	switch note.Op {
+	case NotifyInsert, NotifyUpdate, NotifyDelete:
+	default:
+		return Notification{}, fmt.Errorf("неизвестная операция %q", note.Op)
+	}
+	note.Channel = channel
+	return note, nil
+}
+
+// NotifyQueue выполняет обработчики подписчика в отдельной горутине по порядку поступления.
+// Notifier вызывает обработчики в своей горутине, поэтому подписчик с запросами к Telegram
+// задерживал бы уведомления всех каналов. При переполнении очереди отдельные уведомления
+// отбрасываются и заменяются одной полной синхронизацией.
+type NotifyQueue struct {
+	jobs     chan func()
+	resync   ResyncFunc
+	overflow atomic.Bool
+}
+
+// NewNotifyQueue создаёт очередь на size заданий; она работает до отмены ctx.
+// resync выполняется после переподключения слушателя и при переполнении очереди.
+func NewNotifyQueue(ctx context.Context, size int, resync ResyncFunc) *NotifyQueue {
+	q := &NotifyQueue{jobs: make(chan func(), size), resync: resync}
+	go q.run(ctx)
+	return q
+}
+
+// Handler оборачивает обработчик так, чтобы уведомление лишь ставилось в очередь.
+func (q *NotifyQueue) Handler(handler NotifyHandler) NotifyHandler {
+	return func(n Notification) {
+		q.push(func() { handler(n) })
+	}
+}
+
+// Resync ставит полную синхронизацию в очередь; передаётся в Subscribe вместо исходной функции.
+func (q *NotifyQueue) Resync() {
+	q.push(q.resync)
+}
+
+// push добавляет задание, не блокируя Notifier.
+func (q *NotifyQueue) push(job func()) {
+	select {
+	case q.jobs <- job:
+	default:
+		if !q.overflow.Swap(true) {
+			log.Printf("[NOTIFY] очередь подписчика переполнена, будет выполнена полная синхронизация")
+		}
+	}
+}
+
+// run выполняет задания по одному до отмены ctx.
+func (q *NotifyQueue) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.jobs:
+			job()
+		}
+		// Пропущенные при переполнении уведомления восполняет полная синхронизация
+		if q.overflow.Swap(false) && q.resync != nil {
+			q.resync()
+		}
+	}
+}
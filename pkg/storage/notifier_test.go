@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestParseNotification проверяет разбор JSON-уведомлений и старого формата с одним ID.
+func TestParseNotification(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload string
+		op      string
+		id      int
+		hasRow  bool
+		wantErr bool
+	}{
+		{"вставка", `{"op":"INSERT","table":"channel_duplicate","id":5,"row":{"id":5}}`, NotifyInsert, 5, true, false},
+		{"удаление", `{"op":"DELETE","table":"channel_duplicate","id":7,"row":{"id":7}}`, NotifyDelete, 7, true, false},
+		{"без строки", `{"op":"UPDATE","table":"channel_duplicate","id":3}`, NotifyUpdate, 3, false, false},
+		{"старый формат", `42`, NotifyUpdate, 42, false, false},
+		{"неизвестная операция", `{"op":"TRUNCATE","id":1}`, "", 0, false, true},
+		{"мусор", `not json`, "", 0, false, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := ParseNotification("channel_duplicate_changed", tc.payload)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ожидалась ошибка для %q", tc.payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if n.Op != tc.op || n.ID != tc.id {
+				t.Fatalf("получено op=%s id=%d, ожидалось op=%s id=%d", n.Op, n.ID, tc.op, tc.id)
+			}
+			if n.Channel != "channel_duplicate_changed" {
+				t.Fatalf("канал не сохранён: %q", n.Channel)
+			}
+			if (len(n.Row) > 0) != tc.hasRow {
+				t.Fatalf("наличие строки %v, ожидалось %v", len(n.Row) > 0, tc.hasRow)
+			}
+		})
+	}
+}
+
+// TestNotifierDispatchAndResync проверяет доставку подписчикам, отписку и полную синхронизацию.
+func TestNotifierDispatchAndResync(t *testing.T) {
+	n := NewNotifier("")
+
+	var got []int
+	resyncs := 0
+	unsubscribe, err := n.Subscribe("orders_changed", func(note Notification) {
+		got = append(got, note.ID)
+	}, func() { resyncs++ })
+	if err != nil {
+		t.Fatalf("подписка завершилась ошибкой: %v", err)
+	}
+	otherCalls := 0
+	if _, err := n.Subscribe("channel_duplicate_changed", func(Notification) { otherCalls++ }, nil); err != nil {
+		t.Fatalf("подписка завершилась ошибкой: %v", err)
+	}
+
+	n.dispatch("orders_changed", `{"op":"UPDATE","table":"orders","id":1}`)
+	n.dispatch("orders_changed", `broken`)
+	n.resyncAll()
+	if len(got) != 1 || got[0] != 1 {
+		t.Fatalf("ожидалось одно уведомление с ID 1, получено %v", got)
+	}
+	if otherCalls != 0 {
+		t.Fatalf("уведомление попало подписчику другого канала")
+	}
+	if resyncs != 1 {
+		t.Fatalf("ожидалась одна синхронизация, получено %d", resyncs)
+	}
+
+	unsubscribe()
+	n.dispatch("orders_changed", `{"op":"DELETE","table":"orders","id":2}`)
+	if len(got) != 1 {
+		t.Fatalf("после отписки уведомления не должны доставляться: %v", got)
+	}
+}
+
+// TestNotifyQueueOverflow проверяет, что медленный подписчик не блокирует раздачу,
+// а отброшенные при переполнении уведомления заменяются полной синхронизацией.
+func TestNotifyQueueOverflow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	handled := make(chan int, 10)
+	resynced := make(chan struct{}, 10)
+	q := NewNotifyQueue(ctx, 1, func() { resynced <- struct{}{} })
+	handler := q.Handler(func(n Notification) {
+		<-release
+		handled <- n.ID
+	})
+
+	// Первое уведомление занимает обработчик, второе — очередь, третье не помещается
+	done := make(chan struct{})
+	go func() {
+		handler(Notification{ID: 1})
+		time.Sleep(10 * time.Millisecond)
+		handler(Notification{ID: 2})
+		handler(Notification{ID: 3})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("постановка в очередь заблокировала вызывающего")
+	}
+
+	close(release)
+	for _, want := range []int{1, 2} {
+		select {
+		case id := <-handled:
+			if id != want {
+				t.Fatalf("обработано %d, ожидалось %d", id, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("уведомление %d не обработано", want)
+		}
+	}
+	select {
+	case <-resynced:
+	case <-time.After(time.Second):
+		t.Fatal("после переполнения не выполнена полная синхронизация")
+	}
+	select {
+	case id := <-handled:
+		t.Fatalf("отброшенное уведомление %d обработано", id)
+	default:
+	}
+}
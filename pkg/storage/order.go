@@ -2,30 +2,45 @@ package storage
 
 import (
 	"atg_go/models"
+	"atg_go/pkg/link"
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
 	"strings"
 
 	"github.com/lib/pq"
 )
 
+// ErrOrderArchived означает, что заказ в архиве и не может изменяться.
+var ErrOrderArchived = errors.New("заказ в архиве")
+
+// ErrOrderURLTaken означает, что ссылка заказа занята другим неархивным заказом.
+var ErrOrderURLTaken = errors.New("ссылка уже используется другим заказом")
+
+// orderColumns — поля заказа для выборки целиком.
+// gender и status приводим к text, иначе pq не сможет сканировать enum.
+const orderColumns = `id, name, category, url_description, url_default, channel_tgid, accounts_number_theory, accounts_number_fact, subs_active_count, gender::text[], status::text, archived_at, date_time`
+
 // ExtractChannelTGID извлекает ID канала из ссылки вида https://t.me/c/<id>/...
 // Возвращает nil, если ссылка не соответствует ожидаемому формату
 func ExtractChannelTGID(url string) *string {
-	const prefix = "https://t.me/c/"
-	if strings.HasPrefix(url, prefix) {
-		rest := strings.TrimPrefix(url, prefix)
-		idPart := strings.SplitN(rest, "/", 2)[0]
-		if idPart != "" {
-			return &idPart
-		}
+	l, err := link.Parse(url)
+	if err != nil || l.Kind != link.KindPrivate {
+		return nil
 	}
-	return nil
+	id := strconv.FormatInt(l.ChannelID, 10)
+	return &id
 }
 
-// GetOrdersDefaultURLs возвращает список ссылок, от которых нельзя отписываться
-func (db *DB) GetOrdersDefaultURLs() ([]string, error) {
-	rows, err := db.Conn.Query(`SELECT url_default FROM orders`)
+// GetOrdersDefaultURLs возвращает список ссылок, от которых нельзя отписываться.
+// Каналы архивных заказов больше не обслуживаются, поэтому не учитываются.
+func (db *DB) GetOrdersDefaultURLs(ctx context.Context) ([]string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT url_default FROM orders WHERE status <> 'archived'`)
 	if err != nil {
 		return nil, err
 	}
@@ -47,10 +62,32 @@ func (db *DB) GetOrdersDefaultURLs() ([]string, error) {
 	return urls, nil
 }
 
-// GetOrdersForMonitoring возвращает заказы с их ссылками, ID каналов, фактическим числом аккаунтов и числом активной аудитории.
+// GetOrdersForMonitoring возвращает активные заказы с их ссылками, ID каналов, фактическим числом аккаунтов и числом активной аудитории.
 // Эти данные нужны мониторинговым аккаунтам для подписки на каналы и расчёта метрик постов.
-func (db *DB) GetOrdersForMonitoring() ([]models.Order, error) {
-	rows, err := db.Conn.Query(`SELECT id, url_default, channel_tgid, accounts_number_fact, subs_active_count FROM orders WHERE url_default <> ''`)
+func (db *DB) GetOrdersForMonitoring(ctx context.Context) ([]models.Order, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return db.queryOrdersForMonitoring(ctx, `status = 'active' AND url_default <> ''`)
+}
+
+// GetOrderForMonitoringByID возвращает один заказ в том же виде, что и GetOrdersForMonitoring.
+// Если заказа нет, он не активен или у него пустая ссылка, возвращается sql.ErrNoRows — мониторинг его не отслеживает.
+func (db *DB) GetOrderForMonitoringByID(ctx context.Context, id int) (*models.Order, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	orders, err := db.queryOrdersForMonitoring(ctx, `status = 'active' AND url_default <> '' AND id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &orders[0], nil
+}
+
+// queryOrdersForMonitoring выбирает поля заказов, нужные мониторингу, по произвольному условию.
+func (db *DB) queryOrdersForMonitoring(ctx context.Context, where string, args ...any) ([]models.Order, error) {
+	rows, err := db.Conn.QueryContext(ctx, `SELECT id, url_default, channel_tgid, accounts_number_fact, subs_active_count FROM orders WHERE `+where, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -79,8 +116,10 @@ func (db *DB) GetOrdersForMonitoring() ([]models.Order, error) {
 
 // GetOrdersWithoutChannelTGID возвращает заказы без заполненного channel_tgid
 // Используется перед обновлением описаний, чтобы знать, какие заказы требуют дополнения
-func (db *DB) GetOrdersWithoutChannelTGID() ([]models.Order, error) {
-	rows, err := db.Conn.Query(`SELECT id, url_default FROM orders WHERE channel_tgid IS NULL AND url_default <> ''`)
+func (db *DB) GetOrdersWithoutChannelTGID(ctx context.Context) ([]models.Order, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT id, url_default FROM orders WHERE channel_tgid IS NULL AND url_default <> '' AND status <> 'archived'`)
 	if err != nil {
 		return nil, err
 	}
@@ -102,14 +141,18 @@ func (db *DB) GetOrdersWithoutChannelTGID() ([]models.Order, error) {
 
 // SetOrderChannelTGID устанавливает значение channel_tgid для заказа
 // Вызывается после получения ID канала по ссылке
-func (db *DB) SetOrderChannelTGID(orderID int, channelTGID string) error {
-	_, err := db.Conn.Exec(`UPDATE orders SET channel_tgid = $1 WHERE id = $2`, channelTGID, orderID)
+func (db *DB) SetOrderChannelTGID(ctx context.Context, orderID int, channelTGID string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE orders SET channel_tgid = $1 WHERE id = $2`, channelTGID, orderID)
 	return err
 }
 
 // CreateOrder создаёт заказ и распределяет свободные аккаунты
-func (db *DB) CreateOrder(o models.Order) (*models.Order, error) {
-	tx, err := db.Conn.Begin()
+func (db *DB) CreateOrder(ctx context.Context, o models.Order) (*models.Order, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	tx, err := db.Conn.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +161,7 @@ func (db *DB) CreateOrder(o models.Order) (*models.Order, error) {
 	// Проверяем указанные категории и игнорируем неизвестные.
 	// Так заказ не привязывается к несуществующим категориям, но продолжает создаваться.
 	if len(o.Category) > 0 {
-		rows, err := tx.Query(`SELECT name FROM categories WHERE name = ANY($1)`, pq.Array(o.Category))
+		rows, err := tx.QueryContext(ctx, `SELECT name FROM categories WHERE name = ANY($1)`, pq.Array(o.Category))
 		if err != nil {
 			return nil, err
 		}
@@ -179,7 +222,7 @@ func (db *DB) CreateOrder(o models.Order) (*models.Order, error) {
 		query = `INSERT INTO orders (name, category, url_description, url_default, accounts_number_theory, gender, channel_tgid) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, accounts_number_fact, date_time, subs_active_count`
 		args = []any{o.Name, categoriesArg, o.URLDescription, o.URLDefault, o.AccountsNumberTheory, pq.Array(gender), channelTGID}
 	}
-	if err = tx.QueryRow(query, args...).Scan(&o.ID, &o.AccountsNumberFact, &o.DateTime, &subsActiveCount); err != nil {
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&o.ID, &o.AccountsNumberFact, &o.DateTime, &subsActiveCount); err != nil {
 		return nil, err
 	}
 	if subsActiveCount.Valid {
@@ -190,10 +233,12 @@ func (db *DB) CreateOrder(o models.Order) (*models.Order, error) {
 	}
 	o.Gender = gender
 	o.ChannelTGID = channelTGID
+	o.Status = models.OrderStatusActive
+	o.ArchivedAt = nil
 
 	// Выбираем свободные аккаунты, исключая мониторинговые,
 	// чтобы такие аккаунты не становились исполнителями заказов
-	rows, err := tx.Query(
+	rows, err := tx.QueryContext(ctx,
 		`SELECT id FROM accounts WHERE order_id IS NULL AND account_monitoring = FALSE AND account_generator_category = FALSE ORDER BY RANDOM() LIMIT $1`,
 		o.AccountsNumberTheory,
 	)
@@ -208,7 +253,7 @@ func (db *DB) CreateOrder(o models.Order) (*models.Order, error) {
 		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		if _, err := tx.Exec(`UPDATE accounts SET order_id = $1 WHERE id = $2`, o.ID, id); err != nil {
+		if _, err := tx.ExecContext(ctx, `UPDATE accounts SET order_id = $1 WHERE id = $2`, o.ID, id); err != nil {
 			return nil, err
 		}
 		count++
@@ -223,8 +268,10 @@ func (db *DB) CreateOrder(o models.Order) (*models.Order, error) {
 }
 
 // UpdateOrderAccountsNumber изменяет количество аккаунтов в заказе
-func (db *DB) UpdateOrderAccountsNumber(orderID, newNumber int) (*models.Order, error) {
-	tx, err := db.Conn.Begin()
+func (db *DB) UpdateOrderAccountsNumber(ctx context.Context, orderID, newNumber int) (*models.Order, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	tx, err := db.Conn.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -232,11 +279,11 @@ func (db *DB) UpdateOrderAccountsNumber(orderID, newNumber int) (*models.Order,
 
 	var o models.Order
 	var subsActiveCount sql.NullInt64
-	err = tx.QueryRow(
+	err = tx.QueryRowContext(ctx,
 		// Приводим gender к text[], иначе pq не сможет сканировать массив enum
-		`SELECT id, name, category, url_description, url_default, channel_tgid, accounts_number_theory, accounts_number_fact, subs_active_count, gender::text[], date_time FROM orders WHERE id = $1`,
+		`SELECT `+orderColumns+` FROM orders WHERE id = $1`,
 		orderID,
-	).Scan(&o.ID, &o.Name, &o.Category, &o.URLDescription, &o.URLDefault, &o.ChannelTGID, &o.AccountsNumberTheory, &o.AccountsNumberFact, &subsActiveCount, &o.Gender, &o.DateTime) // читаем текст, категории (pq.StringArray сканируется напрямую) и ссылку по умолчанию
+	).Scan(&o.ID, &o.Name, &o.Category, &o.URLDescription, &o.URLDefault, &o.ChannelTGID, &o.AccountsNumberTheory, &o.AccountsNumberFact, &subsActiveCount, &o.Gender, &o.Status, &o.ArchivedAt, &o.DateTime) // читаем текст, категории (pq.StringArray сканируется напрямую) и ссылку по умолчанию
 	if subsActiveCount.Valid {
 		val := int(subsActiveCount.Int64)
 		o.SubsActiveCount = &val
@@ -250,15 +297,28 @@ func (db *DB) UpdateOrderAccountsNumber(orderID, newNumber int) (*models.Order,
 		return nil, err
 	}
 
-	if _, err := tx.Exec(`UPDATE orders SET accounts_number_theory = $1 WHERE id = $2`, newNumber, orderID); err != nil {
+	if o.Status == models.OrderStatusArchived {
+		return nil, ErrOrderArchived
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET accounts_number_theory = $1 WHERE id = $2`, newNumber, orderID); err != nil {
 		return nil, err
 	}
 	o.AccountsNumberTheory = newNumber
 
+	// У приостановленного заказа меняется только желаемое количество: аккаунты
+	// распределятся после возобновления
+	if o.Status != models.OrderStatusActive {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return &o, nil
+	}
+
 	if newNumber > o.AccountsNumberFact {
 		// Добавляем недостающие аккаунты, игнорируя аккаунты под мониторингом
 		diff := newNumber - o.AccountsNumberFact
-		rows, err := tx.Query(`SELECT id FROM accounts WHERE order_id IS NULL AND account_monitoring = FALSE AND account_generator_category = FALSE ORDER BY RANDOM() LIMIT $1`, diff)
+		rows, err := tx.QueryContext(ctx, `SELECT id FROM accounts WHERE order_id IS NULL AND account_monitoring = FALSE AND account_generator_category = FALSE ORDER BY RANDOM() LIMIT $1`, diff)
 		if err != nil {
 			return nil, err
 		}
@@ -279,7 +339,7 @@ func (db *DB) UpdateOrderAccountsNumber(orderID, newNumber int) (*models.Order,
 		rows.Close()
 
 		for _, id := range ids {
-			if _, err := tx.Exec(`UPDATE accounts SET order_id = $1 WHERE id = $2`, orderID, id); err != nil {
+			if _, err := tx.ExecContext(ctx, `UPDATE accounts SET order_id = $1 WHERE id = $2`, orderID, id); err != nil {
 				return nil, err
 			}
 		}
@@ -287,7 +347,7 @@ func (db *DB) UpdateOrderAccountsNumber(orderID, newNumber int) (*models.Order,
 	} else if newNumber < o.AccountsNumberFact {
 		// Освобождаем лишние аккаунты
 		diff := o.AccountsNumberFact - newNumber
-		rows, err := tx.Query(`SELECT id FROM accounts WHERE order_id = $1 ORDER BY RANDOM() LIMIT $2`, orderID, diff)
+		rows, err := tx.QueryContext(ctx, `SELECT id FROM accounts WHERE order_id = $1 ORDER BY RANDOM() LIMIT $2`, orderID, diff)
 		if err != nil {
 			return nil, err
 		}
@@ -308,7 +368,7 @@ func (db *DB) UpdateOrderAccountsNumber(orderID, newNumber int) (*models.Order,
 		rows.Close()
 
 		for _, id := range ids {
-			if _, err := tx.Exec(`UPDATE accounts SET order_id = NULL WHERE id = $1`, id); err != nil {
+			if _, err := tx.ExecContext(ctx, `UPDATE accounts SET order_id = NULL WHERE id = $1`, id); err != nil {
 				return nil, err
 			}
 		}
@@ -323,14 +383,16 @@ func (db *DB) UpdateOrderAccountsNumber(orderID, newNumber int) (*models.Order,
 
 // GetOrderByID возвращает заказ по его идентификатору
 // Используется для получения ссылки при обновлении описаний аккаунтов
-func (db *DB) GetOrderByID(id int) (*models.Order, error) {
+func (db *DB) GetOrderByID(ctx context.Context, id int) (*models.Order, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var o models.Order
 	var subsActiveCount sql.NullInt64
-	err := db.Conn.QueryRow(
+	err := db.Conn.QueryRowContext(ctx,
 		// gender приводим к text[], чтобы избежать ошибок сканирования enum-массива
-		`SELECT id, name, category, url_description, url_default, channel_tgid, accounts_number_theory, accounts_number_fact, subs_active_count, gender::text[], date_time FROM orders WHERE id = $1`,
+		`SELECT `+orderColumns+` FROM orders WHERE id = $1`,
 		id,
-	).Scan(&o.ID, &o.Name, &o.Category, &o.URLDescription, &o.URLDefault, &o.ChannelTGID, &o.AccountsNumberTheory, &o.AccountsNumberFact, &subsActiveCount, &o.Gender, &o.DateTime) // читаем текст, категории (pq.StringArray сканируется напрямую) и ссылку по умолчанию
+	).Scan(&o.ID, &o.Name, &o.Category, &o.URLDescription, &o.URLDefault, &o.ChannelTGID, &o.AccountsNumberTheory, &o.AccountsNumberFact, &subsActiveCount, &o.Gender, &o.Status, &o.ArchivedAt, &o.DateTime) // читаем текст, категории (pq.StringArray сканируется напрямую) и ссылку по умолчанию
 	if subsActiveCount.Valid {
 		val := int(subsActiveCount.Int64)
 		o.SubsActiveCount = &val
@@ -343,188 +405,116 @@ func (db *DB) GetOrderByID(id int) (*models.Order, error) {
 	return &o, nil
 }
 
+// Запросы AssignFreeAccountsToOrders. Каждый обрабатывает все активные заказы сразу,
+// поэтому число обращений к БД не зависит от количества заказов и аккаунтов.
+const (
+	// Освобождаем аккаунты, чей пол не соответствует требованиям заказа
+	releaseMismatchedGenderQuery = `
+UPDATE accounts a SET order_id = NULL
+FROM orders o
+WHERE a.order_id = o.id AND o.status = 'active' AND NOT (a.gender && o.gender)`
+
+	// Освобождаем случайные лишние аккаунты сверх accounts_number_theory
+	releaseExcessAccountsQuery = `
+UPDATE accounts a SET order_id = NULL
+FROM (
+    SELECT acc.id, o.accounts_number_theory AS theory,
+           row_number() OVER (PARTITION BY acc.order_id ORDER BY random()) AS rn
+    FROM accounts acc
+    JOIN orders o ON o.id = acc.order_id
+    WHERE o.status = 'active'
+) r
+WHERE a.id = r.id AND r.rn > r.theory`
+
+	// Раздаём заказам с нехваткой случайные свободные аккаунты подходящего пола.
+	// Недостающие места заказов нумеруются внутри группы с одинаковым набором полов,
+	// подходящие группе аккаунты — в случайном порядке, и k-е место получает k-й аккаунт.
+	// Так объём работы растёт с числом аккаунтов, а не с произведением заказов на аккаунты.
+	// Аккаунт может подойти нескольким группам — DISTINCT ON отдаёт его одному заказу,
+	// а недобор остальных закрывается следующим выполнением запроса.
+	assignFreeAccountsQuery = `
+WITH need AS (
+    SELECT o.id, o.gender, o.accounts_number_theory - COUNT(a.id) AS need
+    FROM orders o
+    LEFT JOIN accounts a ON a.order_id = o.id
+    WHERE o.status = 'active'
+    GROUP BY o.id
+    HAVING o.accounts_number_theory > COUNT(a.id)
+), slots AS (
+    SELECT n.id AS order_id, n.gender,
+           row_number() OVER (PARTITION BY n.gender ORDER BY n.id, s.i) AS k
+    FROM need n, generate_series(1, n.need) AS s(i)
+), candidates AS (
+    SELECT g.gender, f.id AS account_id,
+           row_number() OVER (PARTITION BY g.gender ORDER BY random()) AS k
+    FROM (SELECT DISTINCT gender FROM need) g
+    JOIN accounts f ON f.order_id IS NULL
+        AND f.is_authorized = TRUE
+        AND f.account_monitoring = FALSE
+        AND f.account_generator_category = FALSE
+        AND f.gender && g.gender
+), picked AS (
+    SELECT DISTINCT ON (c.account_id) c.account_id, s.order_id
+    FROM slots s
+    JOIN candidates c ON c.gender = s.gender AND c.k = s.k
+    ORDER BY c.account_id, s.order_id
+)
+UPDATE accounts a SET order_id = p.order_id
+FROM picked p
+WHERE a.id = p.account_id AND a.order_id IS NULL`
+
+	// Записываем фактическое количество аккаунтов там, где оно разошлось
+	syncAccountsNumberFactQuery = `
+UPDATE orders o SET accounts_number_fact = c.actual
+FROM (
+    SELECT o2.id, COUNT(a.id) AS actual
+    FROM orders o2
+    LEFT JOIN accounts a ON a.order_id = o2.id
+    WHERE o2.status = 'active'
+    GROUP BY o2.id
+) c
+WHERE o.id = c.id AND o.accounts_number_fact <> c.actual`
+)
+
 // AssignFreeAccountsToOrders синхронизирует аккаунты в заказах согласно требуемому количеству.
-// Для каждого заказа добавляются недостающие аккаунты или освобождаются лишние.
-func (db *DB) AssignFreeAccountsToOrders() error {
-	tx, err := db.Conn.Begin()
+// Для каждого активного заказа освобождаются аккаунты неподходящего пола и лишние,
+// добавляются недостающие, после чего обновляется accounts_number_fact.
+// Приостановленные и архивные заказы не трогаем.
+func (db *DB) AssignFreeAccountsToOrders(ctx context.Context) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	tx, err := db.Conn.BeginTx(ctx, nil)
 	if err != nil {
 		log.Printf("[DB ERROR] начало транзакции: %v", err)
 		return err
 	}
 	defer tx.Rollback()
 
-	// Получаем заказы; gender приводим к text[], чтобы pq корректно прочитал массив
-	rows, err := tx.Query(`SELECT id, accounts_number_theory, accounts_number_fact, gender::text[] FROM orders`)
-	if err != nil {
-		log.Printf("[DB ERROR] выборка заказов: %v", err)
+	if _, err := tx.ExecContext(ctx, releaseMismatchedGenderQuery); err != nil {
+		log.Printf("[DB ERROR] освобождение аккаунтов с неподходящим полом: %v", err)
 		return err
 	}
-	defer rows.Close()
-
-	type orderData struct {
-		id     int
-		theory int
-		fact   int
-		gender pq.StringArray
-	}
-	var orders []orderData
-
-	// Считываем все заказы из курсора
-	for rows.Next() {
-		var o orderData
-		if err := rows.Scan(&o.id, &o.theory, &o.fact, &o.gender); err != nil {
-			log.Printf("[DB ERROR] чтение заказа: %v", err)
-			return err
-		}
-		orders = append(orders, o)
-	}
-	if err := rows.Err(); err != nil {
-		log.Printf("[DB ERROR] курсор заказов: %v", err)
+	if _, err := tx.ExecContext(ctx, releaseExcessAccountsQuery); err != nil {
+		log.Printf("[DB ERROR] освобождение лишних аккаунтов: %v", err)
 		return err
 	}
-	// Закрываем курсор до выполнения дальнейших запросов, чтобы освободить соединение
-	rows.Close()
-
-	// Обрабатываем каждый заказ по отдельности
-	for _, o := range orders {
-		orderID := o.id
-		theory := o.theory
-		fact := o.fact
-		genders := o.gender
-
-		// Сначала освобождаем аккаунты, чей пол не соответствует требованиям заказа
-		misRows, err := tx.Query(
-			`SELECT id FROM accounts WHERE order_id = $1 AND NOT (gender && $2::gender_enum[])`,
-			orderID, pq.Array(genders),
-		)
+	// Каждый проход назначает хотя бы один аккаунт, пока есть кому и кого назначать
+	for {
+		res, err := tx.ExecContext(ctx, assignFreeAccountsQuery)
 		if err != nil {
-			log.Printf("[DB ERROR] выборка аккаунтов с неподходящим полом для заказа %d: %v", orderID, err)
+			log.Printf("[DB ERROR] назначение свободных аккаунтов: %v", err)
 			return err
 		}
-		var misIDs []int
-		for misRows.Next() {
-			var accID int
-			if err := misRows.Scan(&accID); err != nil {
-				misRows.Close()
-				log.Printf("[DB ERROR] чтение аккаунта для очистки заказа %d: %v", orderID, err)
-				return err
-			}
-			misIDs = append(misIDs, accID)
-		}
-		if err := misRows.Err(); err != nil {
-			misRows.Close()
-			log.Printf("[DB ERROR] курсор неподходящих аккаунтов для заказа %d: %v", orderID, err)
+		n, err := res.RowsAffected()
+		if err != nil {
 			return err
 		}
-		misRows.Close()
-		for _, accID := range misIDs {
-			if _, err := tx.Exec(`UPDATE accounts SET order_id = NULL WHERE id = $1`, accID); err != nil {
-				log.Printf("[DB ERROR] освобождение аккаунта %d для заказа %d: %v", accID, orderID, err)
-				return err
-			}
-		}
-
-		// Считаем реальное количество аккаунтов, закреплённых за заказом, после очистки
-		var actual int
-		if err := tx.QueryRow(`SELECT COUNT(*) FROM accounts WHERE order_id = $1`, orderID).Scan(&actual); err != nil {
-			log.Printf("[DB ERROR] подсчёт аккаунтов заказа %d: %v", orderID, err)
-			return err
-		}
-
-		// Если сохранённое значение не совпадает с реальным, обновляем accounts_number_fact
-		if fact != actual {
-			if _, err := tx.Exec(`UPDATE orders SET accounts_number_fact = $1 WHERE id = $2`, actual, orderID); err != nil {
-				log.Printf("[DB ERROR] обновление фактического количества для заказа %d: %v", orderID, err)
-				return err
-			}
-			fact = actual
-		}
-
-		// Если желаемое количество не совпадает с фактическим, корректируем аккаунты
-		if theory != actual {
-			if theory > actual {
-				// Нужно добавить недостающие аккаунты
-				need := theory - actual
-				accRows, err := tx.Query(
-					`SELECT id FROM accounts WHERE order_id IS NULL AND is_authorized = TRUE AND account_monitoring = FALSE AND account_generator_category = FALSE AND gender && $1::gender_enum[] ORDER BY RANDOM() LIMIT $2`,
-					pq.Array(genders), need,
-				)
-				if err != nil {
-					log.Printf("[DB ERROR] выборка аккаунтов для заказа %d: %v", orderID, err)
-					return err
-				}
-
-				var accIDs []int
-				for accRows.Next() {
-					var accID int
-					if err := accRows.Scan(&accID); err != nil {
-						accRows.Close()
-						log.Printf("[DB ERROR] чтение аккаунта для заказа %d: %v", orderID, err)
-						return err
-					}
-					accIDs = append(accIDs, accID)
-				}
-				if err := accRows.Err(); err != nil {
-					accRows.Close()
-					log.Printf("[DB ERROR] курсор аккаунтов для заказа %d: %v", orderID, err)
-					return err
-				}
-				accRows.Close()
-
-				for _, accID := range accIDs {
-					if _, err := tx.Exec(`UPDATE accounts SET order_id = $1 WHERE id = $2`, orderID, accID); err != nil {
-						log.Printf("[DB ERROR] обновление аккаунта %d: %v", accID, err)
-						return err
-					}
-				}
-				actual += len(accIDs)
-			} else {
-				// Нужно освободить лишние аккаунты
-				needRelease := actual - theory
-				accRows, err := tx.Query(
-					`SELECT id FROM accounts WHERE order_id = $1 ORDER BY RANDOM() LIMIT $2`,
-					orderID, needRelease,
-				)
-				if err != nil {
-					log.Printf("[DB ERROR] выборка аккаунтов для освобождения заказа %d: %v", orderID, err)
-					return err
-				}
-
-				var accIDs []int
-				for accRows.Next() {
-					var accID int
-					if err := accRows.Scan(&accID); err != nil {
-						accRows.Close()
-						log.Printf("[DB ERROR] чтение аккаунта для освобождения заказа %d: %v", orderID, err)
-						return err
-					}
-					accIDs = append(accIDs, accID)
-				}
-				if err := accRows.Err(); err != nil {
-					accRows.Close()
-					log.Printf("[DB ERROR] курсор аккаунтов для освобождения заказа %d: %v", orderID, err)
-					return err
-				}
-				accRows.Close()
-
-				for _, accID := range accIDs {
-					if _, err := tx.Exec(`UPDATE accounts SET order_id = NULL WHERE id = $1`, accID); err != nil {
-						log.Printf("[DB ERROR] обновление аккаунта %d: %v", accID, err)
-						return err
-					}
-				}
-				actual -= len(accIDs)
-			}
-
-			// После изменений фиксируем фактическое количество аккаунтов
-			if _, err := tx.Exec(`UPDATE orders SET accounts_number_fact = $1 WHERE id = $2`, actual, orderID); err != nil {
-				log.Printf("[DB ERROR] обновление фактического количества для заказа %d: %v", orderID, err)
-				return err
-			}
+		if n == 0 {
+			break
 		}
 	}
-	if err := rows.Err(); err != nil {
-		log.Printf("[DB ERROR] курсор заказов: %v", err)
+	if _, err := tx.ExecContext(ctx, syncAccountsNumberFactQuery); err != nil {
+		log.Printf("[DB ERROR] обновление фактического количества аккаунтов: %v", err)
 		return err
 	}
 
@@ -535,11 +525,109 @@ func (db *DB) AssignFreeAccountsToOrders() error {
 	return nil
 }
 
-// DeleteOrder удаляет заказ по идентификатору
-// При удалении благодаря ON DELETE SET NULL у связанных аккаунтов очищается поле order_id
-func (db *DB) DeleteOrder(id int) error {
-	if _, err := db.Conn.Exec(`DELETE FROM orders WHERE id = $1`, id); err != nil {
-		return err
+// OrderFilter задаёт условия выборки заказов для API.
+type OrderFilter struct {
+	Status string
+	Limit  int
+}
+
+// ListOrders возвращает заказы по фильтру, новые — первыми.
+func (db *DB) ListOrders(ctx context.Context, f OrderFilter) ([]models.Order, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var (
+		where []string
+		args  []any
+	)
+	if f.Status != "" {
+		args = append(args, f.Status)
+		where = append(where, fmt.Sprintf("status = $%d", len(args)))
 	}
-	return nil
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `SELECT ` + orderColumns + ` FROM orders`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := db.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		var o models.Order
+		var subsActiveCount sql.NullInt64
+		if err := rows.Scan(&o.ID, &o.Name, &o.Category, &o.URLDescription, &o.URLDefault, &o.ChannelTGID, &o.AccountsNumberTheory, &o.AccountsNumberFact, &subsActiveCount, &o.Gender, &o.Status, &o.ArchivedAt, &o.DateTime); err != nil {
+			return nil, err
+		}
+		if subsActiveCount.Valid {
+			val := int(subsActiveCount.Int64)
+			o.SubsActiveCount = &val
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// SetOrderStatus переводит заказ в новый статус.
+// При архивировании аккаунты заказа освобождаются (счётчик обновляет триггер),
+// а посты, дублирование и отчёты остаются. При паузе и архивировании
+// невыполненные запланированные действия заказа отменяются. Из архива заказ можно вернуть,
+// если его ссылку не занял другой заказ, — иначе возвращается ErrOrderURLTaken.
+// Возвращает sql.ErrNoRows, если заказа нет.
+func (db *DB) SetOrderStatus(ctx context.Context, id int, status string) (*models.Order, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `UPDATE orders
+        SET status = $2::order_status,
+            archived_at = CASE WHEN $2 = 'archived' THEN COALESCE(archived_at, NOW()) END
+        WHERE id = $1`, id, status)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, ErrOrderURLTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status == models.OrderStatusArchived {
+		if _, err := tx.ExecContext(ctx, `UPDATE accounts SET order_id = NULL WHERE order_id = $1`, id); err != nil {
+			return nil, err
+		}
+	}
+	// Заказ больше не удаляется, поэтому запланированные просмотры и реакции отменяем явно
+	if status != models.OrderStatusActive {
+		if _, err := tx.ExecContext(ctx, cancelOrderActionsQuery, id); err != nil {
+			return nil, err
+		}
+	}
+
+	var o models.Order
+	var subsActiveCount sql.NullInt64
+	err = tx.QueryRowContext(ctx, `SELECT `+orderColumns+` FROM orders WHERE id = $1`, id).
+		Scan(&o.ID, &o.Name, &o.Category, &o.URLDescription, &o.URLDefault, &o.ChannelTGID, &o.AccountsNumberTheory, &o.AccountsNumberFact, &subsActiveCount, &o.Gender, &o.Status, &o.ArchivedAt, &o.DateTime)
+	if err != nil {
+		return nil, err
+	}
+	if subsActiveCount.Valid {
+		val := int(subsActiveCount.Int64)
+		o.SubsActiveCount = &val
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &o, nil
 }
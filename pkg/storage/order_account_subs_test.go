@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"strings"
 	"testing"
@@ -16,10 +17,10 @@ func TestAddOrderAccountSubDuplicate(t *testing.T) {
 	}
 	storageDB := &DB{Conn: db}
 
-	if err := storageDB.AddOrderAccountSub(1, 2); err != nil {
+	if err := storageDB.AddOrderAccountSub(context.Background(), 1, 2); err != nil {
 		t.Fatalf("первая вставка завершилась ошибкой: %v", err)
 	}
-	if err := storageDB.AddOrderAccountSub(1, 2); err != nil {
+	if err := storageDB.AddOrderAccountSub(context.Background(), 1, 2); err != nil {
 		t.Fatalf("повторная вставка завершилась ошибкой: %v", err)
 	}
 	if len(executedQueries) != 2 {
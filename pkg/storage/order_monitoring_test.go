@@ -74,7 +74,7 @@ func TestGetOrdersForMonitoringSubsActiveCount(t *testing.T) {
 	defer func() { _ = db.Close() }()
 	storageDB := &DB{Conn: db}
 
-	orders, err := storageDB.GetOrdersForMonitoring()
+	orders, err := storageDB.GetOrdersForMonitoring(context.Background())
 	if err != nil {
 		t.Fatalf("запрос заказов завершился ошибкой: %v", err)
 	}
@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
@@ -116,7 +117,7 @@ func TestCreateOrderIgnoresUnknownCategories(t *testing.T) {
 		AccountsNumberTheory: 0,
 		Gender:               pq.StringArray{"male"},
 	}
-	created, err := storageDB.CreateOrder(o)
+	created, err := storageDB.CreateOrder(context.Background(), o)
 	if err != nil {
 		t.Fatalf("создание заказа завершилось ошибкой: %v", err)
 	}
@@ -127,3 +128,456 @@ func TestCreateOrderIgnoresUnknownCategories(t *testing.T) {
 		t.Fatalf("в логах отсутствует предупреждение о неизвестной категории: %s", buf.String())
 	}
 }
+
+// TestListOrdersFilter проверяет фильтр по статусу и лимит по умолчанию.
+// Используется мок-драйвер из scheduled_action_test.go: он запоминает последний запрос.
+func TestListOrdersFilter(t *testing.T) {
+	conn, err := sql.Open("scheduledDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	db := &DB{Conn: conn}
+
+	if _, err := db.ListOrders(context.Background(), OrderFilter{Status: models.OrderStatusPaused}); err != nil {
+		t.Fatalf("выборка завершилась ошибкой: %v", err)
+	}
+	if !strings.Contains(scheduledLastQuery, "FROM orders WHERE status = $1 ORDER BY id DESC LIMIT $2") {
+		t.Fatalf("неожиданный запрос: %s", scheduledLastQuery)
+	}
+	if len(scheduledLastArgs) != 2 || scheduledLastArgs[0] != "paused" || scheduledLastArgs[1] != int64(100) {
+		t.Fatalf("неожиданные аргументы: %v", scheduledLastArgs)
+	}
+
+	if _, err := db.ListOrders(context.Background(), OrderFilter{}); err != nil {
+		t.Fatalf("выборка без фильтра завершилась ошибкой: %v", err)
+	}
+	if strings.Contains(scheduledLastQuery, "WHERE") {
+		t.Fatalf("без фильтра WHERE не нужен: %s", scheduledLastQuery)
+	}
+}
+
+// assignTestDriver запоминает запросы Exec распределения аккаунтов.
+// Запрос назначения первые assignRounds раз сообщает об изменённых строках, затем — ни об одной.
+type assignTestDriver struct{}
+
+type assignTestConn struct{}
+
+type assignTestTx struct{}
+
+type assignTestResult int64
+
+var (
+	assignExecs     []string
+	assignRounds    int
+	assignCommitted bool
+)
+
+func (assignTestDriver) Open(name string) (driver.Conn, error) { return &assignTestConn{}, nil }
+
+func (c *assignTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *assignTestConn) Close() error              { return nil }
+func (c *assignTestConn) Begin() (driver.Tx, error) { return assignTestTx{}, nil }
+
+func (c *assignTestConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	assignExecs = append(assignExecs, query)
+	if query == assignFreeAccountsQuery && assignRounds > 0 {
+		assignRounds--
+		return assignTestResult(3), nil
+	}
+	return assignTestResult(0), nil
+}
+
+func (assignTestTx) Commit() error   { assignCommitted = true; return nil }
+func (assignTestTx) Rollback() error { return nil }
+
+func (r assignTestResult) LastInsertId() (int64, error) { return 0, nil }
+func (r assignTestResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func init() { sql.Register("assignDummy", assignTestDriver{}) }
+
+// orderStatusTestDriver запоминает запросы Exec смены статуса заказа
+// и возвращает заказ в запрошенном статусе.
+type orderStatusTestDriver struct{}
+
+type orderStatusTestConn struct{}
+
+var (
+	orderStatusExecs []string
+	orderStatusValue string
+)
+
+func (orderStatusTestDriver) Open(name string) (driver.Conn, error) {
+	return &orderStatusTestConn{}, nil
+}
+
+func (c *orderStatusTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *orderStatusTestConn) Close() error              { return nil }
+func (c *orderStatusTestConn) Begin() (driver.Tx, error) { return orderTestTx{}, nil }
+
+func (c *orderStatusTestConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	orderStatusExecs = append(orderStatusExecs, query)
+	return orderDummyResult{}, nil
+}
+
+func (c *orderStatusTestConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &orderTestRows{
+		columns: strings.Split(strings.ReplaceAll(orderColumns, " ", ""), ","),
+		data: [][]driver.Value{{int64(5), "заказ", "{}", "desc", "https://t.me/test", nil, int64(1), int64(0), nil,
+			"{male}", orderStatusValue, nil, time.Now()}},
+	}, nil
+}
+
+func init() { sql.Register("orderStatusDummy", orderStatusTestDriver{}) }
+
+// TestSetOrderStatusCancelsScheduledActions проверяет, что пауза и архивирование
+// отменяют запланированные действия заказа в той же транзакции, а возобновление — нет.
+func TestSetOrderStatusCancelsScheduledActions(t *testing.T) {
+	conn, err := sql.Open("orderStatusDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	db := &DB{Conn: conn}
+
+	for _, tc := range []struct {
+		status string
+		cancel bool
+	}{
+		{models.OrderStatusPaused, true},
+		{models.OrderStatusArchived, true},
+		{models.OrderStatusActive, false},
+	} {
+		orderStatusExecs, orderStatusValue = nil, tc.status
+		o, err := db.SetOrderStatus(context.Background(), 5, tc.status)
+		if err != nil {
+			t.Fatalf("%s: смена статуса завершилась ошибкой: %v", tc.status, err)
+		}
+		if o.Status != tc.status {
+			t.Fatalf("%s: прочитан статус %s", tc.status, o.Status)
+		}
+		cancelled := false
+		for _, q := range orderStatusExecs {
+			if q == cancelOrderActionsQuery {
+				cancelled = true
+			}
+		}
+		if cancelled != tc.cancel {
+			t.Fatalf("%s: отмена действий = %v, ожидалось %v; запросы: %v", tc.status, cancelled, tc.cancel, orderStatusExecs)
+		}
+	}
+}
+
+// TestAssignFreeAccountsToOrdersSetBased проверяет, что распределение выполняется
+// фиксированным набором запросов по всем активным заказам, а назначение повторяется,
+// пока назначает хотя бы один аккаунт.
+func TestAssignFreeAccountsToOrdersSetBased(t *testing.T) {
+	conn, err := sql.Open("assignDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	db := &DB{Conn: conn}
+
+	assignExecs, assignRounds, assignCommitted = nil, 2, false
+	if err := db.AssignFreeAccountsToOrders(context.Background()); err != nil {
+		t.Fatalf("распределение завершилось ошибкой: %v", err)
+	}
+
+	want := []string{
+		releaseMismatchedGenderQuery,
+		releaseExcessAccountsQuery,
+		assignFreeAccountsQuery, assignFreeAccountsQuery, assignFreeAccountsQuery,
+		syncAccountsNumberFactQuery,
+	}
+	if len(assignExecs) != len(want) {
+		t.Fatalf("ожидалось %d запросов, выполнено %d", len(want), len(assignExecs))
+	}
+	for i := range want {
+		if assignExecs[i] != want[i] {
+			t.Fatalf("запрос %d выполнен не по порядку: %s", i, assignExecs[i])
+		}
+		if !strings.Contains(assignExecs[i], "status = 'active'") {
+			t.Fatalf("запрос %d должен затрагивать только активные заказы: %s", i, assignExecs[i])
+		}
+	}
+	if !assignCommitted {
+		t.Fatal("транзакция не зафиксирована")
+	}
+
+	// Свободные аккаунты отбираются с теми же исключениями, что и раньше
+	for _, cond := range []string{"f.order_id IS NULL", "f.is_authorized = TRUE", "f.account_monitoring = FALSE", "f.account_generator_category = FALSE", "f.gender && g.gender"} {
+		if !strings.Contains(assignFreeAccountsQuery, cond) {
+			t.Fatalf("в выборке свободных аккаунтов нет условия %q", cond)
+		}
+	}
+}
+
+// openBenchDB подключается к отдельной пустой базе из ATG_BENCH_DATABASE_URL
+// и применяет к ней миграции. Без переменной тест или бенчмарк пропускается.
+func openBenchDB(tb testing.TB) (*sql.DB, *DB) {
+	tb.Helper()
+	dsn := os.Getenv("ATG_BENCH_DATABASE_URL")
+	if dsn == "" {
+		tb.Skip("ATG_BENCH_DATABASE_URL не задан")
+	}
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		tb.Fatalf("подключение: %v", err)
+	}
+	tb.Cleanup(func() { conn.Close() })
+	db := NewDB(conn)
+	db.QueryTimeout = 0
+	if _, err := db.MigrateUp(context.Background()); err != nil {
+		tb.Fatalf("миграции: %v", err)
+	}
+	return conn, db
+}
+
+// cleanupBenchData удаляет заказы и аккаунты с префиксом bench-.
+func cleanupBenchData(tb testing.TB, conn *sql.DB) {
+	tb.Helper()
+	if _, err := conn.Exec(`DELETE FROM accounts WHERE phone LIKE 'bench-%'`); err != nil {
+		tb.Fatalf("очистка аккаунтов: %v", err)
+	}
+	if _, err := conn.Exec(`DELETE FROM orders WHERE name LIKE 'bench-%'`); err != nil {
+		tb.Fatalf("очистка заказов: %v", err)
+	}
+}
+
+// seedBenchData создаёт заказы и аккаунты для бенчмарков распределения:
+// каждому заказу нужна половина его доли аккаунтов, треть заказов — только male.
+func seedBenchData(tb testing.TB, conn *sql.DB, orders, accounts int) {
+	tb.Helper()
+	if _, err := conn.Exec(`
+        INSERT INTO orders (name, url_description, url_default, accounts_number_theory, gender)
+        SELECT 'bench-' || g, 'bench', 'https://t.me/bench_' || g, $2,
+               CASE WHEN g % 3 = 0 THEN ARRAY['male']::gender_enum[] ELSE ARRAY['female', 'neutral']::gender_enum[] END
+        FROM generate_series(1, $1) g`, orders, accounts/orders/2); err != nil {
+		tb.Fatalf("заказы: %v", err)
+	}
+	if _, err := conn.Exec(`
+        INSERT INTO accounts (phone, api_id, api_hash, is_authorized, gender)
+        SELECT 'bench-' || g, 1, 'bench', TRUE,
+               CASE WHEN g % 2 = 0 THEN ARRAY['male']::gender_enum[] ELSE ARRAY['female']::gender_enum[] END
+        FROM generate_series(1, $1) g`, accounts); err != nil {
+		tb.Fatalf("аккаунты: %v", err)
+	}
+}
+
+// benchSizes — размеры данных, на которых сравниваются реализации распределения.
+var benchSizes = []struct{ orders, accounts int }{{10, 1000}, {100, 10000}, {500, 50000}}
+
+// benchmarkAssign измеряет assign на одном наборе данных для каждого размера,
+// сбрасывая назначения перед каждой итерацией.
+func benchmarkAssign(b *testing.B, assign func(ctx context.Context, db *DB) error) {
+	conn, db := openBenchDB(b)
+	ctx := context.Background()
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("orders=%d/accounts=%d", size.orders, size.accounts), func(b *testing.B) {
+			cleanupBenchData(b, conn)
+			defer cleanupBenchData(b, conn)
+			seedBenchData(b, conn, size.orders, size.accounts)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if _, err := conn.Exec(`UPDATE accounts SET order_id = NULL WHERE phone LIKE 'bench-%'`); err != nil {
+					b.Fatalf("сброс назначений: %v", err)
+				}
+				b.StartTimer()
+				if err := assign(ctx, db); err != nil {
+					b.Fatalf("распределение: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkAssignFreeAccountsToOrders измеряет распределение на реальной PostgreSQL.
+// Нужна отдельная пустая база в ATG_BENCH_DATABASE_URL: миграции применяются к ней,
+// а заказы и аккаунты с префиксом bench- создаются и удаляются бенчмарком.
+func BenchmarkAssignFreeAccountsToOrders(b *testing.B) {
+	benchmarkAssign(b, func(ctx context.Context, db *DB) error {
+		return db.AssignFreeAccountsToOrders(ctx)
+	})
+}
+
+// BenchmarkAssignFreeAccountsToOrdersLegacy — базовая линия: прежняя реализация
+// с несколькими запросами на каждый заказ и аккаунт на тех же данных.
+func BenchmarkAssignFreeAccountsToOrdersLegacy(b *testing.B) {
+	benchmarkAssign(b, legacyAssignFreeAccountsToOrders)
+}
+
+// legacyAssignFreeAccountsToOrders повторяет прежний обход заказов по одному.
+// Оставлен только для сравнения в бенчмарке, поэтому без журналирования.
+func legacyAssignFreeAccountsToOrders(ctx context.Context, db *DB) error {
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	type orderData struct {
+		id     int
+		theory int
+		gender pq.StringArray
+	}
+	var orders []orderData
+	rows, err := tx.QueryContext(ctx, `SELECT id, accounts_number_theory, gender::text[] FROM orders WHERE status = 'active'`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var o orderData
+		if err := rows.Scan(&o.id, &o.theory, &o.gender); err != nil {
+			rows.Close()
+			return err
+		}
+		orders = append(orders, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// selectIDs читает идентификаторы аккаунтов из выборки
+	selectIDs := func(query string, args ...any) ([]int, error) {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, rows.Err()
+	}
+
+	for _, o := range orders {
+		misIDs, err := selectIDs(`SELECT id FROM accounts WHERE order_id = $1 AND NOT (gender && $2::gender_enum[])`, o.id, pq.Array(o.gender))
+		if err != nil {
+			return err
+		}
+		for _, id := range misIDs {
+			if _, err := tx.ExecContext(ctx, `UPDATE accounts SET order_id = NULL WHERE id = $1`, id); err != nil {
+				return err
+			}
+		}
+		var actual int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM accounts WHERE order_id = $1`, o.id).Scan(&actual); err != nil {
+			return err
+		}
+		var (
+			ids     []int
+			orderID any
+		)
+		switch {
+		case o.theory > actual:
+			ids, err = selectIDs(`SELECT id FROM accounts WHERE order_id IS NULL AND is_authorized = TRUE AND account_monitoring = FALSE AND account_generator_category = FALSE AND gender && $1::gender_enum[] ORDER BY RANDOM() LIMIT $2`, pq.Array(o.gender), o.theory-actual)
+			orderID = o.id
+			actual += len(ids)
+		case o.theory < actual:
+			ids, err = selectIDs(`SELECT id FROM accounts WHERE order_id = $1 ORDER BY RANDOM() LIMIT $2`, o.id, actual-o.theory)
+			actual -= len(ids)
+		}
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if _, err := tx.ExecContext(ctx, `UPDATE accounts SET order_id = $1 WHERE id = $2`, orderID, id); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE orders SET accounts_number_fact = $1 WHERE id = $2`, actual, o.id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// TestAssignFreeAccountsToOrdersPostgres проверяет поведение распределения на реальной PostgreSQL
+// (ATG_BENCH_DATABASE_URL): освобождение аккаунтов неподходящего пола и лишних,
+// приоритет более ранних заказов при нехватке аккаунтов и неизменность приостановленных заказов.
+func TestAssignFreeAccountsToOrdersPostgres(t *testing.T) {
+	conn, db := openBenchDB(t)
+	cleanupBenchData(t, conn)
+	t.Cleanup(func() { cleanupBenchData(t, conn) })
+
+	// addOrder создаёт заказ и возвращает его ID
+	addOrder := func(name string, theory int, gender, status string) int {
+		var id int
+		if err := conn.QueryRow(`INSERT INTO orders (name, url_description, url_default, accounts_number_theory, gender, status)
+            VALUES ($1, 'bench', 'https://t.me/' || $1, $2, $3::gender_enum[], $4) RETURNING id`, name, theory, gender, status).Scan(&id); err != nil {
+			t.Fatalf("заказ %s: %v", name, err)
+		}
+		return id
+	}
+	// addAccounts создаёт n авторизованных аккаунтов пола gender, закреплённых за orderID (0 — свободные)
+	addAccounts := func(prefix string, n int, gender string, orderID int) {
+		if _, err := conn.Exec(`INSERT INTO accounts (phone, api_id, api_hash, is_authorized, gender, order_id)
+            SELECT 'bench-' || $1 || '-' || g, 1, 'bench', TRUE, $2::gender_enum[], NULLIF($3, 0)
+            FROM generate_series(1, $4) g`, prefix, gender, orderID, n); err != nil {
+			t.Fatalf("аккаунты %s: %v", prefix, err)
+		}
+	}
+	// assigned возвращает число аккаунтов заказа, из них — пола gender
+	assigned := func(orderID int, gender string) (total, matching int) {
+		if err := conn.QueryRow(`SELECT COUNT(*), COUNT(*) FILTER (WHERE gender && $2::gender_enum[]) FROM accounts WHERE order_id = $1`, orderID, gender).Scan(&total, &matching); err != nil {
+			t.Fatalf("подсчёт аккаунтов заказа %d: %v", orderID, err)
+		}
+		return total, matching
+	}
+
+	// Заказу только для male назначены female-аккаунты — их нужно освободить и добрать male
+	mismatch := addOrder("bench-mismatch", 2, "{male}", "active")
+	addAccounts("mismatch", 2, "{female}", mismatch)
+	// Лишние аккаунты сверх accounts_number_theory освобождаются
+	excess := addOrder("bench-excess", 1, "{female}", "active")
+	addAccounts("excess", 3, "{female}", excess)
+	// При нехватке male-аккаунтов сначала заполняется более ранний заказ
+	first := addOrder("bench-first", 3, "{male}", "active")
+	second := addOrder("bench-second", 3, "{male}", "active")
+	// Приостановленный заказ не трогаем, даже если у него лишние аккаунты
+	paused := addOrder("bench-paused", 1, "{female}", "paused")
+	addAccounts("paused", 2, "{female}", paused)
+	// Свободно 6 male: 2 уходят заказу mismatch, 3 — first и 1 — second
+	addAccounts("free", 6, "{male}", 0)
+
+	if err := db.AssignFreeAccountsToOrders(context.Background()); err != nil {
+		t.Fatalf("распределение: %v", err)
+	}
+
+	if total, matching := assigned(mismatch, "{male}"); total != 2 || matching != 2 {
+		t.Fatalf("заказ mismatch: %d аккаунтов, подходящих %d; ожидалось 2 и 2", total, matching)
+	}
+	if total, _ := assigned(excess, "{female}"); total != 1 {
+		t.Fatalf("заказ excess: %d аккаунтов, ожидался 1", total)
+	}
+	if total, _ := assigned(first, "{male}"); total != 3 {
+		t.Fatalf("заказ first: %d аккаунтов, ожидалось 3", total)
+	}
+	if total, _ := assigned(second, "{male}"); total != 1 {
+		t.Fatalf("заказ second: %d аккаунтов, ожидался 1", total)
+	}
+	if total, _ := assigned(paused, "{female}"); total != 2 {
+		t.Fatalf("приостановленный заказ: %d аккаунтов, ожидалось 2", total)
+	}
+
+	var mismatchFact int
+	if err := conn.QueryRow(`SELECT accounts_number_fact FROM orders WHERE id = $1`, mismatch).Scan(&mismatchFact); err != nil {
+		t.Fatalf("accounts_number_fact: %v", err)
+	}
+	if mismatchFact != 2 {
+		t.Fatalf("accounts_number_fact заказа mismatch = %d, ожидалось 2", mismatchFact)
+	}
+}
@@ -2,18 +2,21 @@ package storage
 
 import (
 	"atg_go/models"
+	"context"
 	"database/sql"
 )
 
 // CreateProxy сохраняет прокси, чтобы его можно было переиспользовать без дублирования
 // данных и лишних обращений к внешним сервисам.
-func (db *DB) CreateProxy(p models.Proxy) (*models.Proxy, error) {
+func (db *DB) CreateProxy(ctx context.Context, p models.Proxy) (*models.Proxy, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	query := `
               INSERT INTO proxy (ip, port, login, password, ipv6, is_active)
               VALUES ($1, $2, $3, $4, $5, $6)
               RETURNING id, account_count
        `
-	err := db.Conn.QueryRow(query, p.IP, p.Port, p.Login, p.Password, p.IPv6, p.IsActive).Scan(&p.ID, &p.AccountsCount)
+	err := db.Conn.QueryRowContext(ctx, query, p.IP, p.Port, p.Login, p.Password, p.IPv6, p.IsActive).Scan(&p.ID, &p.AccountsCount)
 	if err != nil {
 		return nil, err
 	}
@@ -22,7 +25,9 @@ func (db *DB) CreateProxy(p models.Proxy) (*models.Proxy, error) {
 
 // GetProxyByID загружает прокси по идентификатору, чтобы быстро получать его
 // параметры без повторного ввода пользователем.
-func (db *DB) GetProxyByID(id int) (*models.Proxy, error) {
+func (db *DB) GetProxyByID(ctx context.Context, id int) (*models.Proxy, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	var p models.Proxy
 	var active sql.NullBool
 	query := `
@@ -30,7 +35,7 @@ func (db *DB) GetProxyByID(id int) (*models.Proxy, error) {
               FROM proxy
               WHERE id = $1
        `
-	err := db.Conn.QueryRow(query, id).Scan(
+	err := db.Conn.QueryRowContext(ctx, query, id).Scan(
 		&p.ID,
 		&p.IP,
 		&p.Port,
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"atg_go/models"
+)
+
+// retentionTable описывает, как отбирать устаревшие строки таблицы.
+type retentionTable struct {
+	name       string // Имя таблицы в SQL (с кавычками, если нужно)
+	timeColumn string // Колонка, по которой считается возраст строки
+	archive    string // Выражение JSON строки для архива; t — псевдоним удаляемой строки
+}
+
+// channelPostArchive выгружает пост вместе с теорией и фактом: они удаляются каскадно.
+const channelPostArchive = `json_build_object(
+    'post', row_to_json(t),
+    'theory', (SELECT json_agg(json_build_object(
+            'theory', row_to_json(th),
+            'fact', (SELECT json_agg(row_to_json(f)) FROM channel_post_fact f WHERE f.channel_post_theory_id = th.id)))
+        FROM channel_post_theory th WHERE th.channel_post_id = t.id))`
+
+// retentionTables — таблицы, для которых допускаются политики хранения.
+// Список совпадает с CHECK в retention_policies: имя таблицы не подставляется в SQL от пользователя.
+var retentionTables = map[string]retentionTable{
+	"activity":                     {name: "activity", timeColumn: "date_time", archive: "row_to_json(t)"},
+	"channel_post":                 {name: "channel_post", timeColumn: "post_date_time", archive: channelPostArchive},
+	"Sos":                          {name: `"Sos"`, timeColumn: "date_time", archive: "row_to_json(t)"},
+	"invite_activities_statistics": {name: "invite_activities_statistics", timeColumn: "stat_date", archive: "row_to_json(t)"},
+	"accounts_sessions_disconnect_statistics": {name: "accounts_sessions_disconnect_statistics", timeColumn: "date_time", archive: "row_to_json(t)"},
+}
+
+// IsRetentionTable сообщает, поддерживается ли очистка таблицы.
+func IsRetentionTable(table string) bool {
+	_, ok := retentionTables[table]
+	return ok
+}
+
+const retentionPolicyColumns = `table_name, keep_days, mode, batch_size, enabled, last_run_at, last_removed, updated_at`
+
+func scanRetentionPolicy(row interface{ Scan(...any) error }) (*models.RetentionPolicy, error) {
+	var p models.RetentionPolicy
+	var lastRun sql.NullTime
+	if err := row.Scan(&p.TableName, &p.KeepDays, &p.Mode, &p.BatchSize, &p.Enabled, &lastRun, &p.LastRemoved, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if lastRun.Valid {
+		p.LastRunAt = &lastRun.Time
+	}
+	return &p, nil
+}
+
+// GetRetentionPolicies возвращает политики хранения всех таблиц.
+func (db *DB) GetRetentionPolicies(ctx context.Context) ([]models.RetentionPolicy, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.Conn.QueryContext(ctx, `SELECT `+retentionPolicyColumns+` FROM retention_policies ORDER BY table_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.RetentionPolicy
+	for rows.Next() {
+		p, err := scanRetentionPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *p)
+	}
+	return list, rows.Err()
+}
+
+// RetentionPolicyUpdate — изменяемые поля политики; nil-поля не изменяются.
+type RetentionPolicyUpdate struct {
+	KeepDays  *int
+	Mode      *string
+	BatchSize *int
+	Enabled   *bool
+}
+
+// UpdateRetentionPolicy меняет политику таблицы. Возвращает sql.ErrNoRows, если политики нет.
+func (db *DB) UpdateRetentionPolicy(ctx context.Context, table string, u RetentionPolicyUpdate) (*models.RetentionPolicy, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	row := db.Conn.QueryRowContext(ctx, `UPDATE retention_policies
+        SET keep_days = COALESCE($2, keep_days), mode = COALESCE($3, mode),
+            batch_size = COALESCE($4, batch_size), enabled = COALESCE($5, enabled), updated_at = NOW()
+        WHERE table_name = $1
+        RETURNING `+retentionPolicyColumns, table, u.KeepDays, u.Mode, u.BatchSize, u.Enabled)
+	return scanRetentionPolicy(row)
+}
+
+// FinishRetentionRun сохраняет итог очистки таблицы.
+func (db *DB) FinishRetentionRun(ctx context.Context, table string, removed int64) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE retention_policies SET last_run_at = NOW(), last_removed = $2
+        WHERE table_name = $1`, table, removed)
+	return err
+}
+
+// DeleteExpired удаляет одну пачку строк таблицы старше cutoff и возвращает их количество.
+// Пачки ограничивают время блокировок и объём WAL на больших таблицах.
+func (db *DB) DeleteExpired(ctx context.Context, table string, cutoff time.Time, batch int) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	t, ok := retentionTables[table]
+	if !ok {
+		return 0, fmt.Errorf("таблица %s не поддерживает очистку", table)
+	}
+	res, err := db.Conn.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %[1]s WHERE ctid IN (
+            SELECT ctid FROM %[1]s WHERE %[2]s < $1 LIMIT $2)`, t.name, t.timeColumn), cutoff, batch)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ArchiveExpired удаляет одну пачку строк таблицы старше cutoff, передавая строки
+// в write в виде JSON. Удаление фиксируется только после успешной записи пачки,
+// поэтому при ошибке архива данные остаются в таблице.
+func (db *DB) ArchiveExpired(ctx context.Context, table string, cutoff time.Time, batch int, write func(rows [][]byte) error) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	t, ok := retentionTables[table]
+	if !ok {
+		return 0, fmt.Errorf("таблица %s не поддерживает очистку", table)
+	}
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`DELETE FROM %[1]s t WHERE t.ctid IN (
+            SELECT ctid FROM %[1]s WHERE %[2]s < $1 LIMIT $2)
+        RETURNING (%[3]s)::text`, t.name, t.timeColumn, t.archive), cutoff, batch)
+	if err != nil {
+		return 0, err
+	}
+	var deleted [][]byte
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		deleted = append(deleted, row)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(deleted) == 0 {
+		return 0, nil
+	}
+	if err := write(deleted); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(deleted)), nil
+}
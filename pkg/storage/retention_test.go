@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"atg_go/migrations"
+)
+
+// TestRetentionTablesMatchMigration проверяет, что каждая таблица с поддержкой очистки
+// разрешена ограничением CHECK в retention_policies и получает политику по умолчанию.
+func TestRetentionTablesMatchMigration(t *testing.T) {
+	schema, err := migrations.Read("2025-09-08-0700_create_retention_policies")
+	if err != nil {
+		t.Fatalf("миграция не найдена: %v", err)
+	}
+	for table := range retentionTables {
+		if strings.Count(schema, "'"+table+"'") < 2 {
+			t.Errorf("таблица %s должна быть в CHECK и в политиках по умолчанию", table)
+		}
+	}
+}
+
+// TestDeleteExpiredUnknownTable проверяет, что имя таблицы не из списка не попадает в SQL.
+func TestDeleteExpiredUnknownTable(t *testing.T) {
+	db := &DB{}
+	if _, err := db.DeleteExpired(context.Background(), "accounts", time.Time{}, 10); err == nil {
+		t.Fatal("очистка произвольной таблицы должна отклоняться")
+	}
+	if _, err := db.ArchiveExpired(context.Background(), "accounts; DROP TABLE orders", time.Time{}, 10, nil); err == nil {
+		t.Fatal("архивирование произвольной таблицы должно отклоняться")
+	}
+}
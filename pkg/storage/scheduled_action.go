@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"atg_go/models"
+)
+
+// scheduledActionColumns перечисляет поля, которые читаются во всех выборках очереди.
+const scheduledActionColumns = `id, kind, order_id, account_id, payload, run_at, status, attempts, max_attempts, lease_until, last_error, created_at, updated_at`
+
+// scanScheduledAction читает строку scheduled_actions с учётом NULL-полей.
+func scanScheduledAction(s rowScanner) (models.ScheduledAction, error) {
+	var (
+		a         models.ScheduledAction
+		orderID   sql.NullInt64
+		accountID sql.NullInt64
+		lease     sql.NullTime
+		lastError sql.NullString
+		payload   []byte
+	)
+	if err := s.Scan(&a.ID, &a.Kind, &orderID, &accountID, &payload, &a.RunAt, &a.Status, &a.Attempts, &a.MaxAttempts, &lease, &lastError, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return a, err
+	}
+	if orderID.Valid {
+		v := int(orderID.Int64)
+		a.OrderID = &v
+	}
+	if accountID.Valid {
+		v := int(accountID.Int64)
+		a.AccountID = &v
+	}
+	if lease.Valid {
+		a.LeaseUntil = &lease.Time
+	}
+	if lastError.Valid {
+		a.LastError = &lastError.String
+	}
+	a.Payload = payload
+	return a, nil
+}
+
+// collectScheduledActions читает все строки выборки очереди.
+func collectScheduledActions(rows *sql.Rows) ([]models.ScheduledAction, error) {
+	defer rows.Close()
+	var actions []models.ScheduledAction
+	for rows.Next() {
+		a, err := scanScheduledAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+// CreateScheduledActions сохраняет пачку действий одной транзакцией,
+// чтобы план по посту не записался частично.
+func (db *DB) CreateScheduledActions(ctx context.Context, actions []models.ScheduledAction) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	if len(actions) == 0 {
+		return nil
+	}
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO scheduled_actions (kind, order_id, account_id, payload, run_at, max_attempts) VALUES ($1, $2, $3, $4, $5, $6)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, a := range actions {
+		maxAttempts := a.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = 3
+		}
+		payload := []byte(a.Payload)
+		if len(payload) == 0 {
+			payload = []byte("{}")
+		}
+		if _, err := stmt.ExecContext(ctx, a.Kind, a.OrderID, a.AccountID, payload, a.RunAt, maxAttempts); err != nil {
+			log.Printf("[DB ERROR] сохранение запланированного действия: %v", err)
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ClaimScheduledActions захватывает до limit готовых к запуску действий и выдаёт их в аренду на lease.
+// Действия с истёкшей арендой (упавший воркер) захватываются повторно, пока не исчерпаны попытки.
+// SKIP LOCKED позволяет нескольким воркерам и процессам забирать разные строки без ожидания.
+func (db *DB) ClaimScheduledActions(ctx context.Context, limit int, lease time.Duration) ([]models.ScheduledAction, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	// Действия, чья аренда истекла на последней попытке, больше не повторяем
+	if _, err := db.Conn.ExecContext(ctx, `UPDATE scheduled_actions
+        SET status = 'failed', lease_until = NULL, last_error = 'аренда истекла', updated_at = NOW()
+        WHERE status = 'running' AND lease_until < NOW() AND attempts >= max_attempts`); err != nil {
+		return nil, err
+	}
+	// Действия приостановленных и архивных заказов не выполняем
+	if _, err := db.Conn.ExecContext(ctx, `UPDATE scheduled_actions
+        SET status = 'cancelled', lease_until = NULL, last_error = 'заказ не активен', updated_at = NOW()
+        WHERE status = 'pending' AND run_at <= NOW()
+          AND order_id IN (SELECT id FROM orders WHERE status <> 'active')`); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Conn.QueryContext(ctx, `UPDATE scheduled_actions
+        SET status = 'running', attempts = attempts + 1,
+            lease_until = NOW() + make_interval(secs => $2), updated_at = NOW()
+        WHERE id IN (
+            SELECT id FROM scheduled_actions
+            WHERE run_at <= NOW()
+              AND (status = 'pending' OR (status = 'running' AND lease_until < NOW()))
+              AND (order_id IS NULL OR order_id IN (SELECT id FROM orders WHERE status = 'active'))
+            ORDER BY run_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING `+scheduledActionColumns, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return collectScheduledActions(rows)
+}
+
+// RenewScheduledActionLease продлевает аренду выполняющегося действия на lease.
+// attempts — номер попытки, полученный при захвате: если аренда уже истекла и действие
+// захватил другой воркер, продление не выполняется. Возвращает false, если аренда потеряна
+// (действие отменено или перехвачено).
+func (db *DB) RenewScheduledActionLease(ctx context.Context, id int64, attempts int, lease time.Duration) (bool, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	res, err := db.Conn.ExecContext(ctx, `UPDATE scheduled_actions
+        SET lease_until = NOW() + make_interval(secs => $3), updated_at = NOW()
+        WHERE id = $1 AND status = 'running' AND attempts = $2`, id, attempts, lease.Seconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CompleteScheduledAction отмечает действие выполненным.
+func (db *DB) CompleteScheduledAction(ctx context.Context, id int64) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE scheduled_actions SET status = 'done', lease_until = NULL, updated_at = NOW() WHERE id = $1 AND status = 'running'`, id)
+	return err
+}
+
+// FailScheduledAction фиксирует ошибку выполнения. Если попытки остались,
+// действие возвращается в очередь на retryAt, иначе помечается failed.
+func (db *DB) FailScheduledAction(ctx context.Context, id int64, errMsg string, retryAt time.Time) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE scheduled_actions
+        SET status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'pending' END,
+            run_at = CASE WHEN attempts >= max_attempts THEN run_at ELSE $3 END,
+            lease_until = NULL, last_error = $2, updated_at = NOW()
+        WHERE id = $1 AND status = 'running'`, id, errMsg, retryAt)
+	return err
+}
+
+// SkipScheduledAction отменяет захваченное действие, которое больше не нужно выполнять,
+// и сохраняет причину в last_error.
+func (db *DB) SkipScheduledAction(ctx context.Context, id int64, reason string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `UPDATE scheduled_actions
+        SET status = 'cancelled', lease_until = NULL, last_error = $2, updated_at = NOW()
+        WHERE id = $1 AND status = 'running'`, id, reason)
+	return err
+}
+
+// cancelOrderActionsQuery отменяет невыполненные действия заказа.
+// Выполняющиеся сейчас действия доработают, но не перезапишут статус cancelled.
+const cancelOrderActionsQuery = `UPDATE scheduled_actions SET status = 'cancelled', lease_until = NULL, updated_at = NOW()
+        WHERE order_id = $1 AND status IN ('pending', 'running')`
+
+// CancelScheduledActionsForOrder отменяет невыполненные действия заказа и возвращает их число.
+func (db *DB) CancelScheduledActionsForOrder(ctx context.Context, orderID int) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	res, err := db.Conn.ExecContext(ctx, cancelOrderActionsQuery, orderID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ScheduledActionFilter задаёт условия выборки очереди для API.
+type ScheduledActionFilter struct {
+	Status  string
+	Kind    string
+	OrderID *int
+	Limit   int
+}
+
+// ListScheduledActions возвращает действия очереди по фильтру, ближайшие к запуску — первыми.
+func (db *DB) ListScheduledActions(ctx context.Context, f ScheduledActionFilter) ([]models.ScheduledAction, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var (
+		conds []string
+		args  []any
+	)
+	if f.Status != "" {
+		args = append(args, f.Status)
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if f.Kind != "" {
+		args = append(args, f.Kind)
+		conds = append(conds, fmt.Sprintf("kind = $%d", len(args)))
+	}
+	if f.OrderID != nil {
+		args = append(args, *f.OrderID)
+		conds = append(conds, fmt.Sprintf("order_id = $%d", len(args)))
+	}
+	query := `SELECT ` + scheduledActionColumns + ` FROM scheduled_actions`
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(` ORDER BY run_at LIMIT $%d`, len(args))
+
+	rows, err := db.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return collectScheduledActions(rows)
+}
+
+// ScheduledActionsBacklog описывает размер очереди для проверки готовности и API.
+type ScheduledActionsBacklog struct {
+	Pending int `json:"pending"` // Ожидают запуска
+	Overdue int `json:"overdue"` // Должны были запуститься более минуты назад
+	Running int `json:"running"` // Выполняются сейчас
+}
+
+// GetScheduledActionsBacklog считает невыполненные действия очереди.
+func (db *DB) GetScheduledActionsBacklog(ctx context.Context) (ScheduledActionsBacklog, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var b ScheduledActionsBacklog
+	err := db.Conn.QueryRowContext(ctx, `SELECT
+            COUNT(*) FILTER (WHERE status = 'pending'),
+            COUNT(*) FILTER (WHERE status = 'pending' AND run_at < NOW() - INTERVAL '1 minute'),
+            COUNT(*) FILTER (WHERE status = 'running')
+        FROM scheduled_actions WHERE status IN ('pending', 'running')`).Scan(&b.Pending, &b.Overdue, &b.Running)
+	return b, err
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// scheduledTestDriver запоминает последний запрос выборки и его аргументы.
+type scheduledTestDriver struct{}
+
+type scheduledTestConn struct{}
+
+type scheduledEmptyRows struct{}
+
+var (
+	scheduledLastQuery string
+	scheduledLastArgs  []driver.Value
+)
+
+func (scheduledTestDriver) Open(name string) (driver.Conn, error) { return &scheduledTestConn{}, nil }
+
+func (c *scheduledTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *scheduledTestConn) Close() error              { return nil }
+func (c *scheduledTestConn) Begin() (driver.Tx, error) { return nil, errors.New("not implemented") }
+
+func (c *scheduledTestConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	scheduledLastQuery = query
+	scheduledLastArgs = nil
+	for _, a := range args {
+		scheduledLastArgs = append(scheduledLastArgs, a.Value)
+	}
+	return scheduledEmptyRows{}, nil
+}
+
+func (scheduledEmptyRows) Columns() []string {
+	return strings.Split(strings.ReplaceAll(scheduledActionColumns, " ", ""), ",")
+}
+func (scheduledEmptyRows) Close() error                   { return nil }
+func (scheduledEmptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+func init() { sql.Register("scheduledDummy", scheduledTestDriver{}) }
+
+// TestListScheduledActionsFilter проверяет, что фильтры попадают в WHERE с правильной нумерацией параметров.
+func TestListScheduledActionsFilter(t *testing.T) {
+	conn, err := sql.Open("scheduledDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	db := &DB{Conn: conn}
+
+	orderID := 7
+	if _, err := db.ListScheduledActions(context.Background(), ScheduledActionFilter{Status: "pending", OrderID: &orderID}); err != nil {
+		t.Fatalf("выборка завершилась ошибкой: %v", err)
+	}
+	if !strings.Contains(scheduledLastQuery, "WHERE status = $1 AND order_id = $2") {
+		t.Fatalf("неожиданное условие запроса: %s", scheduledLastQuery)
+	}
+	if !strings.Contains(scheduledLastQuery, "LIMIT $3") {
+		t.Fatalf("лимит должен быть третьим параметром: %s", scheduledLastQuery)
+	}
+	if len(scheduledLastArgs) != 3 || scheduledLastArgs[2] != int64(100) {
+		t.Fatalf("ожидался лимит по умолчанию 100, аргументы: %v", scheduledLastArgs)
+	}
+
+	if _, err := db.ListScheduledActions(context.Background(), ScheduledActionFilter{Limit: 5}); err != nil {
+		t.Fatalf("выборка без фильтров завершилась ошибкой: %v", err)
+	}
+	if strings.Contains(scheduledLastQuery, "WHERE") {
+		t.Fatalf("без фильтров WHERE не нужен: %s", scheduledLastQuery)
+	}
+}
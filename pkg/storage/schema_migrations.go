@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"atg_go/migrations"
+
+	"github.com/lib/pq"
+)
+
+// GetSchemaVersion возвращает последнюю применённую миграцию из schema_migrations.
+// Пустая строка означает, что миграции ещё не отмечались.
+func (db *DB) GetSchemaVersion(ctx context.Context) (string, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var version sql.NullString
+	err := db.Conn.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return "", err
+	}
+	return version.String, nil
+}
+
+// MigrationState описывает миграцию, известную приложению.
+// AppliedAt = nil означает, что миграция ещё не применена.
+type MigrationState struct {
+	Version   string
+	AppliedAt *time.Time
+}
+
+// legacyBaseline — последняя миграция, применявшаяся вручную до появления schema_migrations.
+// Базы с уже существующей схемой отмечают миграции до неё включительно без повторного выполнения.
+const legacyBaseline = "2025-09-07-0700_rename_accounts_sessions_disconnect_table"
+
+// ensureSchemaMigrations создаёт таблицу учёта миграций. Если таблица пуста, а схема
+// уже существует (есть accounts или orders), база создана до учёта миграций: отмечаем
+// применёнными все версии до legacyBaseline, иначе MigrateUp повторил бы full_schema.
+func (db *DB) ensureSchemaMigrations(ctx context.Context) error {
+	if _, err := db.Conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+        version TEXT PRIMARY KEY,
+        applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    )`); err != nil {
+		return err
+	}
+	var legacy bool
+	if err := db.Conn.QueryRowContext(ctx, `SELECT NOT EXISTS (SELECT 1 FROM schema_migrations)
+        AND (to_regclass('accounts') IS NOT NULL OR to_regclass('orders') IS NOT NULL)`).Scan(&legacy); err != nil {
+		return err
+	}
+	if !legacy {
+		return nil
+	}
+	var baseline []string
+	for _, v := range migrations.Versions() {
+		if v <= legacyBaseline {
+			baseline = append(baseline, v)
+		}
+	}
+	log.Printf("[MIGRATIONS] схема создана до учёта миграций, отмечаем применёнными %d версий до %s", len(baseline), legacyBaseline)
+	_, err := db.Conn.ExecContext(ctx, `INSERT INTO schema_migrations (version)
+        SELECT unnest($1::text[]) ON CONFLICT (version) DO NOTHING`, pq.Array(baseline))
+	return err
+}
+
+// appliedMigrations возвращает время применения по версиям из schema_migrations.
+func (db *DB) appliedMigrations(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := db.Conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			v  string
+			at time.Time
+		)
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		applied[v] = at
+	}
+	return applied, rows.Err()
+}
+
+// MigrationStatus сопоставляет встроенные миграции с записями schema_migrations.
+func (db *DB) MigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	if err := db.ensureSchemaMigrations(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions := migrations.Versions()
+	states := make([]MigrationState, 0, len(versions))
+	for _, v := range versions {
+		s := MigrationState{Version: v}
+		if at, ok := applied[v]; ok {
+			s.AppliedAt = &at
+		}
+		states = append(states, s)
+	}
+	return states, nil
+}
+
+// MigrateUp применяет неприменённые встроенные миграции по порядку.
+// Каждая миграция выполняется в своей транзакции вместе с записью в schema_migrations,
+// поэтому при ошибке база остаётся на последней успешно применённой версии.
+func (db *DB) MigrateUp(ctx context.Context) ([]string, error) {
+	states, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []string
+	for _, s := range states {
+		if s.AppliedAt != nil {
+			continue
+		}
+		if err := db.applyMigration(ctx, s.Version); err != nil {
+			return done, fmt.Errorf("миграция %s: %w", s.Version, err)
+		}
+		done = append(done, s.Version)
+	}
+	return done, nil
+}
+
+// applyMigration выполняет одну миграцию и отмечает её применённой.
+func (db *DB) applyMigration(ctx context.Context, version string) error {
+	query, err := migrations.Read(version)
+	if err != nil {
+		return err
+	}
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Без параметров lib/pq отправляет запрос целиком, поэтому файл может содержать несколько команд
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT (version) DO NOTHING`, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
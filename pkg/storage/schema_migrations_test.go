@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// migrationsTestDriver имитирует базу: legacy — схема создана до учёта миграций,
+// migrationsApplied — содержимое schema_migrations, migrationsRun — выполненные файлы миграций.
+type migrationsTestDriver struct{}
+
+type migrationsTestConn struct{}
+
+type migrationsTestTx struct{}
+
+type migrationsTestResult struct{}
+
+type migrationsTestRows struct {
+	cols   []string
+	values [][]driver.Value
+}
+
+var (
+	migrationsLegacy  bool
+	migrationsApplied map[string]bool
+	migrationsRun     []string
+)
+
+func (migrationsTestDriver) Open(name string) (driver.Conn, error) { return &migrationsTestConn{}, nil }
+
+func (c *migrationsTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *migrationsTestConn) Close() error              { return nil }
+func (c *migrationsTestConn) Begin() (driver.Tx, error) { return migrationsTestTx{}, nil }
+
+func (c *migrationsTestConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	switch {
+	case strings.Contains(query, "to_regclass"):
+		return &migrationsTestRows{cols: []string{"legacy"}, values: [][]driver.Value{{migrationsLegacy && len(migrationsApplied) == 0}}}, nil
+	case strings.Contains(query, "SELECT version, applied_at FROM schema_migrations"):
+		rows := &migrationsTestRows{cols: []string{"version", "applied_at"}}
+		for v := range migrationsApplied {
+			rows.values = append(rows.values, []driver.Value{v, time.Now()})
+		}
+		return rows, nil
+	}
+	return nil, errors.New("unexpected query: " + query)
+}
+
+func (c *migrationsTestConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations (version)\n"):
+		// Массив версий приходит в текстовом виде {"a","b"}
+		list := strings.Trim(args[0].Value.(string), "{}")
+		for _, v := range strings.Split(list, ",") {
+			migrationsApplied[strings.Trim(v, `"`)] = true
+		}
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations (version) VALUES ($1)"):
+		migrationsApplied[args[0].Value.(string)] = true
+	default:
+		// Файл миграции: на существующей схеме full_schema падает на CREATE TYPE
+		if migrationsLegacy && strings.Contains(query, "CREATE TYPE gender_enum") {
+			return nil, errors.New(`type "gender_enum" already exists`)
+		}
+		migrationsRun = append(migrationsRun, query)
+	}
+	return migrationsTestResult{}, nil
+}
+
+func (migrationsTestTx) Commit() error   { return nil }
+func (migrationsTestTx) Rollback() error { return nil }
+
+func (r *migrationsTestRows) Columns() []string { return r.cols }
+func (r *migrationsTestRows) Close() error      { return nil }
+func (r *migrationsTestRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func (migrationsTestResult) LastInsertId() (int64, error) { return 0, nil }
+func (migrationsTestResult) RowsAffected() (int64, error) { return 1, nil }
+
+func init() { sql.Register("migrationsDummy", migrationsTestDriver{}) }
+
+// migrateWith прогоняет MigrateUp на мок-базе с заданным состоянием.
+func migrateWith(t *testing.T, legacy bool) []string {
+	t.Helper()
+	migrationsLegacy = legacy
+	migrationsApplied = map[string]bool{}
+	migrationsRun = nil
+
+	conn, err := sql.Open("migrationsDummy", "")
+	if err != nil {
+		t.Fatalf("не удалось открыть мок БД: %v", err)
+	}
+	defer conn.Close()
+	db := &DB{Conn: conn}
+
+	done, err := db.MigrateUp(context.Background())
+	if err != nil {
+		t.Fatalf("применение миграций завершилось ошибкой: %v", err)
+	}
+	return done
+}
+
+// TestMigrateUpLegacySchema проверяет базу, созданную до schema_migrations:
+// версии до legacyBaseline отмечаются без выполнения, применяются только новые.
+func TestMigrateUpLegacySchema(t *testing.T) {
+	done := migrateWith(t, true)
+	if len(done) == 0 || done[0] <= legacyBaseline {
+		t.Fatalf("применены уже существующие миграции: %v", done)
+	}
+	for _, v := range done {
+		if v <= legacyBaseline {
+			t.Fatalf("миграция %s не должна выполняться повторно", v)
+		}
+	}
+	if !migrationsApplied["2025-08-29-0100_full_schema"] || !migrationsApplied[legacyBaseline] {
+		t.Fatalf("базовые версии не отмечены: %v", migrationsApplied)
+	}
+}
+
+// TestMigrateUpEmptyDatabase проверяет, что пустая база получает все миграции начиная с full_schema.
+func TestMigrateUpEmptyDatabase(t *testing.T) {
+	done := migrateWith(t, false)
+	if len(done) == 0 || done[0] != "2025-08-29-0100_full_schema" {
+		t.Fatalf("пустая база должна начинаться с full_schema: %v", done)
+	}
+	if len(migrationsRun) != len(done) {
+		t.Fatalf("выполнено %d файлов, отмечено %d", len(migrationsRun), len(done))
+	}
+}
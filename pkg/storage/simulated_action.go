@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"atg_go/models"
+)
+
+// RecordSimulatedAction сохраняет запрос, не отправленный в Telegram из-за режима dry-run.
+func (db *DB) RecordSimulatedAction(ctx context.Context, accountID int, method string, payload []byte) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	var acc any
+	if accountID > 0 {
+		acc = accountID
+	}
+	_, err := db.Conn.ExecContext(ctx, `INSERT INTO simulated_actions (account_id, method, payload) VALUES ($1, $2, $3)`,
+		acc, method, nullableJSON(payload))
+	return err
+}
+
+// ListSimulatedActions возвращает последние перехваченные действия, новые — первыми.
+// accountID = 0 означает все аккаунты.
+func (db *DB) ListSimulatedActions(ctx context.Context, accountID, limit int) ([]models.SimulatedAction, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.Conn.QueryContext(ctx, `SELECT id, account_id, method, payload, created_at FROM simulated_actions
+        WHERE $1 = 0 OR account_id = $1
+        ORDER BY created_at DESC LIMIT $2`, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []models.SimulatedAction
+	for rows.Next() {
+		var (
+			a       models.SimulatedAction
+			acc     sql.NullInt64
+			payload []byte
+		)
+		if err := rows.Scan(&a.ID, &acc, &a.Method, &payload, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		if acc.Valid {
+			id := int(acc.Int64)
+			a.AccountID = &id
+		}
+		if len(payload) > 0 {
+			a.Payload = payload
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
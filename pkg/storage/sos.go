@@ -1,8 +1,12 @@
 package storage
 
+import "context"
+
 // SaveSos сохраняет сообщение о критичном событии в таблице "Sos".
 // Фиксируем только текст, время добавляет сама БД через DEFAULT NOW().
-func (db *DB) SaveSos(msg string) error {
-	_, err := db.Conn.Exec(`INSERT INTO "Sos" (msg) VALUES ($1)`, msg)
+func (db *DB) SaveSos(ctx context.Context, msg string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	_, err := db.Conn.ExecContext(ctx, `INSERT INTO "Sos" (msg) VALUES ($1)`, msg)
 	return err
 }
@@ -1,21 +1,25 @@
 package storage
 
 import (
+	"context"
 	"strconv"
 
 	"atg_go/models"
 )
 
 // GetAccountsForPostView возвращает авторизованные аккаунты,
-// подписанные на канал заказа и ещё не просмотревшие заданный пост.
+// подписанные на канал заказа, не находящиеся под флуд-вейтом и ещё не просмотревшие заданный пост.
 // channelID и messageID должны быть числовыми идентификаторами канала и поста.
-func (db *DB) GetAccountsForPostView(orderID, channelID, messageID int) ([]models.Account, error) {
+func (db *DB) GetAccountsForPostView(ctx context.Context, orderID, channelID, messageID int) ([]models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 	chID := strconv.FormatInt(int64(channelID), 10)
 	msgID := strconv.FormatInt(int64(messageID), 10)
-	condition := `a.is_authorized = true AND a.account_monitoring = false AND a.account_generator_category = false AND EXISTS (
+	condition := `a.is_authorized = true AND a.account_monitoring = false AND a.account_generator_category = false
+    AND (a.floodwait_until IS NULL OR a.floodwait_until <= NOW()) AND EXISTS (
         SELECT 1 FROM order_account_subs oas WHERE oas.account_id = a.id AND oas.order_id = $1
     ) AND NOT EXISTS (
         SELECT 1 FROM activity act WHERE act.id_account = a.id AND act.id_channel = $2 AND act.id_message = $3 AND act.activity_type = $4
     )`
-	return db.getAccounts(condition, orderID, chID, msgID, ActivityTypeSubsActiveView)
+	return db.getAccounts(ctx, condition, orderID, chID, msgID, ActivityTypeSubsActiveView)
 }
@@ -0,0 +1,138 @@
+package channel_duplicate
+
+import (
+	"context"
+	"sync"
+)
+
+// duplicateRegistry хранит отслеживаемые каналы-доноры по ID канала.
+// Карту читают обработчик обновлений Telegram и горутины публикации из истории,
+// а меняет подписка на channel_duplicate_changed, поэтому доступ защищён мьютексом.
+type duplicateRegistry struct {
+	mu      sync.RWMutex
+	byDonor map[int64]channelInfo
+	// runs хранит по ID записи её параметры и отмену горутин публикации из истории
+	runs map[int]duplicateRun
+}
+
+// duplicateConfig — параметры записи, при смене которых нужно заново подписаться
+// на каналы и перезапустить публикацию из истории.
+type duplicateConfig struct {
+	donorURL  string
+	targetURL string
+	times     string
+	hasLastID bool
+}
+
+// duplicateRun — зарегистрированная запись: её параметры, заказ и отмена горутин публикации.
+type duplicateRun struct {
+	config  duplicateConfig
+	orderID int
+	cancel  context.CancelFunc
+}
+
+func newDuplicateRegistry() *duplicateRegistry {
+	return &duplicateRegistry{byDonor: make(map[int64]channelInfo), runs: make(map[int]duplicateRun)}
+}
+
+// get возвращает копию записи канала-донора.
+func (r *duplicateRegistry) get(donorID int64) (channelInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.byDonor[donorID]
+	return info, ok
+}
+
+// unchanged сообщает, что запись id уже зарегистрирована с параметрами cfg.
+func (r *duplicateRegistry) unchanged(id int, cfg duplicateConfig) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	run, ok := r.runs[id]
+	return ok && run.config == cfg
+}
+
+// hasOrder сообщает, что среди зарегистрированных есть запись заказа orderID.
+func (r *duplicateRegistry) hasOrder(orderID int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, run := range r.runs {
+		if run.orderID == orderID {
+			return true
+		}
+	}
+	return false
+}
+
+// set привязывает запись к каналу-донору, заменяя прежнюю привязку той же записи
+// (ID канала мог измениться вместе со ссылкой). Горутины прежней регистрации останавливаются.
+func (r *duplicateRegistry) set(donorID int64, info channelInfo, run duplicateRun) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropLocked(info.id)
+	r.byDonor[donorID] = info
+	r.runs[info.id] = run
+}
+
+// update меняет запись канала-донора, только если канал по-прежнему привязан
+// к записи id: перерегистрация во время публикации не перезаписывается устаревшими данными.
+func (r *duplicateRegistry) update(donorID int64, id int, fn func(info *channelInfo)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info, ok := r.byDonor[donorID]
+	if !ok || info.id != id {
+		return
+	}
+	fn(&info)
+	r.byDonor[donorID] = info
+}
+
+// refresh меняет запись id, к какому бы каналу-донору она ни была привязана.
+func (r *duplicateRegistry) refresh(id int, fn func(info *channelInfo)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, info := range r.byDonor {
+		if info.id == id {
+			fn(&info)
+			r.byDonor[key] = info
+			return
+		}
+	}
+}
+
+// remove прекращает отслеживание записи channel_duplicate и останавливает её публикацию.
+func (r *duplicateRegistry) remove(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropLocked(id)
+}
+
+// retain оставляет только записи из actual.
+func (r *duplicateRegistry) retain(actual map[int]bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id := range r.runs {
+		if !actual[id] {
+			r.dropLocked(id)
+		}
+	}
+	for key, info := range r.byDonor {
+		if !actual[info.id] {
+			delete(r.byDonor, key)
+		}
+	}
+}
+
+// dropLocked отменяет горутины записи id и удаляет её привязку. Вызывается под r.mu.
+func (r *duplicateRegistry) dropLocked(id int) {
+	if run, ok := r.runs[id]; ok {
+		if run.cancel != nil {
+			run.cancel()
+		}
+		delete(r.runs, id)
+	}
+	for key, info := range r.byDonor {
+		if info.id == id {
+			delete(r.byDonor, key)
+		}
+	}
+}
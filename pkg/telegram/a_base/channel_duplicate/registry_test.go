@@ -0,0 +1,101 @@
+package channel_duplicate
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// TestDuplicateRegistry проверяет замену привязки при смене канала и защиту от устаревших обновлений.
+func TestDuplicateRegistry(t *testing.T) {
+	r := newDuplicateRegistry()
+	r.set(100, channelInfo{id: 1}, duplicateRun{})
+	// Ссылка донора изменилась: запись 1 теперь привязана к каналу 200
+	r.set(200, channelInfo{id: 1}, duplicateRun{})
+	if _, ok := r.get(100); ok {
+		t.Fatal("старая привязка записи не удалена")
+	}
+
+	last := 5
+	r.update(200, 2, func(info *channelInfo) { info.lastID = &last })
+	if info, _ := r.get(200); info.lastID != nil {
+		t.Fatal("обновление чужой записи применено")
+	}
+	r.update(200, 1, func(info *channelInfo) { info.lastID = &last })
+	if info, _ := r.get(200); info.lastID == nil || *info.lastID != 5 {
+		t.Fatalf("обновление не применено: %+v", info)
+	}
+
+	r.retain(map[int]bool{})
+	if _, ok := r.get(200); ok {
+		t.Fatal("запись, которой нет в БД, не удалена")
+	}
+}
+
+// TestDuplicateRegistryStopsRuns проверяет, что замена, удаление и сверка записи
+// останавливают горутины её прежней регистрации.
+func TestDuplicateRegistryStopsRuns(t *testing.T) {
+	r := newDuplicateRegistry()
+	start := func(donorID int64, id int, cfg duplicateConfig) context.Context {
+		ctx, cancel := context.WithCancel(context.Background())
+		r.set(donorID, channelInfo{id: id}, duplicateRun{config: cfg, orderID: 7, cancel: cancel})
+		return ctx
+	}
+	cfg := duplicateConfig{donorURL: "https://t.me/donor", targetURL: "https://t.me/target", times: "10:00:00", hasLastID: true}
+
+	first := start(100, 1, cfg)
+	if !r.hasOrder(7) || r.hasOrder(8) {
+		t.Fatal("реестр должен знать заказы зарегистрированных записей")
+	}
+	if !r.unchanged(1, cfg) {
+		t.Fatal("запись с теми же параметрами должна считаться неизменной")
+	}
+	changed := cfg
+	changed.times = "11:00:00"
+	if r.unchanged(1, changed) {
+		t.Fatal("смена расписания должна требовать перерегистрации")
+	}
+
+	second := start(100, 1, changed)
+	if first.Err() == nil {
+		t.Fatal("горутины заменённой регистрации не остановлены")
+	}
+	r.remove(1)
+	if second.Err() == nil {
+		t.Fatal("горутины удалённой записи не остановлены")
+	}
+
+	kept := start(100, 2, cfg)
+	dropped := start(200, 3, cfg)
+	r.retain(map[int]bool{2: true})
+	if dropped.Err() == nil || kept.Err() != nil {
+		t.Fatal("сверка должна останавливать только отсутствующие в БД записи")
+	}
+	if r.unchanged(3, cfg) {
+		t.Fatal("удалённая запись осталась в реестре")
+	}
+}
+
+// TestDuplicateRegistryConcurrent запускается с -race: чтение и изменение реестра из разных горутин.
+func TestDuplicateRegistryConcurrent(t *testing.T) {
+	r := newDuplicateRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.set(int64(id), channelInfo{id: id}, duplicateRun{})
+				r.remove(id)
+			}
+		}(i)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.get(int64(id))
+				r.update(int64(id), id, func(info *channelInfo) { info.skip = postSkip{} })
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -4,11 +4,10 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
-	"strconv"
-	"strings"
 	"time"
 
 	"atg_go/models"
+	"atg_go/pkg/link"
 	"atg_go/pkg/storage"
 	module "atg_go/pkg/telegram/a_technical"
 	accountmutex "atg_go/pkg/telegram/a_technical/account_mutex"
@@ -19,7 +18,7 @@ import (
 // SendReaction добавляет реакцию к посту канала по ссылке postURL.
 // orderID нужен для выбора предопределённых реакций из заказа (orders).
 // Функция не фиксирует активность аккаунта.
-func SendReaction(db *storage.DB, acc models.Account, orderID int, postURL string) error {
+func SendReaction(ctx context.Context, db *storage.DB, acc models.Account, orderID int, postURL string) error {
 	// Блокируем аккаунт на время операции, чтобы избежать параллельного использования
 	if err := accountmutex.LockAccount(acc.ID); err != nil {
 		return err
@@ -32,22 +31,16 @@ func SendReaction(db *storage.DB, acc models.Account, orderID int, postURL strin
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	return client.Run(ctx, func(ctx context.Context) error {
 		api := tg.NewClient(client)
 
 		// Извлекаем username канала и ID сообщения из ссылки вида https://t.me/name/id
-		trimmed := strings.TrimPrefix(postURL, "https://t.me/")
-		parts := strings.Split(trimmed, "/")
-		if len(parts) != 2 {
-			return fmt.Errorf("некорректная ссылка на пост")
-		}
-		username := parts[0]
-		msgID, err := strconv.Atoi(parts[1])
+		username, msgID, err := link.Post(postURL)
 		if err != nil {
-			return err
+			return fmt.Errorf("некорректная ссылка на пост: %w", err)
 		}
 
 		// Получаем информацию о канале по username
@@ -96,13 +89,11 @@ func SendReaction(db *storage.DB, acc models.Account, orderID int, postURL strin
 		}
 
 		// Получаем список реакций из заказа, если он задан
-		reactions, err := db.GetPostReactionsForOrder(orderID)
+		reactions, err := db.GetPostReactionsForOrder(ctx, orderID)
 		if err != nil {
 			return err
 		}
 
-		rand.Seed(time.Now().UnixNano())
-
 		if len(reactions) > 0 {
 			// Используем заданную реакцию из БД
 			reaction := reactions[rand.Intn(len(reactions))]
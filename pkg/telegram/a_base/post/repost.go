@@ -4,11 +4,10 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
-	"strconv"
-	"strings"
 	"time"
 
 	"atg_go/models"
+	"atg_go/pkg/link"
 	"atg_go/pkg/storage"
 	module "atg_go/pkg/telegram/a_technical"
 	accountmutex "atg_go/pkg/telegram/a_technical/account_mutex"
@@ -18,7 +17,7 @@ import (
 
 // SendRepost пересылает пост канала в "Избранное" аккаунта.
 // Функция не фиксирует активность.
-func SendRepost(db *storage.DB, acc models.Account, postURL string) error {
+func SendRepost(ctx context.Context, db *storage.DB, acc models.Account, postURL string) error {
 	// Защищаем аккаунт от параллельного использования
 	if err := accountmutex.LockAccount(acc.ID); err != nil {
 		return err
@@ -31,22 +30,16 @@ func SendRepost(db *storage.DB, acc models.Account, postURL string) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	return client.Run(ctx, func(ctx context.Context) error {
 		api := tg.NewClient(client)
 
 		// Парсим ссылку поста
-		trimmed := strings.TrimPrefix(postURL, "https://t.me/")
-		parts := strings.Split(trimmed, "/")
-		if len(parts) != 2 {
-			return fmt.Errorf("некорректная ссылка на пост")
-		}
-		username := parts[0]
-		msgID, err := strconv.Atoi(parts[1])
+		username, msgID, err := link.Post(postURL)
 		if err != nil {
-			return err
+			return fmt.Errorf("некорректная ссылка на пост: %w", err)
 		}
 
 		// Разрешаем имя пользователя и находим канал
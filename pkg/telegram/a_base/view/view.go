@@ -5,11 +5,10 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"atg_go/models"
+	"atg_go/pkg/link"
 	"atg_go/pkg/storage"
 	module "atg_go/pkg/telegram/a_technical"
 	accountmutex "atg_go/pkg/telegram/a_technical/account_mutex"
@@ -19,10 +18,7 @@ import (
 )
 
 // ViewPost открывает пост канала, чтобы увеличить счётчик просмотров.
-func ViewPost(db *storage.DB, acc models.Account, postURL string) error {
-	// Инициализируем генератор случайных чисел для задержек просмотра
-	rand.Seed(time.Now().UnixNano())
-
+func ViewPost(ctx context.Context, db *storage.DB, acc models.Account, postURL string) error {
 	if err := accountmutex.LockAccount(acc.ID); err != nil {
 		return err
 	}
@@ -33,20 +29,14 @@ func ViewPost(db *storage.DB, acc models.Account, postURL string) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	return client.Run(ctx, func(ctx context.Context) error {
 		api := tg.NewClient(client)
-		trimmed := strings.TrimPrefix(postURL, "https://t.me/")
-		parts := strings.Split(trimmed, "/")
-		if len(parts) != 2 {
-			return fmt.Errorf("некорректная ссылка на пост")
-		}
-		username := parts[0]
-		msgID, err := strconv.Atoi(parts[1])
+		username, msgID, err := link.Post(postURL)
 		if err != nil {
-			return err
+			return fmt.Errorf("некорректная ссылка на пост: %w", err)
 		}
 		resolved, err := api.ContactsResolveUsername(ctx, &tg.ContactsResolveUsernameRequest{Username: username})
 		if err != nil {
@@ -95,7 +85,7 @@ func ViewPost(db *storage.DB, acc models.Account, postURL string) error {
 		time.Sleep(delay)
 
 		// Сохраняем факт просмотра в таблице активности
-		return module.SaveViewActivity(db, acc.ID, int(ch.ID), msgID)
+		return module.SaveViewActivity(ctx, db, acc.ID, int(ch.ID), msgID)
 	})
 }
 
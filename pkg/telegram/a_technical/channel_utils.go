@@ -11,7 +11,10 @@ import (
 	"time"
 
 	"atg_go/models"
+	"atg_go/pkg/link"
 	"atg_go/pkg/storage"
+	"atg_go/pkg/telegram/a_technical/dryrun"
+	"atg_go/pkg/telegram/a_technical/floodwait"
 	statistics "atg_go/pkg/telegram/invite_activities_statistics"
 
 	"golang.org/x/net/proxy"
@@ -26,7 +29,7 @@ import (
 // Если ранее был достигнут предел в 500 каналов, новая попытка не выполняется до конца суток.
 func Modf_JoinChannel(ctx context.Context, api *tg.Client, channel *tg.Channel, db *storage.DB, accountID int) error {
 	// Проверяем, не активен ли блок на новые подписки для данного аккаунта.
-	blocked, err := statistics.IsChannelsLimitActive(db, accountID)
+	blocked, err := statistics.IsChannelsLimitActive(ctx, db, accountID)
 	if err != nil {
 		return fmt.Errorf("не удалось проверить лимит подписок: %w", err)
 	}
@@ -46,7 +49,7 @@ func Modf_JoinChannel(ctx context.Context, api *tg.Client, channel *tg.Channel,
 			// Вычисляем момент времени 23:59 текущих суток.
 			now := time.Now()
 			until := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
-			_ = statistics.MarkChannelsLimit(db, accountID, until) // Обновляем время блокировки в БД
+			_ = statistics.MarkChannelsLimit(ctx, db, accountID, until) // Обновляем время блокировки в БД
 			log.Printf("[WARN] Аккаунт %d достиг лимита подписок: %v", accountID, err)
 		}
 		return err
@@ -91,12 +94,9 @@ func GetChannelPosts(ctx context.Context, api *tg.Client, channel *tg.Channel, l
 	return validMessages, nil
 }
 
-// извлекает username из URL канала
+// извлекает username из URL канала; разбор ссылки — в пакете link
 func Modf_ExtractUsername(url string) (string, error) {
-	if !strings.HasPrefix(url, "https://t.me/") {
-		return "", fmt.Errorf("invalid URL format")
-	}
-	return strings.TrimPrefix(url, "https://t.me/"), nil
+	return link.Username(url)
 }
 
 // находит канал в списке чатов
@@ -118,12 +118,24 @@ func Modf_FindChannel(chats []tg.ChatClass) (*tg.Channel, error) {
 
 // Создаем клиент Telegram с указанными параметрами и хранилищем сессии в БД.
 func Modf_AccountInitialization(apiID int, apiHash, phone string, p *models.Proxy, r *rand.Rand, db *sql.DB, accountID int, h telegram.UpdateHandler) (*telegram.Client, error) {
+	var store *storage.DB
+	if db != nil {
+		store = storage.NewDB(db)
+	}
+	// В режиме dry-run изменяющие запросы не доходят до Telegram, поэтому этот middleware первый
+	middlewares := []telegram.Middleware{dryrun.Middleware(store, accountID)}
+	// Флуд-вейты учитываем для всех запросов аккаунта: middleware сохраняет срок
+	// ограничения и до его окончания отклоняет запросы без обращения к Telegram
+	if accountID > 0 {
+		middlewares = append(middlewares, floodwait.Middleware(store, accountID))
+	}
+
 	var storage session.Storage = &session.StorageMemory{}
 	if db != nil && accountID > 0 {
 		storage = &DBSessionStorage{DB: db, AccountID: accountID}
 	}
 
-	opts := telegram.Options{SessionStorage: storage}
+	opts := telegram.Options{SessionStorage: storage, Middlewares: middlewares}
 	if h != nil {
 		opts.UpdateHandler = h
 	}
@@ -145,7 +157,7 @@ func Modf_AccountInitialization(apiID int, apiHash, phone string, p *models.Prox
 			return nil, fmt.Errorf("proxy dialer missing context")
 		}
 		opts.Resolver = dcs.Plain(dcs.PlainOptions{Dial: dc.DialContext})
-		log.Printf("[PROXY] %s via %s", phone, addr)
+		log.Printf("[PROXY] аккаунт %d через прокси %d", accountID, p.ID)
 	}
 	client := telegram.NewClient(apiID, apiHash, opts)
 	return client, nil
@@ -5,11 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"math"
-	"math/rand"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
+
+	"atg_go/pkg/clock"
+	"atg_go/pkg/telegram/a_technical/dryrun"
 )
 
 // константный Bearer-токен для внутренних запросов
@@ -38,11 +40,49 @@ type AccountsSessionsDisconnectSettings struct {
 	DispatcherStart string `json:"dispatcher_start"`
 }
 
+// activityWindow — часть окна активности, которую ещё предстоит выполнить.
+type activityWindow struct {
+	start    time.Time
+	duration time.Duration
+	count    int
+}
+
+// planActivityWindow рассчитывает окно активности на день base+offset.
+// Если окно ещё не началось, выполняется весь объём totalCount; если уже идёт —
+// только доля, пропорциональная оставшемуся времени. ok=false, когда выполнять нечего.
+func planActivityWindow(now, base time.Time, loc *time.Location, startTime, endTime time.Time, totalCount, offset int) (activityWindow, bool) {
+	currentDay := base.AddDate(0, 0, offset)
+	windowStart := time.Date(currentDay.Year(), currentDay.Month(), currentDay.Day(), startTime.Hour(), startTime.Minute(), 0, 0, loc)
+	windowEnd := time.Date(currentDay.Year(), currentDay.Month(), currentDay.Day(), endTime.Hour(), endTime.Minute(), 0, 0, loc)
+
+	now = now.In(loc)
+	if now.After(windowEnd) {
+		// Период уже закончился
+		return activityWindow{}, false
+	}
+
+	totalDuration := windowEnd.Sub(windowStart)
+
+	if now.Before(windowStart) {
+		// До начала окна — выполняем весь объём
+		return activityWindow{start: windowStart, duration: totalDuration, count: totalCount}, totalCount > 0
+	}
+
+	// Начало окна прошло — рассчитываем оставшееся количество
+	duration := windowEnd.Sub(now)
+	remainingFraction := float64(duration) / float64(totalDuration)
+	count := int(math.Ceil(float64(totalCount) * remainingFraction))
+	if count <= 0 {
+		return activityWindow{}, false
+	}
+	return activityWindow{start: now, duration: duration, count: count}, true
+}
+
 // runActivityInPeriod равномерно распределяет запросы активности
 // в пределах заданного периода, учитывая текущее время по МСК.
 // Функция вычисляет, сколько действий осталось выполнить,
 // и запускает их в оставшееся окно.
-func runActivityInPeriod(ctx context.Context, base time.Time, loc *time.Location, act ActivityRequest, cfg ActivitySettings, offset int) {
+func runActivityInPeriod(ctx context.Context, clk clock.Clock, rng clock.RNG, base time.Time, loc *time.Location, act ActivityRequest, cfg ActivitySettings, offset int) {
 	// Парсим временные границы выполнения
 	startTime, err1 := time.Parse("15:04", cfg.DispatcherPeriod[0])
 	endTime, err2 := time.Parse("15:04", cfg.DispatcherPeriod[1])
@@ -59,79 +99,64 @@ func runActivityInPeriod(ctx context.Context, base time.Time, loc *time.Location
 	}
 
 	// Определяем общее количество действий на весь период
-	totalCount := rand.Intn(maxAct-minAct+1) + minAct
+	totalCount := rng.Intn(maxAct-minAct+1) + minAct
 
-	currentDay := base.AddDate(0, 0, offset)
-	windowStart := time.Date(currentDay.Year(), currentDay.Month(), currentDay.Day(), startTime.Hour(), startTime.Minute(), 0, 0, loc)
-	windowEnd := time.Date(currentDay.Year(), currentDay.Month(), currentDay.Day(), endTime.Hour(), endTime.Minute(), 0, 0, loc)
-
-	now := time.Now().In(loc)
-	if now.After(windowEnd) {
-		// Период уже закончился
+	window, ok := planActivityWindow(clk.Now(), base, loc, startTime, endTime, totalCount, offset)
+	if !ok {
 		return
 	}
 
-	totalDuration := windowEnd.Sub(windowStart)
-
-	var scheduleStart time.Time
-	var duration time.Duration
-	var count int
-
-	if now.Before(windowStart) {
-		// До начала окна — выполняем весь объём
-		scheduleStart = windowStart
-		duration = totalDuration
-		count = totalCount
-	} else {
-		// Начало окна прошло — рассчитываем оставшееся количество
-		scheduleStart = now
-		duration = windowEnd.Sub(now)
-		remainingFraction := float64(duration) / float64(totalDuration)
-		count = int(math.Ceil(float64(totalCount) * remainingFraction))
-		if count <= 0 {
-			return
-		}
-	}
-
-	interval := duration / time.Duration(count)
+	interval := window.duration / time.Duration(window.count)
 
-	for i := 0; i < count; i++ {
+	for i := 0; i < window.count; i++ {
 		select {
 		case <-ctx.Done():
 			return
 		default:
 		}
 
-		t := scheduleStart.Add(interval * time.Duration(i))
-		sleep := t.Sub(time.Now().In(loc))
+		t := window.start.Add(interval * time.Duration(i))
+		sleep := t.Sub(clk.Now().In(loc))
 		if sleep > 0 {
 			select {
-			case <-time.After(sleep):
+			case <-clk.After(sleep):
 			case <-ctx.Done():
 				return
 			}
 		}
 
-		payload, _ := json.Marshal(act.RequestBody)
-		req, err := http.NewRequestWithContext(ctx, "POST", act.URL, bytes.NewBuffer(payload))
-		if err != nil {
-			continue
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+bearerToken)
-		http.DefaultClient.Do(req)
+		sendActivityRequest(ctx, act)
+	}
+}
+
+// sendActivityRequest отправляет запрос активности от имени внутреннего сервиса.
+func sendActivityRequest(ctx context.Context, act ActivityRequest) {
+	payload, _ := json.Marshal(act.RequestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", act.URL, bytes.NewBuffer(payload))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bearerToken)
+	// Режим dry-run диспетчера передаётся вызываемым модулям
+	if dryrun.Enabled(ctx) {
+		req.Header.Set("X-Dry-Run", "true")
 	}
+	http.DefaultClient.Do(req)
 }
 
 // ModF_DispatcherActivity выполняет запросы активности в течение
 // заданного количества суток и реагирует на отмену контекста.
 func ModF_DispatcherActivity(ctx context.Context, daysNumber int, activities []ActivityRequest, commentCfg, reactionCfg ActivitySettings, unsubscribeCfg UnsubscribeSettings, disconnectCfg AccountsSessionsDisconnectSettings) {
-	rand.Seed(time.Now().UnixNano())
+	dispatcherActivity(ctx, clock.System, clock.NewRandomRNG(), daysNumber, activities, commentCfg, reactionCfg, unsubscribeCfg, disconnectCfg)
+}
 
+// dispatcherActivity содержит логику ModF_DispatcherActivity с явными часами и генератором.
+func dispatcherActivity(ctx context.Context, clk clock.Clock, rng clock.RNG, daysNumber int, activities []ActivityRequest, commentCfg, reactionCfg ActivitySettings, unsubscribeCfg UnsubscribeSettings, disconnectCfg AccountsSessionsDisconnectSettings) {
 	// Загружаем часовую зону Москвы и фиксируем текущее время в ней,
 	// чтобы дальнейшие расчёты опирались на МСК
 	loc, _ := time.LoadLocation("Europe/Moscow")
-	start := time.Now().In(loc)
+	start := clk.Now().In(loc)
 
 	for day := 0; day < daysNumber; day++ {
 		select {
@@ -154,7 +179,7 @@ func ModF_DispatcherActivity(ctx context.Context, daysNumber int, activities []A
 				wg.Add(1)
 				go func(act ActivityRequest, cfg ActivitySettings, offset int) {
 					defer wg.Done()
-					runActivityInPeriod(ctx, start, loc, act, cfg, offset)
+					runActivityInPeriod(ctx, clk, rng, start, loc, act, cfg, offset)
 				}(act, cfg, day)
 			case strings.Contains(act.URL, "reaction"):
 				cfg := reactionCfg
@@ -165,7 +190,7 @@ func ModF_DispatcherActivity(ctx context.Context, daysNumber int, activities []A
 				wg.Add(1)
 				go func(act ActivityRequest, cfg ActivitySettings, offset int) {
 					defer wg.Done()
-					runActivityInPeriod(ctx, start, loc, act, cfg, offset)
+					runActivityInPeriod(ctx, clk, rng, start, loc, act, cfg, offset)
 				}(act, cfg, day)
 			case strings.Contains(act.URL, "unsubscribe"):
 				if unsubscribeCfg.DispatcherStart == "" {
@@ -175,7 +200,7 @@ func ModF_DispatcherActivity(ctx context.Context, daysNumber int, activities []A
 				wg.Add(1)
 				go func(act ActivityRequest, offset int) {
 					defer wg.Done()
-					runAtDispatcherStart(ctx, start, loc, act, unsubscribeCfg.DispatcherStart, offset)
+					runAtDispatcherStart(ctx, clk, start, loc, act, unsubscribeCfg.DispatcherStart, offset)
 				}(act, day)
 			case strings.Contains(act.URL, "accounts_sessions_disconnect"):
 				if disconnectCfg.DispatcherStart == "" {
@@ -185,7 +210,7 @@ func ModF_DispatcherActivity(ctx context.Context, daysNumber int, activities []A
 				wg.Add(1)
 				go func(act ActivityRequest, offset int) {
 					defer wg.Done()
-					runAtDispatcherStart(ctx, start, loc, act, disconnectCfg.DispatcherStart, offset)
+					runAtDispatcherStart(ctx, clk, start, loc, act, disconnectCfg.DispatcherStart, offset)
 				}(act, day)
 			default:
 				continue
@@ -196,19 +221,24 @@ func ModF_DispatcherActivity(ctx context.Context, daysNumber int, activities []A
 	}
 }
 
+// dispatcherStartTarget возвращает момент запуска на день base+offset по МСК.
+func dispatcherStartTarget(base time.Time, loc *time.Location, startTime time.Time, offset int) time.Time {
+	currentDay := base.AddDate(0, 0, offset).In(loc)
+	return time.Date(currentDay.Year(), currentDay.Month(), currentDay.Day(), startTime.Hour(), startTime.Minute(), 0, 0, loc)
+}
+
 // runAtDispatcherStart выполняет запрос act в указанное время по МСК.
 // Используем отдельную функцию, чтобы переиспользовать логику для разных типов действий.
-func runAtDispatcherStart(ctx context.Context, base time.Time, loc *time.Location, act ActivityRequest, dispatcherStart string, offset int) {
+func runAtDispatcherStart(ctx context.Context, clk clock.Clock, base time.Time, loc *time.Location, act ActivityRequest, dispatcherStart string, offset int) {
 	startTime, err := time.Parse("15:04", dispatcherStart)
 	if err != nil {
 		return
 	}
 
 	// Рассчитываем целевое время запуска на текущий день.
-	currentDay := base.AddDate(0, 0, offset).In(loc)
-	target := time.Date(currentDay.Year(), currentDay.Month(), currentDay.Day(), startTime.Hour(), startTime.Minute(), 0, 0, loc)
+	target := dispatcherStartTarget(base, loc, startTime, offset)
 
-	now := time.Now().In(loc)
+	now := clk.Now().In(loc)
 	if target.Before(now) {
 		// Если время уже прошло, выполнять задачу бессмысленно.
 		return
@@ -216,18 +246,11 @@ func runAtDispatcherStart(ctx context.Context, base time.Time, loc *time.Locatio
 
 	if sleep := target.Sub(now); sleep > 0 {
 		select {
-		case <-time.After(sleep):
+		case <-clk.After(sleep):
 		case <-ctx.Done():
 			return
 		}
 	}
 
-	payload, _ := json.Marshal(act.RequestBody)
-	req, err := http.NewRequestWithContext(ctx, "POST", act.URL, bytes.NewBuffer(payload))
-	if err != nil {
-		return
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+bearerToken)
-	http.DefaultClient.Do(req)
+	sendActivityRequest(ctx, act)
 }
@@ -0,0 +1,151 @@
+package module
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"atg_go/pkg/clock"
+)
+
+// mustClock разбирает время в формате HH:MM так же, как конфигурация диспетчера.
+func mustClock(t *testing.T, v string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse("15:04", v)
+	if err != nil {
+		t.Fatalf("некорректное время %q: %v", v, err)
+	}
+	return parsed
+}
+
+// TestPlanActivityWindow проверяет расчёт оставшейся доли окна и смещения по дням.
+func TestPlanActivityWindow(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	base := time.Date(2025, 9, 8, 8, 0, 0, 0, loc)
+
+	cases := []struct {
+		name      string
+		now       time.Time
+		offset    int
+		total     int
+		ok        bool
+		wantStart time.Time
+		wantDur   time.Duration
+		wantCount int
+	}{
+		{
+			name: "до начала окна выполняется весь объём", now: base, total: 10, ok: true,
+			wantStart: time.Date(2025, 9, 8, 10, 0, 0, 0, loc), wantDur: 8 * time.Hour, wantCount: 10,
+		},
+		{
+			name: "середина окна даёт половину", now: time.Date(2025, 9, 8, 14, 0, 0, 0, loc), total: 10, ok: true,
+			wantStart: time.Date(2025, 9, 8, 14, 0, 0, 0, loc), wantDur: 4 * time.Hour, wantCount: 5,
+		},
+		{
+			name: "доля округляется вверх", now: time.Date(2025, 9, 8, 17, 0, 0, 0, loc), total: 10, ok: true,
+			wantStart: time.Date(2025, 9, 8, 17, 0, 0, 0, loc), wantDur: time.Hour, wantCount: 2,
+		},
+		{
+			name: "окно закончилось", now: time.Date(2025, 9, 8, 18, 30, 0, 0, loc), total: 10, ok: false,
+		},
+		{
+			name: "следующий день при смещении", now: time.Date(2025, 9, 8, 18, 30, 0, 0, loc), offset: 1, total: 7, ok: true,
+			wantStart: time.Date(2025, 9, 9, 10, 0, 0, 0, loc), wantDur: 8 * time.Hour, wantCount: 7,
+		},
+		{
+			name: "смещение через границу месяца", now: base, offset: 23, total: 3, ok: true,
+			wantStart: time.Date(2025, 10, 1, 10, 0, 0, 0, loc), wantDur: 8 * time.Hour, wantCount: 3,
+		},
+		{
+			name: "нулевой объём", now: base, total: 0, ok: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, ok := planActivityWindow(tc.now, base, loc, mustClock(t, "10:00"), mustClock(t, "18:00"), tc.total, tc.offset)
+			if ok != tc.ok {
+				t.Fatalf("ok=%v, ожидалось %v", ok, tc.ok)
+			}
+			if !ok {
+				return
+			}
+			if !w.start.Equal(tc.wantStart) || w.duration != tc.wantDur || w.count != tc.wantCount {
+				t.Fatalf("получено start=%v duration=%v count=%d, ожидалось start=%v duration=%v count=%d",
+					w.start, w.duration, w.count, tc.wantStart, tc.wantDur, tc.wantCount)
+			}
+		})
+	}
+}
+
+// TestDispatcherStartTarget проверяет расчёт времени запуска для разных дней.
+func TestDispatcherStartTarget(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	// База в UTC намеренно приходится на другой календарный день, чем в МСК
+	base := time.Date(2025, 9, 7, 22, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		offset int
+		want   time.Time
+	}{
+		{0, time.Date(2025, 9, 8, 3, 15, 0, 0, loc)},
+		{1, time.Date(2025, 9, 9, 3, 15, 0, 0, loc)},
+		{30, time.Date(2025, 10, 8, 3, 15, 0, 0, loc)},
+	}
+	for _, tc := range cases {
+		got := dispatcherStartTarget(base, loc, mustClock(t, "03:15"), tc.offset)
+		if !got.Equal(tc.want) {
+			t.Fatalf("смещение %d: получено %v, ожидалось %v", tc.offset, got, tc.want)
+		}
+	}
+}
+
+// TestRunAtDispatcherStartWaitsForClock проверяет, что запрос уходит только когда часы дошли до времени запуска.
+func TestRunAtDispatcherStartWaitsForClock(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	loc := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2025, 9, 8, 9, 0, 0, 0, loc)
+	fake := clock.NewFake(now)
+
+	done := make(chan struct{})
+	go func() {
+		runAtDispatcherStart(context.Background(), fake, now, loc, ActivityRequest{URL: srv.URL}, "09:30", 0)
+		close(done)
+	}()
+
+	// Ждём, пока функция встанет на таймер
+	deadline := time.Now().Add(time.Second)
+	for fake.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("функция не начала ожидание")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("запрос отправлен раньше времени")
+	}
+
+	fake.Advance(30 * time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("функция не завершилась после наступления времени")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("ожидался один запрос, получено %d", calls.Load())
+	}
+
+	// Время запуска уже прошло — запрос не отправляется
+	runAtDispatcherStart(context.Background(), fake, now, loc, ActivityRequest{URL: srv.URL}, "09:00", 0)
+	if calls.Load() != 1 {
+		t.Fatalf("просроченный запуск не должен выполняться")
+	}
+}
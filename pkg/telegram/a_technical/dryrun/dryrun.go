@@ -0,0 +1,124 @@
+// Package dryrun реализует режим, в котором изменяющие запросы к Telegram
+// не отправляются, а журналируются в simulated_actions. Читающие запросы
+// выполняются как обычно, поэтому прогон на стенде повторяет реальную логику.
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"atg_go/pkg/storage"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+)
+
+// global включает режим для всех запросов процесса (ATG_DRY_RUN).
+var global atomic.Bool
+
+// SetGlobal включает или выключает режим для всего процесса.
+func SetGlobal(enabled bool) {
+	global.Store(enabled)
+}
+
+// Global сообщает, включён ли режим для всего процесса.
+func Global() bool {
+	return global.Load()
+}
+
+type ctxKey struct{}
+
+// WithContext помечает контекст как выполняемый в режиме dry-run.
+// Так режим включается для отдельного HTTP-запроса.
+func WithContext(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, ctxKey{}, enabled)
+}
+
+// Enabled сообщает, действует ли режим для ctx: глобально или для конкретного запроса.
+func Enabled(ctx context.Context) bool {
+	if global.Load() {
+		return true
+	}
+	v, _ := ctx.Value(ctxKey{}).(bool)
+	return v
+}
+
+// stub возвращает заглушку ответа для изменяющего запроса или nil, если запрос читающий.
+func stub(input bin.Encoder) bin.Encoder {
+	switch req := input.(type) {
+	case *tg.ChannelsJoinChannelRequest,
+		*tg.ChannelsLeaveChannelRequest,
+		*tg.MessagesImportChatInviteRequest,
+		*tg.MessagesDeleteChatUserRequest,
+		*tg.MessagesSendMessageRequest,
+		*tg.MessagesSendMediaRequest,
+		*tg.MessagesSendReactionRequest,
+		*tg.MessagesForwardMessagesRequest:
+		return &tg.Updates{}
+	case *tg.AccountUpdateNotifySettingsRequest,
+		*tg.AccountResetAuthorizationRequest,
+		*tg.AuthResetAuthorizationsRequest:
+		return &tg.BoolTrue{}
+	case *tg.AccountUpdateProfileRequest,
+		*tg.AccountUpdateUsernameRequest:
+		return &tg.UserEmpty{}
+	case *tg.PhotosUploadProfilePhotoRequest:
+		return &tg.PhotosPhoto{Photo: &tg.PhotoEmpty{}}
+	case *tg.MessagesGetMessagesViewsRequest:
+		// Без increment это обычное чтение счётчиков, с ним — засчитанный просмотр
+		if !req.Increment {
+			return nil
+		}
+		views := make([]tg.MessageViews, len(req.ID))
+		return &tg.MessagesMessageViews{Views: views}
+	}
+	return nil
+}
+
+// Method возвращает имя метода Telegram API для журнала.
+func Method(input bin.Encoder) string {
+	if t, ok := input.(interface{ TypeName() string }); ok {
+		return t.TypeName()
+	}
+	return fmt.Sprintf("%T", input)
+}
+
+// Middleware перехватывает изменяющие запросы аккаунта accountID, когда режим включён.
+// db может быть nil — тогда перехваченные запросы только логируются.
+func Middleware(db *storage.DB, accountID int) telegram.Middleware {
+	return telegram.MiddlewareFunc(func(next tg.Invoker) telegram.InvokeFunc {
+		return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+			if !Enabled(ctx) {
+				return next.Invoke(ctx, input, output)
+			}
+			resp := stub(input)
+			if resp == nil {
+				return next.Invoke(ctx, input, output)
+			}
+
+			method := Method(input)
+			log.Printf("[DRY RUN] аккаунт %d: %s не отправлен", accountID, method)
+			if db != nil {
+				payload, err := json.Marshal(input)
+				if err != nil {
+					log.Printf("[DRY RUN] аккаунт %d: параметры %s не сериализуются: %v", accountID, method, err)
+					payload = nil
+				}
+				if err := db.RecordSimulatedAction(ctx, accountID, method, payload); err != nil {
+					log.Printf("[DRY RUN] аккаунт %d: не удалось сохранить %s: %v", accountID, method, err)
+				}
+			}
+
+			// Ответ проходит через обычное декодирование, чтобы вызывающий код получил валидное значение
+			var buf bin.Buffer
+			if err := resp.Encode(&buf); err != nil {
+				return fmt.Errorf("dry-run: заглушка %s: %w", method, err)
+			}
+			return output.Decode(&buf)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package dryrun
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+)
+
+// countingInvoker считает запросы, дошедшие до Telegram.
+type countingInvoker struct {
+	calls int
+}
+
+func (i *countingInvoker) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	i.calls++
+	// Ответ на getState нужен, чтобы проверить прохождение читающих запросов
+	if st, ok := output.(*tg.UpdatesState); ok {
+		st.Pts = 42
+	}
+	return nil
+}
+
+func client(next tg.Invoker) *tg.Client {
+	return tg.NewClient(Middleware(nil, 1).Handle(next))
+}
+
+// TestMiddlewareStubsMutations проверяет, что изменяющие запросы получают заглушку
+// и не уходят в Telegram, а читающие выполняются как обычно.
+func TestMiddlewareStubsMutations(t *testing.T) {
+	next := &countingInvoker{}
+	api := client(next)
+	ctx := WithContext(context.Background(), true)
+
+	if _, err := api.ChannelsJoinChannel(ctx, &tg.InputChannel{ChannelID: 1}); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if _, err := api.MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{Peer: &tg.InputPeerSelf{}, Message: "x"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if ok, err := api.AccountResetAuthorization(ctx, 5); err != nil || !ok {
+		t.Fatalf("reset authorization: %v, %v", ok, err)
+	}
+	if _, err := api.AccountUpdateProfile(ctx, &tg.AccountUpdateProfileRequest{}); err != nil {
+		t.Fatalf("update profile: %v", err)
+	}
+	views, err := api.MessagesGetMessagesViews(ctx, &tg.MessagesGetMessagesViewsRequest{Peer: &tg.InputPeerSelf{}, ID: []int{1, 2}, Increment: true})
+	if err != nil || len(views.Views) != 2 {
+		t.Fatalf("views: %+v, %v", views, err)
+	}
+	if next.calls != 0 {
+		t.Fatalf("в dry-run изменяющие запросы не должны отправляться, отправлено %d", next.calls)
+	}
+
+	state, err := api.UpdatesGetState(ctx)
+	if err != nil || state.Pts != 42 || next.calls != 1 {
+		t.Fatalf("читающий запрос должен пройти: %+v, %v, вызовов %d", state, err, next.calls)
+	}
+}
+
+// TestMiddlewareDisabled проверяет, что без режима запросы отправляются.
+func TestMiddlewareDisabled(t *testing.T) {
+	next := &countingInvoker{}
+	api := client(next)
+	if _, err := api.AccountResetAuthorization(context.Background(), 5); err != nil {
+		t.Fatalf("reset authorization: %v", err)
+	}
+	if next.calls != 1 {
+		t.Fatalf("без dry-run запрос должен уйти в Telegram, вызовов %d", next.calls)
+	}
+}
+
+// TestGlobal проверяет глобальное включение режима.
+func TestGlobal(t *testing.T) {
+	SetGlobal(true)
+	defer SetGlobal(false)
+	if !Enabled(context.Background()) {
+		t.Fatal("глобальный режим должен действовать для любого контекста")
+	}
+}
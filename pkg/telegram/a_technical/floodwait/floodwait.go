@@ -0,0 +1,215 @@
+// Package floodwait отслеживает ограничения Telegram FLOOD_WAIT по аккаунтам.
+// Middleware запоминает, до какого момента аккаунт заблокирован, сохраняет это в accounts.floodwait_until
+// и до истечения срока отклоняет запросы аккаунта без обращения к Telegram.
+// SLOWMODE_WAIT относится к одному чату, поэтому аккаунт из-за него не блокируется.
+package floodwait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"atg_go/models"
+	"atg_go/pkg/clock"
+	"atg_go/pkg/storage"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+// ErrActive означает, что аккаунт ещё находится под ограничением Telegram.
+var ErrActive = errors.New("аккаунт под флуд-вейтом")
+
+// ErrSlowMode означает, что в чате включён медленный режим и писать в него пока нельзя.
+// Остальные запросы аккаунта выполняются как обычно.
+var ErrSlowMode = errors.New("медленный режим чата")
+
+// errSlowMode — тип ошибки Telegram о медленном режиме чата.
+const errSlowMode = "SLOWMODE_WAIT"
+
+// inlineWait — ожидания не длиннее этого выдерживаются внутри вызова с одной повторной попыткой.
+const inlineWait = 5 * time.Second
+
+// limitedWait — с такого ожидания аккаунт получает статус limited; короткие флуд-вейты
+// не засоряют историю аккаунта.
+const limitedWait = 10 * time.Minute
+
+// clk позволяет подменять время в тестах.
+var clk clock.Clock = clock.System
+
+// Error сообщает, до какого момента аккаунт заблокирован.
+// errors.Is(err, ErrActive) истинно для любой такой ошибки.
+type Error struct {
+	AccountID int
+	Until     time.Time
+	Err       error // Исходная ошибка Telegram; nil, если запрос отклонён по кэшу
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("аккаунт %d под флуд-вейтом до %s: %v", e.AccountID, e.Until.Format(time.RFC3339), e.Err)
+	}
+	return fmt.Sprintf("аккаунт %d под флуд-вейтом до %s", e.AccountID, e.Until.Format(time.RFC3339))
+}
+
+// Unwrap позволяет проверять как ErrActive, так и исходную ошибку Telegram.
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{ErrActive, e.Err}
+	}
+	return []error{ErrActive}
+}
+
+// SlowModeError сообщает, когда в чат снова можно будет написать.
+// errors.Is(err, ErrSlowMode) истинно для любой такой ошибки.
+type SlowModeError struct {
+	Until time.Time
+	Err   error // Исходная ошибка Telegram
+}
+
+func (e *SlowModeError) Error() string {
+	return fmt.Sprintf("медленный режим чата до %s: %v", e.Until.Format(time.RFC3339), e.Err)
+}
+
+// Unwrap позволяет проверять как ErrSlowMode, так и исходную ошибку Telegram.
+func (e *SlowModeError) Unwrap() []error {
+	return []error{ErrSlowMode, e.Err}
+}
+
+// registry хранит сроки ограничений в памяти, чтобы не ходить в БД перед каждым запросом.
+type registry struct {
+	mu    sync.RWMutex
+	until map[int]time.Time
+}
+
+var blocked = &registry{until: make(map[int]time.Time)}
+
+// Until возвращает срок ограничения аккаунта, если оно ещё действует.
+func Until(accountID int) (time.Time, bool) {
+	blocked.mu.RLock()
+	until, ok := blocked.until[accountID]
+	blocked.mu.RUnlock()
+	if !ok {
+		return time.Time{}, false
+	}
+	if !clk.Now().Before(until) {
+		blocked.mu.Lock()
+		if cur, ok := blocked.until[accountID]; ok && cur.Equal(until) {
+			delete(blocked.until, accountID)
+		}
+		blocked.mu.Unlock()
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// Active сообщает, находится ли аккаунт под ограничением.
+func Active(accountID int) bool {
+	_, ok := Until(accountID)
+	return ok
+}
+
+// Mark запоминает ограничение аккаунта. Более ранний срок не сокращает уже известный.
+func Mark(accountID int, until time.Time) {
+	blocked.mu.Lock()
+	defer blocked.mu.Unlock()
+	if cur, ok := blocked.until[accountID]; !ok || until.After(cur) {
+		blocked.until[accountID] = until
+	}
+}
+
+// Load заполняет кэш ограничениями из БД, чтобы они переживали перезапуск сервиса.
+func Load(db *storage.DB) error {
+	waits, err := db.GetActiveFloodWaits(context.Background())
+	if err != nil {
+		return err
+	}
+	for id, until := range waits {
+		Mark(id, until)
+	}
+	return nil
+}
+
+// WaitDuration возвращает время ожидания из ошибки FLOOD_WAIT_X.
+func WaitDuration(err error) (time.Duration, bool) {
+	return tgerr.AsFloodWait(err)
+}
+
+// SlowModeDuration возвращает время ожидания из ошибки SLOWMODE_WAIT_X.
+func SlowModeDuration(err error) (time.Duration, bool) {
+	if rpcErr, ok := tgerr.AsType(err, errSlowMode); ok {
+		return time.Duration(rpcErr.Argument) * time.Second, true
+	}
+	return 0, false
+}
+
+// sleep выдерживает ожидание d или возвращает ошибку отмены ctx.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clk.After(d):
+		return nil
+	}
+}
+
+// Middleware возвращает middleware gotd для аккаунта accountID.
+// db может быть nil — тогда ограничение хранится только в памяти.
+func Middleware(db *storage.DB, accountID int) telegram.Middleware {
+	return telegram.MiddlewareFunc(func(next tg.Invoker) telegram.InvokeFunc {
+		return func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+			if until, ok := Until(accountID); ok {
+				return &Error{AccountID: accountID, Until: until}
+			}
+
+			err := next.Invoke(ctx, input, output)
+			// Медленный режим ограничивает только этот чат: короткое ожидание выдерживаем
+			// и повторяем запрос, длинное возвращаем вызывающему без блокировки аккаунта
+			if d, ok := SlowModeDuration(err); ok && d <= inlineWait {
+				if err := sleep(ctx, d); err != nil {
+					return err
+				}
+				err = next.Invoke(ctx, input, output)
+			}
+			if d, ok := SlowModeDuration(err); ok {
+				return &SlowModeError{Until: clk.Now().Add(d), Err: err}
+			}
+
+			d, ok := WaitDuration(err)
+			if !ok {
+				return err
+			}
+			// Короткое ожидание дешевле выдержать сразу, чем откладывать всю операцию
+			if d <= inlineWait {
+				if err := sleep(ctx, d); err != nil {
+					return err
+				}
+				err = next.Invoke(ctx, input, output)
+				if d, ok = WaitDuration(err); !ok {
+					return err
+				}
+			}
+
+			until := clk.Now().Add(d)
+			Mark(accountID, until)
+			log.Printf("[FLOODWAIT] аккаунт %d: ограничение на %s (до %s)", accountID, d, until.Format(time.RFC3339))
+			if db != nil {
+				if dbErr := db.MarkFloodWait(ctx, accountID, until); dbErr != nil {
+					log.Printf("[FLOODWAIT] аккаунт %d: не удалось сохранить ограничение: %v", accountID, dbErr)
+				}
+				if d >= limitedWait {
+					reason := "FLOOD_WAIT до " + until.Format(time.RFC3339)
+					if _, dbErr := db.SetAccountStatus(ctx, accountID, models.AccountStatusLimited, reason, storage.AccountSourceFloodWait); dbErr != nil {
+						log.Printf("[FLOODWAIT] аккаунт %d: не удалось обновить статус: %v", accountID, dbErr)
+					}
+				}
+			}
+			return &Error{AccountID: accountID, Until: until, Err: err}
+		}
+	})
+}
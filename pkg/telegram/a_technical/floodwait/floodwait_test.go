@@ -0,0 +1,156 @@
+package floodwait
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"atg_go/pkg/clock"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tgerr"
+)
+
+// invoker возвращает ошибки по очереди и считает вызовы.
+type invoker struct {
+	errs  []error
+	calls int
+}
+
+func (i *invoker) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	i.calls++
+	if len(i.errs) == 0 {
+		return nil
+	}
+	err := i.errs[0]
+	i.errs = i.errs[1:]
+	return err
+}
+
+// useFakeClock подменяет время пакета и очищает кэш ограничений.
+func useFakeClock(t *testing.T) *clock.Fake {
+	t.Helper()
+	fake := clock.NewFake(time.Date(2025, 9, 8, 12, 0, 0, 0, time.UTC))
+	prev := clk
+	clk = fake
+	blocked = &registry{until: make(map[int]time.Time)}
+	t.Cleanup(func() { clk = prev })
+	return fake
+}
+
+func invoke(mw telegram.Middleware, next *invoker) error {
+	return mw.Handle(next)(context.Background(), nil, nil)
+}
+
+// TestMiddlewareBlocksAccount проверяет, что длинный FLOOD_WAIT запоминается
+// и следующие запросы отклоняются без обращения к Telegram до окончания срока.
+func TestMiddlewareBlocksAccount(t *testing.T) {
+	fake := useFakeClock(t)
+	next := &invoker{errs: []error{tgerr.New(420, "FLOOD_WAIT_60")}}
+	mw := Middleware(nil, 7)
+
+	err := invoke(mw, next)
+	var fw *Error
+	if !errors.As(err, &fw) || !errors.Is(err, ErrActive) {
+		t.Fatalf("ожидалась ошибка флуд-вейта, получено %v", err)
+	}
+	if _, ok := tgerr.AsFloodWait(err); !ok {
+		t.Fatalf("исходная ошибка Telegram должна оставаться доступной: %v", err)
+	}
+	if want := fake.Now().Add(time.Minute); !fw.Until.Equal(want) {
+		t.Fatalf("срок %v, ожидался %v", fw.Until, want)
+	}
+
+	if err := invoke(mw, next); !errors.Is(err, ErrActive) {
+		t.Fatalf("запрос под ограничением должен отклоняться, получено %v", err)
+	}
+	if next.calls != 1 {
+		t.Fatalf("под ограничением Telegram вызываться не должен, вызовов: %d", next.calls)
+	}
+
+	fake.Advance(time.Minute)
+	if err := invoke(mw, next); err != nil {
+		t.Fatalf("после окончания ограничения запрос должен пройти: %v", err)
+	}
+	if Active(7) {
+		t.Fatal("ограничение должно сняться")
+	}
+}
+
+// TestMiddlewareShortWait проверяет, что короткое ожидание выдерживается внутри вызова.
+func TestMiddlewareShortWait(t *testing.T) {
+	fake := useFakeClock(t)
+	next := &invoker{errs: []error{tgerr.New(420, "SLOWMODE_WAIT_2")}}
+	mw := Middleware(nil, 8)
+
+	done := make(chan error, 1)
+	go func() { done <- invoke(mw, next) }()
+
+	deadline := time.Now().Add(time.Second)
+	for fake.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("middleware не начал ожидание")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	fake.Advance(2 * time.Second)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("повтор после короткого ожидания должен пройти: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("middleware не завершился после ожидания")
+	}
+	if next.calls != 2 {
+		t.Fatalf("ожидалось два вызова, получено %d", next.calls)
+	}
+	if Active(8) {
+		t.Fatal("успешный повтор не должен оставлять ограничение")
+	}
+}
+
+// TestMiddlewareSlowModeKeepsAccount проверяет, что длинный SLOWMODE_WAIT
+// возвращается как ошибка чата и не блокирует остальные запросы аккаунта.
+func TestMiddlewareSlowModeKeepsAccount(t *testing.T) {
+	fake := useFakeClock(t)
+	next := &invoker{errs: []error{tgerr.New(420, "SLOWMODE_WAIT_60")}}
+	mw := Middleware(nil, 10)
+
+	err := invoke(mw, next)
+	var sm *SlowModeError
+	if !errors.As(err, &sm) || !errors.Is(err, ErrSlowMode) {
+		t.Fatalf("ожидалась ошибка медленного режима, получено %v", err)
+	}
+	if errors.Is(err, ErrActive) {
+		t.Fatalf("медленный режим не должен считаться флуд-вейтом: %v", err)
+	}
+	if want := fake.Now().Add(time.Minute); !sm.Until.Equal(want) {
+		t.Fatalf("срок %v, ожидался %v", sm.Until, want)
+	}
+	if Active(10) {
+		t.Fatal("аккаунт не должен блокироваться")
+	}
+	if err := invoke(mw, next); err != nil {
+		t.Fatalf("запрос в другой чат должен пройти: %v", err)
+	}
+	if next.calls != 2 {
+		t.Fatalf("ожидалось два вызова Telegram, получено %d", next.calls)
+	}
+}
+
+// TestMiddlewarePassesOtherErrors проверяет, что прочие ошибки не блокируют аккаунт.
+func TestMiddlewarePassesOtherErrors(t *testing.T) {
+	useFakeClock(t)
+	want := tgerr.New(400, "CHANNEL_INVALID")
+	err := invoke(Middleware(nil, 9), &invoker{errs: []error{want}})
+	if !errors.Is(err, want) || errors.Is(err, ErrActive) {
+		t.Fatalf("ошибка должна вернуться без изменений, получено %v", err)
+	}
+	if Active(9) {
+		t.Fatal("аккаунт не должен блокироваться")
+	}
+}
@@ -15,31 +15,31 @@ import (
 // Modf_OrderLinkUpdate обновляет описание у всех аккаунтов согласно их order_id
 // Если order_id есть, в описание ставится текст из поля url_description соответствующего заказа,
 // иначе описание очищается. Комментарии на русском языке по требованию пользователя.
-func Modf_OrderLinkUpdate(db *storage.DB) error {
+func Modf_OrderLinkUpdate(ctx context.Context, db *storage.DB) error {
 	// Перед основными операциями заполняем отсутствующие channel_tgid у заказов
-	if err := Modf_UpdateOrdersChannelTGID(db); err != nil {
+	if err := Modf_UpdateOrdersChannelTGID(ctx, db); err != nil {
 		log.Printf("[LINK_UPDATE ERROR] обновление channel_tgid: %v", err)
 		return err
 	}
 
 	// Сначала освобождаем аккаунты под мониторингом, если они случайно привязаны к заказам
-	if err := db.ReleaseMonitoringAccounts(); err != nil {
+	if err := db.ReleaseMonitoringAccounts(ctx); err != nil {
 		log.Printf("[LINK_UPDATE ERROR] освобождение мониторинговых аккаунтов: %v", err)
 		return err
 	}
-	if err := db.ReleaseGeneratorCategoryAccounts(); err != nil {
+	if err := db.ReleaseGeneratorCategoryAccounts(ctx); err != nil {
 		log.Printf("[LINK_UPDATE ERROR] освобождение аккаунтов генерации категорий: %v", err)
 		return err
 	}
 
 	// Перед обновлением описаний синхронизируем количество аккаунтов в заказах
-	if err := db.AssignFreeAccountsToOrders(); err != nil {
+	if err := db.AssignFreeAccountsToOrders(ctx); err != nil {
 		log.Printf("[LINK_UPDATE ERROR] назначение аккаунтов: %v", err)
 		return err
 	}
 
 	// Получаем все авторизованные аккаунты
-	accounts, err := db.GetAuthorizedAccounts()
+	accounts, err := db.GetAuthorizedAccounts(ctx)
 	if err != nil {
 		log.Printf("[LINK_UPDATE ERROR] выборка аккаунтов: %v", err)
 		return err
@@ -49,14 +49,14 @@ func Modf_OrderLinkUpdate(db *storage.DB) error {
 		var description string
 		if acc.OrderID != nil {
 			// Получаем текст для описания из заказа (поле url_description)
-			order, err := db.GetOrderByID(*acc.OrderID)
+			order, err := db.GetOrderByID(ctx, *acc.OrderID)
 			if err != nil {
 				log.Printf("[LINK_UPDATE ERROR] заказ %d: %v", *acc.OrderID, err)
 				continue
 			}
 			description = order.URLDescription
 		}
-		if err := updateAccountDescription(db, acc, description); err != nil {
+		if err := updateAccountDescription(ctx, db, acc, description); err != nil {
 			log.Printf("[LINK_UPDATE ERROR] аккаунт %d: %v", acc.ID, err)
 		}
 	}
@@ -65,7 +65,7 @@ func Modf_OrderLinkUpdate(db *storage.DB) error {
 
 // updateAccountDescription устанавливает новое описание (about) для аккаунта
 // Описание берётся из поля url_description заказа
-func updateAccountDescription(db *storage.DB, acc models.Account, description string) error {
+func updateAccountDescription(ctx context.Context, db *storage.DB, acc models.Account, description string) error {
 	// Блокируем аккаунт, чтобы не выполнять параллельные операции
 	if err := accountmutex.LockAccount(acc.ID); err != nil {
 		return err
@@ -78,7 +78,7 @@ func updateAccountDescription(db *storage.DB, acc models.Account, description st
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	return client.Run(ctx, func(ctx context.Context) error {
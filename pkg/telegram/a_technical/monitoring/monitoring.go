@@ -2,16 +2,16 @@ package monitoring
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"math/rand"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"atg_go/models"
+	"atg_go/pkg/clock"
+	"atg_go/pkg/featureflags"
 	"atg_go/pkg/storage"
-	base "atg_go/pkg/telegram/a_technical"
 
 	"github.com/gotd/td/tg"
 )
@@ -22,19 +22,31 @@ type orderInfo struct {
 	url string
 }
 
+// running считает подключения модуля: после перезапуска через реестр старое
+// подключение может завершиться позже нового, поэтому флага недостаточно.
+var running atomic.Int32
+
+// Running сообщает, подключён ли модуль мониторинга к клиенту Telegram.
+func Running() bool {
+	return running.Load() > 0
+}
+
+// rnd — генератор модуля мониторинга; общий для всех планов, поэтому потокобезопасный.
+var rnd = clock.NewRandomRNG()
+
 // randomByPercent возвращает число, равное случайному проценту от base.
 // Диапазон процентов задаётся в min и max, округление вверх или вниз выбирается случайно.
-func randomByPercent(base int, min, max float64) int {
+func randomByPercent(rng clock.RNG, base int, min, max float64) int {
 	if base == 0 {
 		return 0
 	}
-	percent := min + rand.Float64()*(max-min)
+	percent := min + rng.Float64()*(max-min)
 	value := float64(base) * percent / 100
 	floor := int(value)
 	if value == float64(floor) {
 		return floor
 	}
-	if rand.Intn(2) == 0 {
+	if rng.Intn(2) == 0 {
 		return floor
 	}
 	return floor + 1
@@ -42,14 +54,10 @@ func randomByPercent(base int, min, max float64) int {
 
 // Connect присоединяет модуль мониторинга к существующему клиенту Telegram.
 // Предполагается, что клиент и диспетчер уже инициализированы и работают.
-func Connect(ctx context.Context, api *tg.Client, dispatcher *tg.UpdateDispatcher, db *storage.DB, accountID int) {
-	orders, err := db.GetOrdersForMonitoring()
-	if err != nil {
-		log.Printf("[MONITORING] получение заказов: %v", err)
-		return
-	}
-
-	orderMap := make(map[int64]orderInfo)
+// Список заказов поддерживается актуальным через уведомления orders_changed.
+// Функция не блокируется: подписка на каналы заказов выполняется в горутине.
+func Connect(ctx context.Context, api *tg.Client, dispatcher *tg.UpdateDispatcher, db *storage.DB, notifier *storage.Notifier, accountID int) {
+	orders := newOrderRegistry()
 
 	dispatcher.OnNewChannelMessage(func(ctx context.Context, e tg.Entities, upd *tg.UpdateNewChannelMessage) error {
 		msg, ok := upd.Message.(*tg.Message)
@@ -60,20 +68,23 @@ func Connect(ctx context.Context, api *tg.Client, dispatcher *tg.UpdateDispatche
 		if !ok {
 			return nil
 		}
-		if o, ok := orderMap[peer.ChannelID]; ok {
+		if !featureflags.Enabled(featureflags.Monitoring) {
+			return nil
+		}
+		if o, ok := orders.get(peer.ChannelID); ok {
 			postTime := time.Unix(int64(msg.Date), 0)
 			link := strings.TrimSuffix(o.url, "/") + "/" + strconv.Itoa(msg.ID)
 
 			// Считаем фактическое число подписанных аккаунтов для заказа
-			view, err := db.CountOrderSubs(o.id)
+			view, err := db.CountOrderSubs(ctx, o.id)
 			if err != nil {
 				log.Printf("[MONITORING] подсчёт подписчиков заказа %d: %v", o.id, err)
 			}
 
 			// Реакции: от 0.5% до 2% от фактического числа просмотров
-			reaction := randomByPercent(view, 0.5, 2)
+			reaction := randomByPercent(rnd, view, 0.5, 2)
 			// Репосты: от 2% до 10% от фактического числа просмотров
-			repost := randomByPercent(view, 2, 10)
+			repost := randomByPercent(rnd, view, 2, 10)
 
 			// Указатели устанавливаются только при наличии активной аудитории
 			var viewPtr, reactionPtr, repostPtr *int
@@ -92,25 +103,25 @@ func Connect(ctx context.Context, api *tg.Client, dispatcher *tg.UpdateDispatche
 				SubsActiveRepost:   repostPtr,
 			}
 			// Сохраняем пост и получаем его идентификатор для последующей теории
-			postID, err := db.CreateChannelPost(cp)
+			postID, err := db.CreateChannelPost(ctx, cp)
 			if err != nil {
 				log.Printf("[MONITORING] сохранение поста: %v", err)
 			} else {
 				// Формируем прогноз просмотров по группам часов
 				// и ограничиваем суммарное значение фактическим максимумом
-				view1 := randomByPercent(view, 20.6, 25.7)
+				view1 := randomByPercent(rnd, view, 20.6, 25.7)
 				remain := view - view1
-				view23 := randomByPercent(view, 17.2, 21.7)
+				view23 := randomByPercent(rnd, view, 17.2, 21.7)
 				if view23 > remain {
 					view23 = remain
 				}
 				remain -= view23
-				view46 := randomByPercent(view, 14.9, 19.4)
+				view46 := randomByPercent(rnd, view, 14.9, 19.4)
 				if view46 > remain {
 					view46 = remain
 				}
 				remain -= view46
-				view724 := randomByPercent(view, 31.9, 39.4)
+				view724 := randomByPercent(rnd, view, 31.9, 39.4)
 				if view724 > remain {
 					view724 = remain
 				}
@@ -124,17 +135,17 @@ func Connect(ctx context.Context, api *tg.Client, dispatcher *tg.UpdateDispatche
 					Repost24HourTheory:   repost,
 				}
 				// Создаём прогноз и получаем его идентификатор
-				theoryID, err := db.CreateChannelPostTheory(theory)
+				theoryID, err := db.CreateChannelPostTheory(ctx, theory)
 				if err != nil {
 					log.Printf("[MONITORING] сохранение теории просмотров: %v", err)
 				} else {
 					// Создаём запись фактических просмотров с нулевыми значениями
 					fact := models.ChannelPostFact{ChannelPostTheoryID: theoryID}
-					if err := db.CreateChannelPostFact(fact); err != nil {
+					if err := db.CreateChannelPostFact(ctx, fact); err != nil {
 						log.Printf("[MONITORING] сохранение факта просмотров: %v", err)
 					} else {
 						// Планируем просмотры поста по интервалам
-						schedulePostViews(db, cp, theory, theoryID)
+						schedulePostViews(ctx, db, cp, theory, theoryID)
 					}
 				}
 			}
@@ -142,38 +153,18 @@ func Connect(ctx context.Context, api *tg.Client, dispatcher *tg.UpdateDispatche
 		return nil
 	})
 
-	// Подписываемся на каналы заказов и включаем уведомления
-	for _, o := range orders {
-		username, err := base.Modf_ExtractUsername(o.URLDefault)
-		if err != nil {
-			log.Printf("[MONITORING] некорректная ссылка %s: %v", o.URLDefault, err)
-			continue
-		}
-		resolved, err := api.ContactsResolveUsername(ctx, &tg.ContactsResolveUsernameRequest{Username: username})
-		if err != nil {
-			log.Printf("[MONITORING] не удалось получить канал %s: %v", o.URLDefault, err)
-			continue
-		}
-		ch, err := base.Modf_FindChannel(resolved.GetChats())
-		if err != nil {
-			log.Printf("[MONITORING] канал %s не найден: %v", o.URLDefault, err)
-			continue
-		}
-		if err := base.Modf_JoinChannel(ctx, api, ch, db, accountID); err != nil && !strings.Contains(err.Error(), "USER_ALREADY_PARTICIPANT") {
-			log.Printf("[MONITORING] подписка на %s: %v", o.URLDefault, err)
-		}
-		settings := tg.InputPeerNotifySettings{}
-		settings.SetMuteUntil(0)
-		_, err = api.AccountUpdateNotifySettings(ctx, &tg.AccountUpdateNotifySettingsRequest{
-			Peer:     &tg.InputNotifyPeer{Peer: &tg.InputPeerChannel{ChannelID: ch.ID, AccessHash: ch.AccessHash}},
-			Settings: settings,
-		})
-		if err != nil {
-			log.Printf("[MONITORING] уведомления %s: %v", o.URLDefault, err)
-		}
-		if o.ChannelTGID == nil {
-			_ = db.SetOrderChannelTGID(o.ID, fmt.Sprintf("%d", ch.ID))
-		}
-		orderMap[ch.ID] = orderInfo{id: o.ID, url: o.URLDefault}
-	}
+	running.Add(1)
+	go func() {
+		// Клиент завершился или модуль остановлен — обновления больше не приходят
+		<-ctx.Done()
+		running.Add(-1)
+	}()
+
+	go func() {
+		// Сначала подписываемся на изменения, чтобы не пропустить заказы,
+		// созданные во время первичной загрузки
+		subscribeOrders(ctx, api, db, notifier, accountID, orders)
+		// Подписываемся на каналы заказов и включаем уведомления
+		syncOrders(ctx, api, db, accountID, orders)
+	}()
 }
@@ -0,0 +1,102 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"atg_go/models"
+	"atg_go/pkg/clock"
+)
+
+// stubRNG возвращает заранее заданные значения, чтобы тест не зависел от случайности.
+type stubRNG struct {
+	float float64
+	intn  int
+}
+
+func (s stubRNG) Intn(n int) int   { return s.intn % n }
+func (s stubRNG) Float64() float64 { return s.float }
+func (s stubRNG) Int63() int64     { return 0 }
+
+// TestRandomByPercent проверяет выбор процента и округление.
+func TestRandomByPercent(t *testing.T) {
+	cases := []struct {
+		name string
+		rng  clock.RNG
+		base int
+		min  float64
+		max  float64
+		want int
+	}{
+		{"нулевая база", stubRNG{float: 0.5}, 0, 10, 20, 0},
+		{"минимум диапазона без дробной части", stubRNG{float: 0}, 200, 10, 20, 20},
+		{"середина диапазона, округление вниз", stubRNG{float: 0.5, intn: 0}, 33, 10, 20, 4},
+		{"середина диапазона, округление вверх", stubRNG{float: 0.5, intn: 1}, 33, 10, 20, 5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := randomByPercent(tc.rng, tc.base, tc.min, tc.max); got != tc.want {
+				t.Fatalf("получено %d, ожидалось %d", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestPlanPostViews проверяет распределение просмотров по интервалам и число реакций и репостов.
+func TestPlanPostViews(t *testing.T) {
+	postTime := time.Date(2025, 9, 8, 12, 0, 0, 0, time.UTC)
+	post := models.ChannelPost{OrderID: 3, PostDateTime: postTime, PostURL: "https://t.me/test/10"}
+	theory := models.ChannelPostTheory{
+		View1HourTheory:      4,
+		View23HourTheory:     2,
+		View46HourTheory:     0,
+		View724HourTheory:    1,
+		Reaction24HourTheory: 2,
+		Repost24HourTheory:   1,
+	}
+	accounts := []models.Account{{ID: 11}, {ID: 12}}
+
+	actions := planPostViews(clock.NewRNG(1), post, theory, 77, accounts)
+	if len(actions) != 7 {
+		t.Fatalf("ожидалось 7 просмотров, получено %d", len(actions))
+	}
+
+	perColumn := map[string]int{}
+	reactions, reposts := 0, 0
+	for i, a := range actions {
+		var p postViewPayload
+		if err := json.Unmarshal(a.Payload, &p); err != nil {
+			t.Fatalf("некорректный payload: %v", err)
+		}
+		if a.Kind != KindPostView || *a.OrderID != 3 || p.TheoryID != 77 || p.PostURL != post.PostURL {
+			t.Fatalf("неверные параметры действия %d: %+v %+v", i, a, p)
+		}
+		if *a.AccountID != 11 && *a.AccountID != 12 {
+			t.Fatalf("выбран посторонний аккаунт %d", *a.AccountID)
+		}
+		perColumn[p.Column]++
+		if p.React {
+			reactions++
+		}
+		if p.Repost {
+			reposts++
+		}
+	}
+	if perColumn["view_1hour_fact"] != 4 || perColumn["view_2_3hour_fact"] != 2 || perColumn["view_7_24hour_fact"] != 1 {
+		t.Fatalf("неверное распределение по интервалам: %v", perColumn)
+	}
+	// Вероятность растёт до 1 к последнему просмотру, поэтому теория выполняется полностью
+	if reactions != 2 || reposts != 1 {
+		t.Fatalf("ожидалось 2 реакции и 1 репост, получено %d и %d", reactions, reposts)
+	}
+
+	// Первый просмотр — в момент публикации, шаг первого часа — 15 минут
+	if !actions[0].RunAt.Equal(postTime) || !actions[1].RunAt.Equal(postTime.Add(15*time.Minute)) {
+		t.Fatalf("неверное время первых просмотров: %v, %v", actions[0].RunAt, actions[1].RunAt)
+	}
+	// Интервал 2–3 часа начинается через два часа после публикации
+	if !actions[4].RunAt.Equal(postTime.Add(2 * time.Hour)) {
+		t.Fatalf("неверное начало второго интервала: %v", actions[4].RunAt)
+	}
+}
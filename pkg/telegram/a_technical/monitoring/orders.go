@@ -0,0 +1,176 @@
+package monitoring
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"atg_go/models"
+	"atg_go/pkg/storage"
+	base "atg_go/pkg/telegram/a_technical"
+
+	"github.com/gotd/td/tg"
+)
+
+// notifyQueueSize — сколько уведомлений об изменениях заказов ждут обработки, прежде чем
+// их заменит полная синхронизация.
+const notifyQueueSize = 64
+
+// orderRegistry хранит отслеживаемые заказы по ID канала.
+// Карту читает обработчик обновлений Telegram, а меняет подписка на orders_changed,
+// поэтому доступ защищён мьютексом.
+type orderRegistry struct {
+	mu        sync.RWMutex
+	byChannel map[int64]orderInfo
+	byOrder   map[int]int64
+}
+
+func newOrderRegistry() *orderRegistry {
+	return &orderRegistry{byChannel: make(map[int64]orderInfo), byOrder: make(map[int]int64)}
+}
+
+// get возвращает заказ, привязанный к каналу.
+func (r *orderRegistry) get(channelID int64) (orderInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	o, ok := r.byChannel[channelID]
+	return o, ok
+}
+
+// tracked сообщает, отслеживается ли заказ с той же ссылкой.
+func (r *orderRegistry) tracked(orderID int, url string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	channelID, ok := r.byOrder[orderID]
+	return ok && r.byChannel[channelID].url == url
+}
+
+// set привязывает заказ к каналу, заменяя прежнюю привязку заказа.
+func (r *orderRegistry) set(channelID int64, o orderInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.byOrder[o.id]; ok {
+		delete(r.byChannel, old)
+	}
+	r.byChannel[channelID] = o
+	r.byOrder[o.id] = channelID
+}
+
+// remove прекращает отслеживание заказа.
+func (r *orderRegistry) remove(orderID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if channelID, ok := r.byOrder[orderID]; ok {
+		delete(r.byChannel, channelID)
+		delete(r.byOrder, orderID)
+	}
+}
+
+// orderIDs возвращает ID всех отслеживаемых заказов.
+func (r *orderRegistry) orderIDs() []int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]int, 0, len(r.byOrder))
+	for id := range r.byOrder {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// trackOrder подписывает мониторинговый аккаунт на канал заказа, включает уведомления
+// и добавляет заказ в реестр. Повторный вызов для той же ссылки ничего не делает.
+func trackOrder(ctx context.Context, api *tg.Client, db *storage.DB, accountID int, registry *orderRegistry, o models.Order) {
+	if registry.tracked(o.ID, o.URLDefault) {
+		return
+	}
+	username, err := base.Modf_ExtractUsername(o.URLDefault)
+	if err != nil {
+		log.Printf("[MONITORING] некорректная ссылка %s: %v", o.URLDefault, err)
+		return
+	}
+	resolved, err := api.ContactsResolveUsername(ctx, &tg.ContactsResolveUsernameRequest{Username: username})
+	if err != nil {
+		log.Printf("[MONITORING] не удалось получить канал %s: %v", o.URLDefault, err)
+		return
+	}
+	ch, err := base.Modf_FindChannel(resolved.GetChats())
+	if err != nil {
+		log.Printf("[MONITORING] канал %s не найден: %v", o.URLDefault, err)
+		return
+	}
+	if err := base.Modf_JoinChannel(ctx, api, ch, db, accountID); err != nil && !strings.Contains(err.Error(), "USER_ALREADY_PARTICIPANT") {
+		log.Printf("[MONITORING] подписка на %s: %v", o.URLDefault, err)
+	}
+	settings := tg.InputPeerNotifySettings{}
+	settings.SetMuteUntil(0)
+	_, err = api.AccountUpdateNotifySettings(ctx, &tg.AccountUpdateNotifySettingsRequest{
+		Peer:     &tg.InputNotifyPeer{Peer: &tg.InputPeerChannel{ChannelID: ch.ID, AccessHash: ch.AccessHash}},
+		Settings: settings,
+	})
+	if err != nil {
+		log.Printf("[MONITORING] уведомления %s: %v", o.URLDefault, err)
+	}
+	if o.ChannelTGID == nil {
+		_ = db.SetOrderChannelTGID(ctx, o.ID, fmt.Sprintf("%d", ch.ID))
+	}
+	registry.set(ch.ID, orderInfo{id: o.ID, url: o.URLDefault})
+}
+
+// syncOrders приводит реестр к списку заказов из БД: новые заказы подключаются, удалённые исключаются.
+func syncOrders(ctx context.Context, api *tg.Client, db *storage.DB, accountID int, registry *orderRegistry) {
+	orders, err := db.GetOrdersForMonitoring(ctx)
+	if err != nil {
+		log.Printf("[MONITORING] получение заказов: %v", err)
+		return
+	}
+	actual := make(map[int]bool, len(orders))
+	for _, o := range orders {
+		actual[o.ID] = true
+	}
+	for _, id := range registry.orderIDs() {
+		if !actual[id] {
+			registry.remove(id)
+		}
+	}
+	for _, o := range orders {
+		trackOrder(ctx, api, db, accountID, registry, o)
+	}
+}
+
+// subscribeOrders отслеживает изменения заказов через уведомления orders_changed.
+// После переподключения слушателя реестр сверяется с БД целиком.
+func subscribeOrders(ctx context.Context, api *tg.Client, db *storage.DB, notifier *storage.Notifier, accountID int, registry *orderRegistry) {
+	handle := func(n storage.Notification) {
+		if n.Op == storage.NotifyDelete {
+			registry.remove(n.ID)
+			return
+		}
+		o, err := db.GetOrderForMonitoringByID(ctx, n.ID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				// Заказ удалён или у него больше нет ссылки
+				registry.remove(n.ID)
+				return
+			}
+			log.Printf("[MONITORING] получение заказа %d: %v", n.ID, err)
+			return
+		}
+		trackOrder(ctx, api, db, accountID, registry, *o)
+	}
+	resync := func() { syncOrders(ctx, api, db, accountID, registry) }
+
+	// Подключение заказа обращается к Telegram, поэтому уведомления обрабатываются в своей очереди
+	queue := storage.NewNotifyQueue(ctx, notifyQueueSize, resync)
+	unsubscribe, err := notifier.Subscribe("orders_changed", queue.Handler(handle), queue.Resync)
+	if err != nil {
+		log.Printf("[MONITORING] подписка на изменения заказов: %v", err)
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+}
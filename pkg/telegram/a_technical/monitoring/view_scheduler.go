@@ -1,23 +1,99 @@
 package monitoring
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
-	"math/rand"
 	"strconv"
-	"strings"
 	"time"
 
 	"atg_go/models"
+	"atg_go/pkg/clock"
+	"atg_go/pkg/featureflags"
+	"atg_go/pkg/link"
 	"atg_go/pkg/storage"
 	postaction "atg_go/pkg/telegram/a_base/post"
 	view "atg_go/pkg/telegram/a_base/view"
+	schedact "atg_go/pkg/telegram/a_technical/scheduled_actions"
 )
 
+// KindPostView — тип запланированного действия «просмотр поста».
+const KindPostView = "post_view"
+
+// postViewPayload — параметры просмотра, сохраняемые в scheduled_actions.payload.
+type postViewPayload struct {
+	PostURL  string `json:"post_url"`
+	TheoryID int    `json:"theory_id"`
+	Column   string `json:"column"`
+	React    bool   `json:"react"`
+	Repost   bool   `json:"repost"`
+}
+
+// RegisterExecutors подключает к пулу исполнителей действий мониторинга.
+func RegisterExecutors(pool *schedact.Pool, db *storage.DB) {
+	pool.Register(KindPostView, func(ctx context.Context, a models.ScheduledAction) error {
+		return executePostView(ctx, db, a)
+	})
+}
+
+// executePostView выполняет запланированный просмотр и сопутствующие реакцию и репост.
+// Ошибка возвращается только при неудачном просмотре: повтор реакции или репоста
+// после успешного просмотра исказил бы счётчики фактов.
+func executePostView(ctx context.Context, db *storage.DB, a models.ScheduledAction) error {
+	var p postViewPayload
+	if err := json.Unmarshal(a.Payload, &p); err != nil {
+		return fmt.Errorf("некорректные параметры просмотра: %w", err)
+	}
+	if a.AccountID == nil || a.OrderID == nil {
+		return fmt.Errorf("у просмотра не указан аккаунт или заказ")
+	}
+	// Действие могли захватить до паузы или архивирования заказа
+	order, err := db.GetOrderByID(ctx, *a.OrderID)
+	if err != nil {
+		return fmt.Errorf("получение заказа %d: %w", *a.OrderID, err)
+	}
+	if order.Status != models.OrderStatusActive {
+		return fmt.Errorf("%w: заказ %d в статусе %s", schedact.ErrSkip, order.ID, order.Status)
+	}
+	acc, err := db.GetAccountByID(ctx, *a.AccountID)
+	if err != nil {
+		return fmt.Errorf("получение аккаунта %d: %w", *a.AccountID, err)
+	}
+
+	if err := view.ViewPost(ctx, db, *acc, p.PostURL); err != nil {
+		return fmt.Errorf("просмотр поста не выполнен: %w", err)
+	}
+	if err := db.IncrementChannelPostFact(ctx, p.TheoryID, p.Column); err != nil {
+		log.Printf("[MONITORING] обновление факта просмотров: %v", err)
+	}
+	if p.React {
+		if err := postaction.SendReaction(ctx, db, *acc, *a.OrderID, p.PostURL); err != nil {
+			log.Printf("[MONITORING] реакция не выполнена: %v", err)
+		} else if err := db.IncrementChannelPostFact(ctx, p.TheoryID, "reaction_24hour_fact"); err != nil {
+			log.Printf("[MONITORING] обновление факта реакций: %v", err)
+		}
+	}
+	if p.Repost {
+		if err := postaction.SendRepost(ctx, db, *acc, p.PostURL); err != nil {
+			log.Printf("[MONITORING] репост не выполнен: %v", err)
+		} else if err := db.IncrementChannelPostFact(ctx, p.TheoryID, "repost_24hour_fact"); err != nil {
+			log.Printf("[MONITORING] обновление факта репостов: %v", err)
+		}
+	}
+	return nil
+}
+
 // schedulePostViews распределяет просмотры, реакции и репосты поста по времени.
 // Реакции и репосты выполняются вместе с просмотром, но не при каждом просмотре.
-func schedulePostViews(db *storage.DB, post models.ChannelPost, theory models.ChannelPostTheory, theoryID int) {
+// План сохраняется в scheduled_actions, поэтому переживает перезапуск сервиса.
+func schedulePostViews(ctx context.Context, db *storage.DB, post models.ChannelPost, theory models.ChannelPostTheory, theoryID int) {
+	if !featureflags.Enabled(featureflags.PostViewScheduler) {
+		log.Printf("[MONITORING] планирование просмотров выключено флагом %s", featureflags.PostViewScheduler)
+		return
+	}
 	// Определяем ID канала заказа
-	order, err := db.GetOrderByID(post.OrderID)
+	order, err := db.GetOrderByID(ctx, post.OrderID)
 	if err != nil || order.ChannelTGID == nil {
 		log.Printf("[MONITORING] не удалось получить канал заказа: %v", err)
 		return
@@ -28,20 +104,29 @@ func schedulePostViews(db *storage.DB, post models.ChannelPost, theory models.Ch
 		return
 	}
 	// Извлекаем идентификатор поста из ссылки
-	parts := strings.Split(strings.TrimSuffix(post.PostURL, "/"), "/")
-	msgID, err := strconv.Atoi(parts[len(parts)-1])
-	if err != nil {
-		log.Printf("[MONITORING] некорректная ссылка на пост: %v", err)
+	parsed, err := link.Parse(post.PostURL)
+	if err != nil || parsed.MessageID == 0 {
+		log.Printf("[MONITORING] некорректная ссылка на пост %s: %v", post.PostURL, err)
 		return
 	}
+	msgID := parsed.MessageID
 
 	// Выбираем аккаунты, подписанные на канал заказа и ещё не просмотревшие пост
-	accounts, err := db.GetAccountsForPostView(post.OrderID, channelID, msgID)
+	accounts, err := db.GetAccountsForPostView(ctx, post.OrderID, channelID, msgID)
 	if err != nil || len(accounts) == 0 {
 		log.Printf("[MONITORING] не удалось получить аккаунты для просмотров: %v", err)
 		return
 	}
 
+	actions := planPostViews(rnd, post, theory, theoryID, accounts)
+	if err := db.CreateScheduledActions(ctx, actions); err != nil {
+		log.Printf("[MONITORING] сохранение плана просмотров: %v", err)
+	}
+}
+
+// planPostViews строит план просмотров поста: просмотры равномерно распределяются
+// внутри интервалов теории, аккаунты и попадание реакций и репостов выбираются случайно.
+func planPostViews(rng clock.RNG, post models.ChannelPost, theory models.ChannelPostTheory, theoryID int, accounts []models.Account) []models.ScheduledAction {
 	type period struct {
 		count  int
 		start  time.Duration
@@ -65,57 +150,51 @@ func schedulePostViews(db *storage.DB, post models.ChannelPost, theory models.Ch
 	reactionsLeft := theory.Reaction24HourTheory
 	repostsLeft := theory.Repost24HourTheory
 
+	var actions []models.ScheduledAction
 	for _, p := range periods {
 		if p.count <= 0 {
 			continue
 		}
 		step := (p.end - p.start) / time.Duration(p.count)
 		for i := 0; i < p.count; i++ {
-			acc := accounts[rand.Intn(len(accounts))]
-			delay := post.PostDateTime.Add(p.start + step*time.Duration(i)).Sub(time.Now())
-			if delay < 0 {
-				delay = 0
-			}
+			acc := accounts[rng.Intn(len(accounts))]
 
 			// Определяем, нужны ли реакция и репост для данного просмотра
 			react, repost := false, false
 			if viewsLeft > 0 {
-				if reactionsLeft > 0 && rand.Float64() < float64(reactionsLeft)/float64(viewsLeft) {
+				if reactionsLeft > 0 && rng.Float64() < float64(reactionsLeft)/float64(viewsLeft) {
 					react = true
 					reactionsLeft--
 				}
-				if repostsLeft > 0 && rand.Float64() < float64(repostsLeft)/float64(viewsLeft) {
+				if repostsLeft > 0 && rng.Float64() < float64(repostsLeft)/float64(viewsLeft) {
 					repost = true
 					repostsLeft--
 				}
 				viewsLeft--
 			}
 
-			go func(a models.Account, column string, d time.Duration, doReact, doRepost bool) {
-				time.AfterFunc(d, func() {
-					if err := view.ViewPost(db, a, post.PostURL); err != nil {
-						log.Printf("[MONITORING] просмотр поста не выполнен: %v", err)
-						return
-					}
-					if err := db.IncrementChannelPostFact(theoryID, column); err != nil {
-						log.Printf("[MONITORING] обновление факта просмотров: %v", err)
-					}
-					if doReact {
-						if err := postaction.SendReaction(db, a, post.OrderID, post.PostURL); err != nil {
-							log.Printf("[MONITORING] реакция не выполнена: %v", err)
-						} else if err := db.IncrementChannelPostFact(theoryID, "reaction_24hour_fact"); err != nil {
-							log.Printf("[MONITORING] обновление факта реакций: %v", err)
-						}
-					}
-					if doRepost {
-						if err := postaction.SendRepost(db, a, post.PostURL); err != nil {
-							log.Printf("[MONITORING] репост не выполнен: %v", err)
-						} else if err := db.IncrementChannelPostFact(theoryID, "repost_24hour_fact"); err != nil {
-							log.Printf("[MONITORING] обновление факта репостов: %v", err)
-						}
-					}
-				})
-			}(acc, p.column, delay, react, repost)
+			payload, err := json.Marshal(postViewPayload{
+				PostURL:  post.PostURL,
+				TheoryID: theoryID,
+				Column:   p.column,
+				React:    react,
+				Repost:   repost,
+			})
+			if err != nil {
+				log.Printf("[MONITORING] подготовка просмотра: %v", err)
+				continue
+			}
+			orderID, accountID := post.OrderID, acc.ID
+			actions = append(actions, models.ScheduledAction{
+				Kind:      KindPostView,
+				OrderID:   &orderID,
+				AccountID: &accountID,
+				Payload:   payload,
+				// Просмотры в прошлом выполняются сразу: воркер заберёт их при ближайшем опросе
+				RunAt: post.PostDateTime.Add(p.start + step*time.Duration(i)),
+			})
 		}
 	}
+
+	return actions
 }
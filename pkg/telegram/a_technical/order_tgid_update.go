@@ -15,8 +15,8 @@ import (
 
 // Modf_UpdateOrdersChannelTGID заполняет channel_tgid для заказов, где оно отсутствует.
 // Сначала пробует извлечь ID из ссылки, затем обращается к Telegram по username.
-func Modf_UpdateOrdersChannelTGID(db *storage.DB) error {
-	orders, err := db.GetOrdersWithoutChannelTGID()
+func Modf_UpdateOrdersChannelTGID(ctx context.Context, db *storage.DB) error {
+	orders, err := db.GetOrdersWithoutChannelTGID(ctx)
 	if err != nil {
 		return err
 	}
@@ -27,7 +27,7 @@ func Modf_UpdateOrdersChannelTGID(db *storage.DB) error {
 	var pending []models.Order
 	for _, o := range orders {
 		if id := storage.ExtractChannelTGID(o.URLDefault); id != nil {
-			if err := db.SetOrderChannelTGID(o.ID, *id); err != nil {
+			if err := db.SetOrderChannelTGID(ctx, o.ID, *id); err != nil {
 				return err
 			}
 		} else {
@@ -38,7 +38,7 @@ func Modf_UpdateOrdersChannelTGID(db *storage.DB) error {
 		return nil
 	}
 
-	accounts, err := db.GetAuthorizedAccounts()
+	accounts, err := db.GetAuthorizedAccounts(ctx)
 	if err != nil {
 		return err
 	}
@@ -57,7 +57,7 @@ func Modf_UpdateOrdersChannelTGID(db *storage.DB) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	return client.Run(ctx, func(ctx context.Context) error {
@@ -78,7 +78,7 @@ func Modf_UpdateOrdersChannelTGID(db *storage.DB) error {
 				log.Printf("[WARN] канал %s не найден: %v", o.URLDefault, err)
 				continue
 			}
-			if err := db.SetOrderChannelTGID(o.ID, fmt.Sprintf("%d", channel.ID)); err != nil {
+			if err := db.SetOrderChannelTGID(ctx, o.ID, fmt.Sprintf("%d", channel.ID)); err != nil {
 				return err
 			}
 		}
@@ -1,6 +1,9 @@
 package module
 
-import "atg_go/pkg/storage"
+import (
+	"atg_go/pkg/storage"
+	"context"
+)
 
 // Функции ниже сохраняют поддерживаемые типы активности. Отказ от
 // универсального метода заставляет явно добавлять новый тип активности,
@@ -8,19 +11,19 @@ import "atg_go/pkg/storage"
 
 // SaveReactionActivity сохраняет реакцию, что позволяет не передавать
 // строковый тип действия из вызывающего кода и тем самым избегать опечаток.
-func SaveReactionActivity(db *storage.DB, accountID, channelID, messageID int) error {
-	return db.SaveReaction(accountID, channelID, messageID)
+func SaveReactionActivity(ctx context.Context, db *storage.DB, accountID, channelID, messageID int) error {
+	return db.SaveReaction(ctx, accountID, channelID, messageID)
 }
 
 // SaveCommentActivity сохраняет комментарий по той же причине: тип действия
 // фиксирован и не зависит от внешнего ввода.
 // messageID — ID поста, к которому оставлен комментарий.
-func SaveCommentActivity(db *storage.DB, accountID, channelID, messageID int) error {
-	return db.SaveComment(accountID, channelID, messageID)
+func SaveCommentActivity(ctx context.Context, db *storage.DB, accountID, channelID, messageID int) error {
+	return db.SaveComment(ctx, accountID, channelID, messageID)
 }
 
 // SaveViewActivity фиксирует просмотр поста активной аудиторией.
 // messageID — идентификатор поста канала, открытого для увеличения просмотров.
-func SaveViewActivity(db *storage.DB, accountID, channelID, messageID int) error {
-	return db.SaveSubsActiveView(accountID, channelID, messageID)
+func SaveViewActivity(ctx context.Context, db *storage.DB, accountID, channelID, messageID int) error {
+	return db.SaveSubsActiveView(ctx, accountID, channelID, messageID)
 }
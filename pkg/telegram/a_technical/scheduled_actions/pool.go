@@ -0,0 +1,202 @@
+package scheduled_actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"atg_go/models"
+	"atg_go/pkg/storage"
+	"atg_go/pkg/telegram/a_technical/floodwait"
+)
+
+// Executor выполняет действие определённого типа.
+// Ошибка возвращает действие в очередь, пока не исчерпаны попытки.
+type Executor func(ctx context.Context, action models.ScheduledAction) error
+
+// ErrSkip возвращается исполнителем, если действие больше не нужно выполнять
+// (например, заказ приостановлен). Такое действие отменяется без повторов.
+var ErrSkip = errors.New("действие больше не требуется")
+
+// Параметры пула по умолчанию. Аренда продлевается каждые lease/3,
+// поэтому её истечение означает, что воркер действительно остановился.
+const (
+	defaultLease        = 5 * time.Minute
+	defaultPollInterval = time.Second
+	retryBackoff        = time.Minute
+)
+
+// Pool забирает действия из scheduled_actions и выполняет их ограниченным числом воркеров.
+type Pool struct {
+	DB *storage.DB
+
+	workers      int
+	lease        time.Duration
+	pollInterval time.Duration
+
+	mu        sync.RWMutex
+	executors map[string]Executor
+}
+
+// NewPool создаёт пул с указанным числом воркеров.
+func NewPool(db *storage.DB, workers int) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &Pool{
+		DB:           db,
+		workers:      workers,
+		lease:        defaultLease,
+		pollInterval: defaultPollInterval,
+		executors:    make(map[string]Executor),
+	}
+}
+
+// Register связывает тип действия с исполнителем.
+func (p *Pool) Register(kind string, exec Executor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.executors[kind] = exec
+}
+
+// executor возвращает исполнителя для типа действия.
+func (p *Pool) executor(kind string) (Executor, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	exec, ok := p.executors[kind]
+	return exec, ok
+}
+
+// Run опрашивает очередь до отмены контекста. Захватывается ровно столько действий,
+// сколько свободных воркеров, чтобы аренда не истекала у ожидающих в памяти задач.
+func (p *Pool) Run(ctx context.Context) {
+	slots := make(chan struct{}, p.workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		free := p.workers - len(slots)
+		if free == 0 {
+			continue
+		}
+		actions, err := p.DB.ClaimScheduledActions(ctx, free, p.lease)
+		if err != nil {
+			log.Printf("[SCHEDULED ACTIONS] захват действий: %v", err)
+			continue
+		}
+		for _, a := range actions {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(a models.ScheduledAction) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				p.execute(ctx, a)
+			}(a)
+		}
+	}
+}
+
+// execute выполняет одно действие и фиксирует результат в БД.
+// Пока исполнитель работает, аренда продлевается, чтобы долгое действие
+// не захватил повторно другой воркер.
+func (p *Pool) execute(ctx context.Context, a models.ScheduledAction) {
+	exec, ok := p.executor(a.Kind)
+	var err error
+	if !ok {
+		err = fmt.Errorf("неизвестный тип действия %q", a.Kind)
+	} else {
+		execCtx, cancel := context.WithCancel(ctx)
+		lost := make(chan bool, 1)
+		go func() {
+			lost <- p.renewLease(execCtx, cancel, a)
+		}()
+		err = runSafely(execCtx, exec, a)
+		cancel()
+		if <-lost {
+			// Действием уже распоряжается отмена или другой воркер: результат не записываем
+			return
+		}
+	}
+
+	if err == nil {
+		if err := p.DB.CompleteScheduledAction(ctx, a.ID); err != nil {
+			log.Printf("[SCHEDULED ACTIONS] завершение действия %d: %v", a.ID, err)
+		}
+		return
+	}
+	if errors.Is(err, ErrSkip) {
+		log.Printf("[SCHEDULED ACTIONS] действие %d (%s) пропущено: %v", a.ID, a.Kind, err)
+		if err := p.DB.SkipScheduledAction(ctx, a.ID, err.Error()); err != nil {
+			log.Printf("[SCHEDULED ACTIONS] отмена действия %d: %v", a.ID, err)
+		}
+		return
+	}
+
+	log.Printf("[SCHEDULED ACTIONS] действие %d (%s), попытка %d/%d: %v", a.ID, a.Kind, a.Attempts, a.MaxAttempts, err)
+	retryAt := time.Now().Add(retryBackoff * time.Duration(a.Attempts))
+	// Под флуд-вейтом повтор раньше окончания ограничения бесполезен
+	var fw *floodwait.Error
+	if errors.As(err, &fw) && fw.Until.After(retryAt) {
+		retryAt = fw.Until
+	}
+	// То же для медленного режима чата, в который пишет действие
+	var sm *floodwait.SlowModeError
+	if errors.As(err, &sm) && sm.Until.After(retryAt) {
+		retryAt = sm.Until
+	}
+	if err := p.DB.FailScheduledAction(ctx, a.ID, err.Error(), retryAt); err != nil {
+		log.Printf("[SCHEDULED ACTIONS] сохранение ошибки действия %d: %v", a.ID, err)
+	}
+}
+
+// renewLease продлевает аренду действия до отмены ctx. Если аренда потеряна
+// (действие отменено вместе с заказом или перехвачено после истечения), прерывает
+// исполнителя и возвращает true.
+func (p *Pool) renewLease(ctx context.Context, cancel context.CancelFunc, a models.ScheduledAction) bool {
+	ticker := time.NewTicker(p.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+		held, err := p.DB.RenewScheduledActionLease(ctx, a.ID, a.Attempts, p.lease)
+		if err != nil {
+			// Временная ошибка БД: аренды хватит ещё на несколько попыток продления
+			if ctx.Err() == nil {
+				log.Printf("[SCHEDULED ACTIONS] продление аренды действия %d: %v", a.ID, err)
+			}
+			continue
+		}
+		if !held {
+			log.Printf("[SCHEDULED ACTIONS] аренда действия %d потеряна, выполнение прервано", a.ID)
+			cancel()
+			return true
+		}
+	}
+}
+
+// runSafely не даёт панике исполнителя уронить весь пул.
+func runSafely(ctx context.Context, exec Executor, a models.ScheduledAction) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("паника исполнителя: %v", r)
+		}
+	}()
+	return exec(ctx, a)
+}
@@ -2,72 +2,92 @@ package module
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"math/rand"
-	"net/url"
 	"strings"
 	"time"
 
 	"atg_go/models"
+	"atg_go/pkg/link"
 	"atg_go/pkg/storage"
+	"atg_go/pkg/telegram/a_technical/floodwait"
 
 	"github.com/gotd/td/tg"
 )
 
-// ModF_UnsubscribeAll отключает указанное количество каналов и групп у всех аккаунтов.
-func ModF_UnsubscribeAll(db *storage.DB, delay [2]int, limit int) error {
-	rand.Seed(time.Now().UnixNano())
+// UnsubscribeProgress принимает ход отписки по аккаунтам.
+type UnsubscribeProgress interface {
+	SetTotal(n int)
+	SetCurrent(item string)
+	Success()
+	Fail()
+}
 
+// ModF_UnsubscribeAll отключает указанное количество каналов и групп у всех аккаунтов.
+// Возвращает общее число покинутых каналов и групп. При отмене ctx обработка прекращается.
+func ModF_UnsubscribeAll(ctx context.Context, db *storage.DB, delay [2]int, limit int, progress UnsubscribeProgress) (int, error) {
 	// Получаем ссылки из заказов (поле url_default) один раз, чтобы не обращаться к БД при каждой отписке
-	orderLinks, err := db.GetOrdersDefaultURLs()
+	orderLinks, err := db.GetOrdersDefaultURLs(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// Преобразуем ссылки из заказов в множество имён каналов
 	skipChannels := make(map[string]struct{})
 	for _, l := range orderLinks {
-		if name, ok := channelUsernameFromLink(l); ok {
+		if name, ok := channelUsername(l); ok {
 			skipChannels[strings.ToLower(name)] = struct{}{}
 		}
 	}
 
 	// Дополняем множество ссылками, отписка от которых запрещена явно
-	keepLinks, err := db.GetChannelsNotUnsubscribeURLs()
+	keepLinks, err := db.GetChannelsNotUnsubscribeURLs(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	for _, l := range keepLinks {
-		if name, ok := channelUsernameFromLink(l); ok {
+		if name, ok := channelUsername(l); ok {
 			skipChannels[strings.ToLower(name)] = struct{}{}
 		}
 	}
 
 	// Получаем ссылки на донорские каналы, чтобы мониторинговые аккаунты не отписывались от них
-	donorLinks, err := db.GetChannelDonorURLs()
+	donorLinks, err := db.GetChannelDonorURLs(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	donorChannels := make(map[string]struct{})
 	for _, l := range donorLinks {
-		if name, ok := channelUsernameFromLink(l); ok {
+		if name, ok := channelUsername(l); ok {
 			donorChannels[strings.ToLower(name)] = struct{}{}
 		}
 	}
 
 	// Собираем все авторизованные аккаунты, включая мониторинговые
-	accounts, err := db.GetAuthorizedAccounts()
+	accounts, err := db.GetAuthorizedAccounts(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	monitoringAccounts, err := db.GetMonitoringAccounts()
+	monitoringAccounts, err := db.GetMonitoringAccounts(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	accounts = append(accounts, monitoringAccounts...)
+	progress.SetTotal(len(accounts))
 
+	total := 0
 	for _, acc := range accounts {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
 		log.Printf("[UNSUBSCRIBE] аккаунт %d: начало обработки", acc.ID)
+		progress.SetCurrent(fmt.Sprintf("аккаунт %d", acc.ID))
+		if floodwait.Active(acc.ID) {
+			log.Printf("[UNSUBSCRIBE] аккаунт %d под флуд-вейтом, пропуск", acc.ID)
+			progress.Fail()
+			continue
+		}
 
 		// Для мониторинговых аккаунтов объединяем списки каналов, отписка от которых запрещена
 		skip := skipChannels
@@ -81,23 +101,28 @@ func ModF_UnsubscribeAll(db *storage.DB, delay [2]int, limit int) error {
 			}
 		}
 
-		if err := unsubscribeAccount(db, &acc, delay, limit, skip); err != nil {
+		n, err := unsubscribeAccount(ctx, db, &acc, delay, limit, skip)
+		total += n
+		if err != nil {
 			log.Printf("[UNSUBSCRIBE] аккаунт %d: %v", acc.ID, err)
+			progress.Fail()
 		} else {
-			log.Printf("[UNSUBSCRIBE] аккаунт %d: завершено", acc.ID)
+			log.Printf("[UNSUBSCRIBE] аккаунт %d: завершено, покинуто %d", acc.ID, n)
+			progress.Success()
 		}
 	}
-	return nil
+	return total, nil
 }
 
-// unsubscribeAccount выходит из указанного количества каналов и групп для одного аккаунта.
-func unsubscribeAccount(db *storage.DB, acc *models.Account, delay [2]int, limit int, skip map[string]struct{}) error {
+// unsubscribeAccount выходит из указанного количества каналов и групп для одного аккаунта
+// и возвращает, сколько из них удалось покинуть.
+func unsubscribeAccount(ctx context.Context, db *storage.DB, acc *models.Account, delay [2]int, limit int, skip map[string]struct{}) (int, error) {
 	client, err := Modf_AccountInitialization(acc.ApiID, acc.ApiHash, acc.Phone, acc.Proxy, nil, db.Conn, acc.ID, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	ctx := context.Background()
-	return client.Run(ctx, func(ctx context.Context) error {
+	count := 0
+	err = client.Run(ctx, func(ctx context.Context) error {
 		api := tg.NewClient(client)
 		res, err := api.MessagesGetDialogs(ctx, &tg.MessagesGetDialogsRequest{
 			Limit:      100,
@@ -132,7 +157,6 @@ func unsubscribeAccount(db *storage.DB, acc *models.Account, delay [2]int, limit
 			}
 		}
 
-		count := 0
 		for _, raw := range dialogs.GetDialogs() {
 			if count >= limit {
 				break
@@ -147,7 +171,9 @@ func unsubscribeAccount(db *storage.DB, acc *models.Account, delay [2]int, limit
 					if _, banned := skip[strings.ToLower(ch.Username)]; banned {
 						continue
 					}
-					time.Sleep(randomDelay(delay))
+					if err := sleepCtx(ctx, randomDelay(delay)); err != nil {
+						return err
+					}
 					if _, err := api.ChannelsLeaveChannel(ctx, &tg.InputChannel{ChannelID: ch.ID, AccessHash: ch.AccessHash}); err != nil {
 						log.Printf("[UNSUBSCRIBE] аккаунт %d не покинул канал %d: %v", acc.ID, ch.ID, err)
 					} else {
@@ -159,7 +185,9 @@ func unsubscribeAccount(db *storage.DB, acc *models.Account, delay [2]int, limit
 				if _, banned := bannedChats[peer.ChatID]; banned {
 					continue
 				}
-				time.Sleep(randomDelay(delay))
+				if err := sleepCtx(ctx, randomDelay(delay)); err != nil {
+					return err
+				}
 				if _, err := api.MessagesDeleteChatUser(ctx, &tg.MessagesDeleteChatUserRequest{ChatID: peer.ChatID, UserID: &tg.InputUserSelf{}}); err != nil {
 					log.Printf("[UNSUBSCRIBE] аккаунт %d не покинул группу %d: %v", acc.ID, peer.ChatID, err)
 				} else {
@@ -170,6 +198,19 @@ func unsubscribeAccount(db *storage.DB, acc *models.Account, delay [2]int, limit
 		}
 		return nil
 	})
+	return count, err
+}
+
+// sleepCtx ждёт d или отмены контекста.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
 }
 
 // findChannel ищет канал по идентификатору среди чатов.
@@ -190,24 +231,10 @@ func channelAddress(ch *tg.Channel) string {
 	return "адрес недоступен"
 }
 
-// channelUsernameFromLink извлекает имя канала из URL, если это корректная ссылка на Telegram-канал
-func channelUsernameFromLink(link string) (string, bool) {
-	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
-		link = "https://" + link
-	}
-	u, err := url.Parse(link)
-	if err != nil {
-		return "", false
-	}
-	host := strings.ToLower(u.Host)
-	if host != "t.me" && host != "telegram.me" {
-		return "", false
-	}
-	name := strings.Trim(u.Path, "/")
-	if name == "" || strings.Contains(name, "/") || strings.HasPrefix(name, "+") || strings.HasPrefix(name, "joinchat") || strings.HasPrefix(name, "c/") {
-		return "", false
-	}
-	return name, true
+// channelUsername извлекает имя публичного канала из ссылки; прочие ссылки пропускаются
+func channelUsername(raw string) (string, bool) {
+	name, err := link.Username(raw)
+	return name, err == nil
 }
 
 // randomDelay возвращает случайную задержку в заданном диапазоне.
@@ -12,18 +12,26 @@ import (
 	"time"
 
 	"atg_go/models"
+	"atg_go/pkg/secret"
 	"atg_go/pkg/storage"
 
 	"github.com/gotd/td/telegram/auth"
 	"github.com/gotd/td/tg"
 )
 
-const twoFAPassword = "Avgust134"
+// ErrPasswordRequired означает, что у аккаунта включена 2FA, а пароль не задан.
+var ErrPasswordRequired = errors.New("требуется пароль 2FA")
 
+// ErrPasswordInvalid означает, что Telegram отклонил пароль 2FA.
+var ErrPasswordInvalid = errors.New("неверный пароль 2FA")
+
+// AuthHelper отвечает на шаги входа данными конкретного аккаунта.
+// password — расшифрованный пароль 2FA; пустой, если пароль не задан.
 type AuthHelper struct {
 	phone         string
 	code          string
 	phoneCodeHash string
+	password      string
 }
 
 // SignUp реализует auth.UserAuthenticator (для новых регистраций)
@@ -35,8 +43,12 @@ func (a AuthHelper) Phone(ctx context.Context) (string, error) {
 	return a.phone, nil
 }
 
+// Password возвращает пароль 2FA аккаунта или auth.ErrPasswordNotProvided, если его нет.
 func (a AuthHelper) Password(ctx context.Context) (string, error) {
-	return twoFAPassword, nil
+	if a.password == "" {
+		return "", auth.ErrPasswordNotProvided
+	}
+	return a.password, nil
 }
 
 func (a AuthHelper) Code(ctx context.Context, _ *tg.AuthSentCode) (string, error) {
@@ -48,13 +60,12 @@ func (a AuthHelper) AcceptTermsOfService(ctx context.Context, tos tg.HelpTermsOf
 }
 
 // RequestCode отправляет код подтверждения и сохраняет хеш в БД
-func RequestCode(apiID int, apiHash, phone string, proxy *models.Proxy, db *storage.DB, accountID int) (string, error) {
+func RequestCode(ctx context.Context, apiID int, apiHash, phone string, proxy *models.Proxy, db *storage.DB, accountID int) (string, error) {
 	client, err := module.Modf_AccountInitialization(apiID, apiHash, phone, proxy, nil, db.Conn, accountID, nil)
 	if err != nil {
 		return "", err
 	}
 	var phoneCodeHash string
-	ctx := context.Background()
 	err = client.Run(ctx, func(ctx context.Context) error {
 		sentCode, err := client.Auth().SendCode(ctx, phone, auth.SendCodeOptions{})
 		if err != nil {
@@ -63,7 +74,7 @@ func RequestCode(apiID int, apiHash, phone string, proxy *models.Proxy, db *stor
 		if sent, ok := sentCode.(*tg.AuthSentCode); ok {
 			phoneCodeHash = sent.PhoneCodeHash
 			// Сохраняем полученный хеш в БД для дальнейшей авторизации
-			if err := db.UpdatePhoneCodeHash(accountID, phoneCodeHash); err != nil {
+			if err := db.UpdatePhoneCodeHash(ctx, accountID, phoneCodeHash); err != nil {
 				return err
 			}
 		} else {
@@ -75,28 +86,61 @@ func RequestCode(apiID int, apiHash, phone string, proxy *models.Proxy, db *stor
 	return phoneCodeHash, err
 }
 
-func CompleteAuthorization(db *storage.DB, accountID, apiID int, apiHash, phone, code, phoneCodeHash string, proxy *models.Proxy) error {
+// CompleteAuthorization завершает вход кодом. Если у аккаунта включена 2FA, используется
+// password, а при его отсутствии — сохранённый зашифрованный пароль аккаунта.
+// Без пароля возвращается ErrPasswordRequired, при отказе Telegram — ErrPasswordInvalid.
+func CompleteAuthorization(ctx context.Context, db *storage.DB, accountID, apiID int, apiHash, phone, code, phoneCodeHash, password string, proxy *models.Proxy) error {
 	randSrc := rand.New(rand.NewSource(time.Now().UnixNano()))
 	client, err := module.Modf_AccountInitialization(apiID, apiHash, phone, proxy, randSrc, db.Conn, accountID, nil)
 	if err != nil {
 		return err
 	}
-	ctx := context.Background()
+	helper := AuthHelper{phone: phone, code: code, phoneCodeHash: phoneCodeHash, password: password}
 	return client.Run(ctx, func(ctx context.Context) error {
-		if _, err := client.Auth().SignIn(ctx, phone, code, phoneCodeHash); err != nil {
+		if _, err := client.Auth().SignIn(ctx, helper.phone, helper.code, helper.phoneCodeHash); err != nil {
 			if errors.Is(err, auth.ErrPasswordAuthNeeded) {
-				if _, err := client.Auth().Password(ctx, twoFAPassword); err != nil {
+				if helper.password == "" {
+					if helper.password, err = loadTwoFAPassword(ctx, db, accountID); err != nil {
+						return err
+					}
+				}
+				pwd, err := helper.Password(ctx)
+				if errors.Is(err, auth.ErrPasswordNotProvided) {
+					log.Printf("[WARN] Аккаунт %d: требуется пароль 2FA, но он не задан", accountID)
+					return ErrPasswordRequired
+				}
+				if _, err := client.Auth().Password(ctx, pwd); err != nil {
 					log.Printf("[ERROR] Password authentication failed: %v", err)
+					if errors.Is(err, auth.ErrPasswordInvalid) {
+						return ErrPasswordInvalid
+					}
 					return fmt.Errorf("password authentication failed: %w", err)
 				}
-				log.Printf("[INFO] Successfully authorized phone: %s", phone)
+				log.Printf("[INFO] Successfully authorized account: %d", accountID)
 				return nil
 			}
 			log.Printf("[ERROR] Authorization failed: %v", err)
 			return fmt.Errorf("authorization error: %w", err)
 		}
 
-		log.Printf("[INFO] Successfully authorized phone: %s", phone)
+		log.Printf("[INFO] Successfully authorized account: %d", accountID)
 		return nil
 	})
 }
+
+// loadTwoFAPassword читает и расшифровывает сохранённый пароль 2FA аккаунта.
+// Пустая строка означает, что пароль не задан.
+func loadTwoFAPassword(ctx context.Context, db *storage.DB, accountID int) (string, error) {
+	enc, err := db.GetAccountTwoFAPassword(ctx, accountID)
+	if err != nil {
+		return "", fmt.Errorf("получение пароля 2FA: %w", err)
+	}
+	if enc == "" {
+		return "", nil
+	}
+	password, err := secret.Open(enc)
+	if err != nil {
+		return "", fmt.Errorf("расшифровка пароля 2FA: %w", err)
+	}
+	return password, nil
+}
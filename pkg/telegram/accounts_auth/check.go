@@ -2,6 +2,7 @@ package accounts_auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 
@@ -11,34 +12,43 @@ import (
 
 	"github.com/gotd/td/session"
 	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
 )
 
 // Check проверяет, сохранилась ли авторизация аккаунта.
 // При отсутствии сессии или ошибке Telegram фиксируем событие в Sos
-// и сбрасываем флаг авторизации.
-func Check(db *storage.DB, acc models.Account) bool {
+// и сбрасываем флаг авторизации. Если проверку прервала отмена ctx,
+// статус аккаунта не меняется, а результат — false.
+func Check(ctx context.Context, db *storage.DB, acc models.Account) bool {
 	client, err := module.Modf_AccountInitialization(acc.ApiID, acc.ApiHash, acc.Phone, acc.Proxy, nil, db.Conn, acc.ID, nil)
 	if err != nil {
 		// Инициализация клиента без сессии невозможна, считаем аккаунт неавторизованным.
-		log.Printf("[ACCOUNT AUTH CHECK] аккаунт %s: ошибка инициализации: %v", acc.Phone, err)
-		markUnauthorized(db, acc)
+		log.Printf("[ACCOUNT AUTH CHECK] аккаунт %d: ошибка инициализации: %v", acc.ID, err)
+		markUnauthorized(ctx, db, acc, models.AccountStatusUnauthorized, "ошибка инициализации клиента: "+err.Error())
 		return false
 	}
 
-	ctx := context.Background()
 	err = client.Run(ctx, func(ctx context.Context) error {
 		api := tg.NewClient(client)
 		_, err := api.UsersGetFullUser(ctx, &tg.InputUserSelf{})
 		return err
 	})
+	if err != nil && ctx.Err() != nil {
+		log.Printf("[ACCOUNT AUTH CHECK] аккаунт %d: проверка прервана: %v", acc.ID, ctx.Err())
+		return false
+	}
 	if err != nil {
 		// Любая ошибка при запросе означает, что сессия недействительна или отсутствует.
 		if err == session.ErrNotFound {
-			log.Printf("[ACCOUNT AUTH CHECK] аккаунт %s: сессия не найдена", acc.Phone)
+			log.Printf("[ACCOUNT AUTH CHECK] аккаунт %d: сессия не найдена", acc.ID)
 		} else {
-			log.Printf("[ACCOUNT AUTH CHECK] аккаунт %s: ошибка запроса: %v", acc.Phone, err)
+			log.Printf("[ACCOUNT AUTH CHECK] аккаунт %d: ошибка запроса: %v", acc.ID, err)
+		}
+		status, reason, ok := StatusForError(err)
+		if !ok {
+			status, reason = models.AccountStatusUnauthorized, "ошибка проверки авторизации: "+err.Error()
 		}
-		markUnauthorized(db, acc)
+		markUnauthorized(ctx, db, acc, status, reason)
 		return false
 	}
 
@@ -46,13 +56,28 @@ func Check(db *storage.DB, acc models.Account) bool {
 	return true
 }
 
-// markUnauthorized сбрасывает флаг авторизации и пишет сообщение в Sos.
-func markUnauthorized(db *storage.DB, acc models.Account) {
-	if err := db.MarkAccountAsUnauthorized(acc.ID); err != nil {
-		log.Printf("[ACCOUNT AUTH CHECK] ошибка обновления статуса %s: %v", acc.Phone, err)
+// markUnauthorized переводит аккаунт в status и пишет сообщение в Sos.
+func markUnauthorized(ctx context.Context, db *storage.DB, acc models.Account, status, reason string) {
+	if _, err := db.SetAccountStatus(ctx, acc.ID, status, reason, storage.AccountSourceAuth); err != nil {
+		log.Printf("[ACCOUNT AUTH CHECK] ошибка обновления статуса аккаунта %d: %v", acc.ID, err)
 	}
 	msg := fmt.Sprintf("номер %s больше не авторизован в программе", acc.Phone)
-	if err := db.SaveSos(msg); err != nil {
+	if err := db.SaveSos(ctx, msg); err != nil {
 		log.Printf("[ACCOUNT AUTH CHECK] ошибка записи в Sos: %v", err)
 	}
 }
+
+// StatusForError определяет статус аккаунта по ошибке Telegram, однозначно
+// говорящей о потере сессии или блокировке. Для сетевых ошибок и ошибок
+// прокси ok равно false: по ним нельзя судить о самом аккаунте.
+func StatusForError(err error) (status, reason string, ok bool) {
+	switch {
+	case errors.Is(err, session.ErrNotFound):
+		return models.AccountStatusUnauthorized, "сессия не найдена", true
+	case tgerr.Is(err, "USER_DEACTIVATED", "USER_DEACTIVATED_BAN"):
+		return models.AccountStatusDisabled, "аккаунт заблокирован Telegram: " + err.Error(), true
+	case tgerr.Is(err, "AUTH_KEY_UNREGISTERED", "AUTH_KEY_INVALID", "SESSION_REVOKED", "SESSION_EXPIRED"):
+		return models.AccountStatusUnauthorized, "сессия отозвана: " + err.Error(), true
+	}
+	return "", "", false
+}
@@ -6,41 +6,61 @@ import (
 
 	"atg_go/pkg/storage"
 	tech "atg_go/pkg/telegram/a_technical"
+	tgauth "atg_go/pkg/telegram/accounts_auth"
 
 	"github.com/gotd/td/tg"
 )
 
 // CheckAccountsState проверяет доступность всех авторизованных аккаунтов
 // через метод updates.getState. Возвращает список телефонов,
-// к которым программа потеряла доступ.
-func CheckAccountsState(db *storage.DB) ([]string, error) {
-	accounts, err := db.GetAllAuthorizedAccounts()
+// к которым программа потеряла доступ. Аккаунты с истёкшими ограничениями
+// возвращаются в active, а отозванные сессии и блокировки меняют статус аккаунта.
+// При отмене ctx проверка прекращается и возвращается ошибка контекста.
+func CheckAccountsState(ctx context.Context, db *storage.DB) ([]string, error) {
+	if n, err := db.ReleaseExpiredLimits(ctx, storage.AccountSourceStateCheck); err != nil {
+		log.Printf("[ACCOUNTS SESSIONS] снятие истёкших ограничений: %v", err)
+	} else if n > 0 {
+		log.Printf("[ACCOUNTS SESSIONS] сняты истёкшие ограничения у %d аккаунтов", n)
+	}
+
+	accounts, err := db.GetAllAuthorizedAccounts(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	var lost []string
 	for _, acc := range accounts {
+		if err := ctx.Err(); err != nil {
+			return lost, err
+		}
 		client, err := tech.Modf_AccountInitialization(acc.ApiID, acc.ApiHash, acc.Phone, acc.Proxy, nil, db.Conn, acc.ID, nil)
 		if err != nil {
-			log.Printf("[ACCOUNTS SESSIONS] аккаунт %d (%s): ошибка инициализации: %v", acc.ID, acc.Phone, err)
+			log.Printf("[ACCOUNTS SESSIONS] аккаунт %d: ошибка инициализации: %v", acc.ID, err)
 			lost = append(lost, acc.Phone)
 			continue
 		}
 
-		ctx := context.Background()
 		err = client.Run(ctx, func(ctx context.Context) error {
 			api := tg.NewClient(client)
 			_, err := api.UpdatesGetState(ctx)
 			return err
 		})
+		if err != nil && ctx.Err() != nil {
+			// Проверку прервали: доступ к аккаунту не потерян
+			return lost, ctx.Err()
+		}
 		if err != nil {
-			log.Printf("[ACCOUNTS SESSIONS] аккаунт %d (%s): потерян доступ: %v", acc.ID, acc.Phone, err)
+			log.Printf("[ACCOUNTS SESSIONS] аккаунт %d: потерян доступ: %v", acc.ID, err)
 			lost = append(lost, acc.Phone)
+			if status, reason, ok := tgauth.StatusForError(err); ok {
+				if _, err := db.SetAccountStatus(ctx, acc.ID, status, reason, storage.AccountSourceStateCheck); err != nil {
+					log.Printf("[ACCOUNTS SESSIONS] аккаунт %d: обновление статуса: %v", acc.ID, err)
+				}
+			}
 			continue
 		}
 		// Фиксируем успешно проверенный аккаунт.
-		if incErr := db.IncreaseAccountsCheck(); incErr != nil {
+		if incErr := db.IncreaseAccountsCheck(ctx); incErr != nil {
 			log.Printf("[ACCOUNTS SESSIONS] ошибка увеличения счётчика: %v", incErr)
 		}
 	}
@@ -25,8 +25,8 @@ var allowedDeviceModels = map[string]struct{}{
 // чтобы запросы в Telegram не выглядели подозрительно.
 // Сессии отключаются, если они не текущие и их устройство не входит в список разрешённых.
 // minDelay и maxDelay задают границы задержки в секундах.
-func DisconnectSuspiciousSessions(db *storage.DB, minDelay, maxDelay int) (map[string][]string, error) {
-	accounts, err := db.GetAuthorizedAccounts()
+func DisconnectSuspiciousSessions(ctx context.Context, db *storage.DB, minDelay, maxDelay int) (map[string][]string, error) {
+	accounts, err := db.GetAuthorizedAccounts(ctx)
 	if err != nil {
 		// Логируем ошибку, чтобы быстрее найти проблемы с БД
 		log.Printf("[ACCOUNTS SESSIONS DISCONNECT] ошибка получения аккаунтов: %v", err)
@@ -40,7 +40,7 @@ func DisconnectSuspiciousSessions(db *storage.DB, minDelay, maxDelay int) (map[s
 	for _, acc := range accounts {
 		// Перед проверкой сессий убеждаемся, что аккаунт ещё авторизован.
 		// Если авторизация пропала, пропускаем обработку, чтобы не тратить ресурсы впустую.
-		if !tgauth.Check(db, acc) {
+		if !tgauth.Check(ctx, db, acc) {
 			continue
 		}
 
@@ -53,11 +53,10 @@ func DisconnectSuspiciousSessions(db *storage.DB, minDelay, maxDelay int) (map[s
 		client, err := tech.Modf_AccountInitialization(acc.ApiID, acc.ApiHash, acc.Phone, acc.Proxy, nil, db.Conn, acc.ID, nil)
 		if err != nil {
 			// Не прерываем работу из-за одного аккаунта, чтобы обработать остальные
-			log.Printf("[ACCOUNTS SESSIONS DISCONNECT] аккаунт %s: ошибка инициализации: %v", acc.Phone, err)
+			log.Printf("[ACCOUNTS SESSIONS DISCONNECT] аккаунт %d: ошибка инициализации: %v", acc.ID, err)
 			continue
 		}
 
-		ctx := context.Background()
 		if err := client.Run(ctx, func(ctx context.Context) error {
 			api := tg.NewClient(client)
 			auths, err := api.AccountGetAuthorizations(ctx)
@@ -76,26 +75,26 @@ func DisconnectSuspiciousSessions(db *storage.DB, minDelay, maxDelay int) (map[s
 					// Если отключение не удалось, то сессия продолжит работать.
 					// Фиксируем проблему в таблице Sos, чтобы вовремя заметить сбой.
 					msg := fmt.Sprintf("аккаунт %d (%s), устройство %s: %v", acc.ID, acc.Phone, a.DeviceModel, err)
-					if saveErr := db.SaveSos(msg); saveErr != nil {
+					if saveErr := db.SaveSos(ctx, msg); saveErr != nil {
 						log.Printf("[ACCOUNTS SESSIONS DISCONNECT] ошибка записи в Sos: %v", saveErr)
 					}
-					log.Printf("[ACCOUNTS SESSIONS DISCONNECT] аккаунт %s: не удалось отключить %s: %v", acc.Phone, a.DeviceModel, err)
+					log.Printf("[ACCOUNTS SESSIONS DISCONNECT] аккаунт %d: не удалось отключить %s: %v", acc.ID, a.DeviceModel, err)
 					continue
 				}
-				log.Printf("[ACCOUNTS SESSIONS DISCONNECT] аккаунт %s: отключено устройство %s", acc.Phone, a.DeviceModel)
+				log.Printf("[ACCOUNTS SESSIONS DISCONNECT] аккаунт %d: отключено устройство %s", acc.ID, a.DeviceModel)
 				result[acc.Phone] = append(result[acc.Phone], a.DeviceModel)
 				// Увеличиваем счётчик отключённых сессий.
-				if incErr := db.IncreaseAccountsSessionsDisconnect(); incErr != nil {
+				if incErr := db.IncreaseAccountsSessionsDisconnect(ctx); incErr != nil {
 					log.Printf("[ACCOUNTS SESSIONS DISCONNECT] ошибка увеличения счётчика сессий: %v", incErr)
 				}
 			}
 			return nil
 		}); err != nil {
-			log.Printf("[ACCOUNTS SESSIONS DISCONNECT] аккаунт %s: ошибка обработки: %v", acc.Phone, err)
+			log.Printf("[ACCOUNTS SESSIONS DISCONNECT] аккаунт %d: ошибка обработки: %v", acc.ID, err)
 			continue
 		}
 		// Фиксируем успешно проверенный аккаунт.
-		if incErr := db.IncreaseAccountsCheck(); incErr != nil {
+		if incErr := db.IncreaseAccountsCheck(ctx); incErr != nil {
 			log.Printf("[ACCOUNTS SESSIONS DISCONNECT] ошибка увеличения счётчика аккаунтов: %v", incErr)
 		}
 	}
@@ -24,8 +24,8 @@ import (
 // Возвращает ID поста, к которому оставлен комментарий (int),
 // ID исходного канала (int) и ошибку.
 // При неудаче оба идентификатора равны 0.
-func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID int, apiHash string, postsCount int, canSend func(channelID, messageID int) (bool, error), userIDs []int, proxy *models.Proxy) (int, int, error) {
-	log.Printf("[START] Отправка эмодзи в канал %s от имени %s", channelURL, phone)
+func sendComment(ctx context.Context, db *storage.DB, accountID int, phone, channelURL string, apiID int, apiHash string, postsCount int, canSend func(channelID, messageID int) (bool, error), userIDs []int, proxy *models.Proxy) (int, int, error) {
+	log.Printf("[START] Отправка эмодзи в канал %s от имени аккаунта %d", channelURL, accountID)
 
 	// Блокируем аккаунт, чтобы избежать параллельного использования
 	if err := accountmutex.LockAccount(accountID); err != nil {
@@ -49,7 +49,7 @@ func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID
 	}
 
 	// Создаем контекст с таймаутом 60 секунд
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel() // Гарантируем отмену контекста при выходе из функции
 
 	var (
@@ -68,7 +68,7 @@ func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID
 		if err != nil {
 			// При отсутствии канала фиксируем факт в таблице category_channels_delete
 			if strings.Contains(err.Error(), "USERNAME_NOT_OCCUPIED") {
-				_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonChannelMissing)
+				_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonChannelMissing)
 			}
 			return fmt.Errorf("не удалось распознать канал: %w", err)
 		}
@@ -77,7 +77,7 @@ func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID
 		channel, err := module.Modf_FindChannel(resolved.GetChats())
 		if err != nil {
 			// Канал не найден в результатах — сохраняем запись об удалении
-			_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonChannelMissing)
+			_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonChannelMissing)
 			return err
 		}
 
@@ -85,7 +85,7 @@ func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID
 		if errJoinChannel := module.Modf_JoinChannel(ctx, api, channel, db, accountID); errJoinChannel != nil {
 			// Закрытый канал недоступен
 			if tg.IsChannelPrivate(errJoinChannel) || strings.Contains(errJoinChannel.Error(), "CHANNEL_PRIVATE") {
-				_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonChannelClosed)
+				_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonChannelClosed)
 				return errJoinChannel
 			}
 			log.Printf("[ERROR] Не удалось вступить в канал: ID=%d AccessHash=%d Ошибка=%v",
@@ -96,7 +96,7 @@ func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID
 		channelID = int(channel.ID)
 
 		// Получаем из базы ID последнего поста, который мы комментировали
-		lastID, err := db.GetLastCommentMessageID(accountID, channelID)
+		lastID, err := db.GetLastCommentMessageID(ctx, accountID, channelID)
 		if err != nil {
 			return fmt.Errorf("не удалось получить последний ID комментария: %w", err)
 		}
@@ -142,12 +142,12 @@ func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID
 			if err != nil {
 				// Если у канала нет обсуждений, фиксируем это и прерываем обработку
 				if strings.Contains(err.Error(), "discussion chat not found") {
-					_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonDiscussionClosed)
+					_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonDiscussionClosed)
 					return err
 				}
 				// Закрытый канал тоже фиксируется
 				if strings.Contains(err.Error(), "CHANNEL_PRIVATE") {
-					_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonChannelClosed)
+					_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonChannelClosed)
 					return err
 				}
 				continue
@@ -157,7 +157,7 @@ func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID
 			if errJoinDisc := module.Modf_JoinChannel(ctx, api, discussionData.Chat, db, accountID); errJoinDisc != nil {
 				if tg.IsChannelPrivate(errJoinDisc) || strings.Contains(errJoinDisc.Error(), "CHANNEL_PRIVATE") {
 					// Если обсуждение закрыто, фиксируем это и прекращаем обработку
-					_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonDiscussionClosed)
+					_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonDiscussionClosed)
 					return errJoinDisc
 				}
 				log.Printf("[ERROR] Не удалось присоединиться к чату обсуждений: ID=%d Ошибка=%v", discussionData.Chat.ID, errJoinDisc)
@@ -172,7 +172,7 @@ func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID
 					return err
 				}
 				if !allowed {
-					log.Printf("[INFO] Пост %d уже комментирован нашими аккаунтами, пропуск для %s", p.ID, phone)
+					log.Printf("[INFO] Пост %d уже комментирован нашими аккаунтами, пропуск для аккаунта %d", p.ID, accountID)
 					continue
 				}
 			}
@@ -182,7 +182,7 @@ func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID
 				continue
 			}
 			if hasOwn {
-				log.Printf("[INFO] Пост %d уже комментирован нашими аккаунтами, пропуск для %s", p.ID, phone)
+				log.Printf("[INFO] Пост %d уже комментирован нашими аккаунтами, пропуск для аккаунта %d", p.ID, accountID)
 				continue
 			}
 
@@ -197,7 +197,7 @@ func sendComment(db *storage.DB, accountID int, phone, channelURL string, apiID
 			// Сохраняем ID исходного поста (из канала)
 			msgID = p.ID
 			// Записываем активность в таблицу activity по ID поста
-			if err := module.SaveCommentActivity(db, accountID, channelID, msgID); err != nil {
+			if err := module.SaveCommentActivity(ctx, db, accountID, channelID, msgID); err != nil {
 				return fmt.Errorf("не удалось сохранить активность: %w", err)
 			}
 
@@ -224,8 +224,6 @@ var emojiList = []string{
 
 // возвращает случайный элемент из emojiList
 func getRandomEmoji() string {
-	// Инициализация генератора случайных чисел текущим временем
-	rand.Seed(time.Now().UnixNano())
 	// Выбор случайного индекса и возврат соответствующего элемента
 	return emojiList[rand.Intn(len(emojiList))]
 }
@@ -24,8 +24,8 @@ import (
 // сохраняет запись об активности в таблице activity. Возвращает ID сообщения,
 // к которому была поставлена реакция (int), ID исходного канала (int) и
 // ошибку. При неудаче оба идентификатора равны 0.
-func sendReaction(db *storage.DB, accountID int, phone, channelURL string, apiID int, apiHash string, msgCount int, proxy *models.Proxy) (int, int, error) {
-	log.Printf("[START] Отправка реакции в канал %s от имени %s", channelURL, phone)
+func sendReaction(ctx context.Context, db *storage.DB, accountID int, phone, channelURL string, apiID int, apiHash string, msgCount int, proxy *models.Proxy) (int, int, error) {
+	log.Printf("[START] Отправка реакции в канал %s от имени аккаунта %d", channelURL, accountID)
 
 	// Захватываем мьютекс для аккаунта, чтобы исключить параллельное использование
 	if err := accountmutex.LockAccount(accountID); err != nil {
@@ -46,7 +46,7 @@ func sendReaction(db *storage.DB, accountID int, phone, channelURL string, apiID
 		return 0, 0, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	var (
@@ -60,7 +60,7 @@ func sendReaction(db *storage.DB, accountID int, phone, channelURL string, apiID
 		resolved, err := api.ContactsResolveUsername(ctx, &tg.ContactsResolveUsernameRequest{Username: username})
 		if err != nil {
 			if strings.Contains(err.Error(), "USERNAME_NOT_OCCUPIED") {
-				_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonChannelMissing)
+				_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonChannelMissing)
 			}
 			return fmt.Errorf("не удалось распознать канал: %w", err)
 		}
@@ -68,7 +68,7 @@ func sendReaction(db *storage.DB, accountID int, phone, channelURL string, apiID
 		// Находим сам канал по username
 		channel, err := module.Modf_FindChannel(resolved.GetChats())
 		if err != nil {
-			_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonChannelMissing)
+			_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonChannelMissing)
 			return err
 		}
 		channelID = int(channel.ID)
@@ -76,7 +76,7 @@ func sendReaction(db *storage.DB, accountID int, phone, channelURL string, apiID
 		// Пытаемся вступить в канал, чтобы иметь доступ к обсуждению
 		if errJoin := module.Modf_JoinChannel(ctx, api, channel, db, accountID); errJoin != nil {
 			if tg.IsChannelPrivate(errJoin) || strings.Contains(errJoin.Error(), "CHANNEL_PRIVATE") {
-				_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonChannelClosed)
+				_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonChannelClosed)
 				return errJoin
 			}
 			log.Printf("[ERROR] Не удалось вступить в канал: ID=%d Ошибка=%v", channel.ID, errJoin)
@@ -87,16 +87,16 @@ func sendReaction(db *storage.DB, accountID int, phone, channelURL string, apiID
 		if err != nil {
 			errStr := err.Error()
 			if strings.Contains(errStr, "нет чата обсуждения") || strings.Contains(errStr, "не удалось найти чат обсуждения") {
-				_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonDiscussionClosed)
+				_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonDiscussionClosed)
 			} else if strings.Contains(errStr, "CHANNEL_PRIVATE") {
-				_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonChannelClosed)
+				_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonChannelClosed)
 			}
 			return fmt.Errorf("не удалось получить чат обсуждения: %w", err)
 		}
 
 		if errJoin := module.Modf_JoinChannel(ctx, api, discussionChat, db, accountID); errJoin != nil {
 			if tg.IsChannelPrivate(errJoin) || strings.Contains(errJoin.Error(), "CHANNEL_PRIVATE") {
-				_ = db.SaveCategoryChannelDelete(channelURL, models.ReasonDiscussionClosed)
+				_ = db.SaveCategoryChannelDelete(ctx, channelURL, models.ReasonDiscussionClosed)
 				return errJoin
 			}
 			log.Printf("[ERROR] Не удалось вступить в чат обсуждения: ID=%d Ошибка=%v", discussionChat.ID, errJoin)
@@ -118,14 +118,14 @@ func sendReaction(db *storage.DB, accountID int, phone, channelURL string, apiID
 		}
 
 		// Определяем сообщение, которому нужно поставить реакцию
-		targetMsg, err := selectTargetMessage(messages, db, accountID, channelID)
+		targetMsg, err := selectTargetMessage(ctx, messages, db, accountID, channelID)
 		if err != nil {
 			return err
 		}
 
 		// Проверяем, не слишком ли близко текущее сообщение к предыдущему
 		// Передаём ID аккаунта, канала и сообщения
-		canReact, err := db.CanReactOnMessage(accountID, channelID, targetMsg.ID)
+		canReact, err := db.CanReactOnMessage(ctx, accountID, channelID, targetMsg.ID)
 		if err != nil {
 			return fmt.Errorf("не удалось проверить возможность реакции: %w", err)
 		}
@@ -163,7 +163,7 @@ func sendReaction(db *storage.DB, accountID int, phone, channelURL string, apiID
 		// Сохраняем ID сообщения обсуждения (не ID поста канала)
 		reactedMsgID = targetMsg.ID
 		// Записываем активность в таблицу activity, используя ID сообщения обсуждения
-		if err := module.SaveReactionActivity(db, accountID, channelID, reactedMsgID); err != nil {
+		if err := module.SaveReactionActivity(ctx, db, accountID, channelID, reactedMsgID); err != nil {
 			return fmt.Errorf("не удалось сохранить активность: %w", err)
 		}
 
@@ -200,7 +200,7 @@ func pickReaction(base, allowed []string) string {
 // MessagesGetHistory возвращает сообщения от новых к старым, поэтому
 // последовательно проверяем каждое сообщение. Если подходящее не найдено,
 // возвращаем ошибку.
-func selectTargetMessage(messages []*tg.Message, db *storage.DB, accountID, channelID int) (*tg.Message, error) {
+func selectTargetMessage(ctx context.Context, messages []*tg.Message, db *storage.DB, accountID, channelID int) (*tg.Message, error) {
 	if len(messages) == 0 {
 		return nil, fmt.Errorf("нет сообщений для реакции")
 	}
@@ -210,7 +210,7 @@ func selectTargetMessage(messages []*tg.Message, db *storage.DB, accountID, chan
 		}
 		// Проверяем возможность реакции с учётом последнего ID аккаунта
 		// Передаём ID аккаунта, канала и сообщения
-		canReact, err := db.CanReactOnMessage(accountID, channelID, m.ID)
+		canReact, err := db.CanReactOnMessage(ctx, accountID, channelID, m.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -1,15 +1,17 @@
 package invite_activities_statistics
 
 import (
+	"atg_go/models"
 	"atg_go/pkg/storage"
+	"context"
 	"database/sql"
 	"time"
 )
 
 // IsChannelsLimitActive проверяет, запрещены ли новые подписки для аккаунта.
-func IsChannelsLimitActive(db *storage.DB, accountID int) (bool, error) {
+func IsChannelsLimitActive(ctx context.Context, db *storage.DB, accountID int) (bool, error) {
 	var until sql.NullTime
-	err := db.Conn.QueryRow("SELECT channels_limit_until FROM accounts WHERE id = $1", accountID).Scan(&until)
+	err := db.Conn.QueryRowContext(ctx, "SELECT channels_limit_until FROM accounts WHERE id = $1", accountID).Scan(&until)
 	if err != nil {
 		return false, err
 	}
@@ -19,8 +21,14 @@ func IsChannelsLimitActive(db *storage.DB, accountID int) (bool, error) {
 	return time.Now().Before(until.Time), nil
 }
 
-// MarkChannelsLimit устанавливает время, до которого запрещены новые подписки.
-func MarkChannelsLimit(db *storage.DB, accountID int, until time.Time) error {
-	_, err := db.Conn.Exec("UPDATE accounts SET channels_limit_until = $1 WHERE id = $2", until, accountID)
+// MarkChannelsLimit устанавливает время, до которого запрещены новые подписки,
+// и переводит аккаунт в статус limited.
+func MarkChannelsLimit(ctx context.Context, db *storage.DB, accountID int, until time.Time) error {
+	_, err := db.Conn.ExecContext(ctx, "UPDATE accounts SET channels_limit_until = $1 WHERE id = $2", until, accountID)
+	if err != nil {
+		return err
+	}
+	reason := "лимит подписок на каналы до " + until.Format(time.RFC3339)
+	_, err = db.SetAccountStatus(ctx, accountID, models.AccountStatusLimited, reason, storage.AccountSourceChannelsLimit)
 	return err
 }
@@ -2,21 +2,22 @@ package invite_activities_statistics
 
 import (
 	"atg_go/pkg/storage"
+	"context"
 	"time"
 )
 
 // IncrementReaction увеличивает счётчик реакций за текущие сутки на указанное количество.
-func IncrementReaction(db *storage.DB, count int) error {
-	return increment(db, 0, count)
+func IncrementReaction(ctx context.Context, db *storage.DB, count int) error {
+	return increment(ctx, db, 0, count)
 }
 
 // IncrementComment увеличивает счётчик комментариев за текущие сутки на указанное количество.
-func IncrementComment(db *storage.DB, count int) error {
-	return increment(db, count, 0)
+func IncrementComment(ctx context.Context, db *storage.DB, count int) error {
+	return increment(ctx, db, count, 0)
 }
 
 // increment выполняет обновление записи invite_activities_statistics, добавляя переданные значения.
-func increment(db *storage.DB, commentDelta, reactionDelta int) error {
+func increment(ctx context.Context, db *storage.DB, commentDelta, reactionDelta int) error {
 	if commentDelta == 0 && reactionDelta == 0 {
 		return nil
 	}
@@ -27,7 +28,7 @@ func increment(db *storage.DB, commentDelta, reactionDelta int) error {
 	}
 	dayStart := time.Now().In(loc).Truncate(24 * time.Hour)
 
-	_, err = db.Conn.Exec(
+	_, err = db.Conn.ExecContext(ctx,
 		`INSERT INTO invite_activities_statistics (stat_date, comment_mean, reaction_mean, comment_all, reaction_all, account_floodban, account_all)
                  VALUES ($1, 0, 0, $2, $3, 0, 0)
                  ON CONFLICT (stat_date) DO UPDATE SET
@@ -3,12 +3,13 @@ package invite_activities_statistics
 import (
 	"atg_go/models"
 	"atg_go/pkg/storage"
+	"context"
 	"database/sql"
 	"time"
 )
 
 // Calculate обновляет средние показатели за текущие сутки и сохраняет их в таблицу invite_activities_statistics.
-func Calculate(db *storage.DB) (*models.InviteActivitiesStatistics, error) {
+func Calculate(ctx context.Context, db *storage.DB) (*models.InviteActivitiesStatistics, error) {
 	var stat models.InviteActivitiesStatistics
 
 	// Определяем начало суток по московскому времени
@@ -20,13 +21,13 @@ func Calculate(db *storage.DB) (*models.InviteActivitiesStatistics, error) {
 	stat.Date = dayStart
 
 	// Получаем существующую запись или создаём новую
-	err = db.Conn.QueryRow(
+	err = db.Conn.QueryRowContext(ctx,
 		"SELECT id, comment_all, reaction_all FROM invite_activities_statistics WHERE stat_date = $1",
 		dayStart,
 	).Scan(&stat.ID, &stat.CommentAll, &stat.ReactionAll)
 	if err == sql.ErrNoRows {
 		// Создаём запись с нулевыми значениями
-		err = db.Conn.QueryRow(
+		err = db.Conn.QueryRowContext(ctx,
 			"INSERT INTO invite_activities_statistics (stat_date, comment_mean, reaction_mean, comment_all, reaction_all, account_floodban, account_all) VALUES ($1, 0, 0, 0, 0, 0, 0) RETURNING id",
 			dayStart,
 		).Scan(&stat.ID)
@@ -38,12 +39,12 @@ func Calculate(db *storage.DB) (*models.InviteActivitiesStatistics, error) {
 	}
 
 	// Количество авторизованных аккаунтов
-	if err := db.Conn.QueryRow("SELECT COUNT(*) FROM accounts WHERE is_authorized = true").Scan(&stat.AccountAll); err != nil {
+	if err := db.Conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM accounts WHERE is_authorized = true").Scan(&stat.AccountAll); err != nil {
 		return nil, err
 	}
 
 	// Количество аккаунтов во флуд-бане
-	if err := db.Conn.QueryRow("SELECT COUNT(*) FROM accounts WHERE floodwait_until IS NOT NULL AND floodwait_until > NOW()").Scan(&stat.AccountFloodBan); err != nil {
+	if err := db.Conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM accounts WHERE floodwait_until IS NOT NULL AND floodwait_until > NOW()").Scan(&stat.AccountFloodBan); err != nil {
 		return nil, err
 	}
 
@@ -54,7 +55,7 @@ func Calculate(db *storage.DB) (*models.InviteActivitiesStatistics, error) {
 	}
 
 	// Сохраняем обновлённые данные
-	_, err = db.Conn.Exec(
+	_, err = db.Conn.ExecContext(ctx,
 		"UPDATE invite_activities_statistics SET comment_mean = $1, reaction_mean = $2, account_floodban = $3, account_all = $4 WHERE stat_date = $5",
 		stat.CommentMean, stat.ReactionMean, stat.AccountFloodBan, stat.AccountAll, stat.Date,
 	)
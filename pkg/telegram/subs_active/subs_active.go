@@ -15,7 +15,7 @@ import (
 )
 
 // SubscribeAccount подписывает аккаунт на канал заказа по ссылке.
-func SubscribeAccount(db *storage.DB, acc models.Account, url string) error {
+func SubscribeAccount(ctx context.Context, db *storage.DB, acc models.Account, url string) error {
 	// Блокируем аккаунт, чтобы исключить параллельное использование
 	if err := accountmutex.LockAccount(acc.ID); err != nil {
 		return err
@@ -27,7 +27,7 @@ func SubscribeAccount(db *storage.DB, acc models.Account, url string) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	return client.Run(ctx, func(ctx context.Context) error {